@@ -0,0 +1,80 @@
+package disgomux
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// MinAccountAge is an Inhibitor refusing invocations by accounts created less
+// than age ago, a common anti-raid measure
+func MinAccountAge(age time.Duration) Inhibitor {
+	return func(ctx *Context) error {
+		if age <= 0 || ctx.accountAge() >= age {
+			return nil
+		}
+		return &InhibitError{Reason: ctx.mux.localize(
+			ctx, MessageAccountTooNew,
+			"Your account is too new to use that command.",
+		)}
+	}
+}
+
+// MinMembership is an Inhibitor refusing invocations by members who joined
+// the guild less than age ago. Invocations in DMs are let through.
+func MinMembership(age time.Duration) Inhibitor {
+	return func(ctx *Context) error {
+		if age <= 0 || ctx.Message.GuildID == "" {
+			return nil
+		}
+
+		joined, err := ctx.joinedAt()
+		if err != nil || ctx.mux.clock().Now().Sub(joined) >= age {
+			return nil
+		}
+		return &InhibitError{Reason: ctx.mux.localize(
+			ctx, MessageMemberTooNew,
+			"You joined too recently to use that command.",
+		)}
+	}
+}
+
+// GuildAgeGate is an Inhibitor applying the MinAccountAge and MinMembership
+// thresholds of the guild configuration, e.g.
+//
+//	mux.UseInhibitor(disgomux.GuildAgeGate())
+func GuildAgeGate() Inhibitor {
+	return func(ctx *Context) error {
+		if ctx.Message.GuildID == "" {
+			return nil
+		}
+		if err := MinAccountAge(ctx.config.MinAccountAge)(ctx); err != nil {
+			return err
+		}
+		return MinMembership(ctx.config.MinMembership)(ctx)
+	}
+}
+
+// accountAge returns how long ago the account of the invoking user was
+// created, from its snowflake
+func (ctx *Context) accountAge() time.Duration {
+	created, err := discordgo.SnowflakeTimestamp(ctx.Message.Author.ID)
+	if err != nil {
+		return 0
+	}
+	return ctx.mux.clock().Now().Sub(created)
+}
+
+// joinedAt returns when the invoking member joined the guild, from the
+// message if it carries the member, else from Member()
+func (ctx *Context) joinedAt() (time.Time, error) {
+	if member := ctx.Message.Member; member != nil && !member.JoinedAt.IsZero() {
+		return member.JoinedAt, nil
+	}
+
+	member, err := ctx.Member()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return member.JoinedAt, nil
+}
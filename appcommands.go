@@ -0,0 +1,411 @@
+package disgomux
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord limits for application commands
+const (
+	appCommandDescriptionLimit = 100
+	defaultAppDescription      = "No description"
+)
+
+// SyncApplicationCommands makes the slash commands of the bot in the guild (or
+// the global ones for an empty guild ID) match the registered commands:
+// missing ones are created, changed ones updated and ones which are no longer
+// registered deleted. Command help texts become the descriptions, and their
+// arguments the options. With permission sync enabled, their permissions are
+// pushed too.
+func (m *Mux) SyncApplicationCommands(
+	session *discordgo.Session,
+	guildID string,
+) error {
+	appID := session.State.User.ID
+
+	existing, err := session.ApplicationCommands(appID, guildID)
+	if err != nil {
+		return err
+	}
+
+	remote := make(map[string]*discordgo.ApplicationCommand, len(existing))
+	for _, c := range existing {
+		remote[appCommandKey(c)] = c
+	}
+
+	commands, permissions := m.applicationCommands()
+	for _, c := range commands {
+		key := appCommandKey(c)
+		current, ok := remote[key]
+		delete(remote, key)
+
+		switch {
+		case !ok && m.skipSync("ApplicationCommandCreate", guildID, c.Name):
+			current = &discordgo.ApplicationCommand{ID: "dry-run-" + c.Name}
+		case !ok:
+			created, err := session.ApplicationCommandCreate(appID, guildID, c)
+			if err != nil {
+				return fmt.Errorf("Creating command %s: %v", c.Name, err)
+			}
+			current = created
+		case !sameAppCommand(current, c):
+			if m.skipSync("ApplicationCommandEdit", guildID, c.Name) {
+				break
+			}
+			if _, err := session.ApplicationCommandEdit(
+				appID, guildID, current.ID, c,
+			); err != nil {
+				return fmt.Errorf("Updating command %s: %v", c.Name, err)
+			}
+		}
+
+		/* Overwrites only exist in guilds */
+		if m.permissionSync && guildID != "" &&
+			!m.skipSync("ApplicationCommandPermissionsEdit", guildID, c.Name) {
+			if err := session.ApplicationCommandPermissionsEdit(
+				appID, guildID, current.ID, &discordgo.ApplicationCommandPermissionsList{
+					Permissions: permissionOverwrites(guildID, permissions[key]),
+				},
+			); err != nil {
+				return fmt.Errorf("Updating permissions of command %s: %v", c.Name, err)
+			}
+		}
+	}
+
+	for _, c := range remote {
+		if m.skipSync("ApplicationCommandDelete", guildID, c.Name) {
+			continue
+		}
+		if err := session.ApplicationCommandDelete(appID, guildID, c.ID); err != nil {
+			return fmt.Errorf("Deleting command %s: %v", c.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// SetPermissionSync makes SyncApplicationCommands push the permissions of
+// commands to Discord, so it hides them from users who can't run them.
+// Permission bits become the default member permissions; user and role
+// whitelists become command permission overwrites in guilds, as do channel
+// whitelists when they are the only ones. Overwrites can only be edited with
+// a bearer token having the applications.commands.permissions.update scope.
+// Permissions are still checked on invocation.
+func (m *Mux) SetPermissionSync(enabled bool) {
+	m.permissionSync = enabled
+}
+
+// defaultMemberPermissions returns the default member permissions of a
+// command with permissions p, or nil if they are not synced. Commands limited
+// to users or roles are hidden from everyone else.
+func (m *Mux) defaultMemberPermissions(p *CommandPermissions) *int64 {
+	if !m.permissionSync || p == nil {
+		return nil
+	}
+
+	perms := p.Permissions
+	if perms == 0 && len(p.UserIDs) == 0 && len(p.RoleIDs) == 0 {
+		return nil
+	}
+	return &perms
+}
+
+// permissionOverwrites converts the permissions of a command into permission
+// overwrites in the guild
+func permissionOverwrites(
+	guildID string,
+	p *CommandPermissions,
+) []*discordgo.ApplicationCommandPermissions {
+	overwrites := []*discordgo.ApplicationCommandPermissions{}
+	if p == nil {
+		return overwrites
+	}
+
+	for _, id := range p.UserIDs {
+		overwrites = append(overwrites, &discordgo.ApplicationCommandPermissions{
+			ID:         id,
+			Type:       discordgo.ApplicationCommandPermissionTypeUser,
+			Permission: true,
+		})
+	}
+	for _, id := range p.RoleIDs {
+		overwrites = append(overwrites, &discordgo.ApplicationCommandPermissions{
+			ID:         id,
+			Type:       discordgo.ApplicationCommandPermissionTypeRole,
+			Permission: true,
+		})
+	}
+
+	/*
+		Discord requires both the member and the channel to be allowed, so
+		channel whitelists combined with others can't be expressed
+	*/
+	if len(p.ChanIDs) == 0 || len(overwrites) > 0 || p.Permissions != 0 {
+		return overwrites
+	}
+
+	/* The guild ID minus one stands for all channels */
+	id, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		return overwrites
+	}
+	overwrites = append(overwrites, &discordgo.ApplicationCommandPermissions{
+		ID:         strconv.FormatUint(id-1, 10),
+		Type:       discordgo.ApplicationCommandPermissionTypeChannel,
+		Permission: false,
+	})
+	for _, id := range p.ChanIDs {
+		overwrites = append(overwrites, &discordgo.ApplicationCommandPermissions{
+			ID:         id,
+			Type:       discordgo.ApplicationCommandPermissionTypeChannel,
+			Permission: true,
+		})
+	}
+	return overwrites
+}
+
+// applicationCommands converts the registered commands and context menu
+// commands into application commands, sorted by name. Their permissions are
+// returned keyed by appCommandKey.
+func (m *Mux) applicationCommands() (
+	[]*discordgo.ApplicationCommand,
+	map[string]*CommandPermissions,
+) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var commands []*discordgo.ApplicationCommand
+	permissions := make(map[string]*CommandPermissions)
+	for _, c := range m.Commands {
+		settings := c.Settings()
+		options := appOptions(settings.Arguments)
+		if parent, ok := c.(ParentCommand); ok {
+			options = subcommandOptions(parent.Subcommands(), 0)
+		}
+
+		command := &discordgo.ApplicationCommand{
+			Type:                     discordgo.ChatApplicationCommand,
+			Name:                     strings.ToLower(settings.Command),
+			Description:              appDescription(settings.HelpText),
+			Options:                  options,
+			DefaultMemberPermissions: m.defaultMemberPermissions(c.Permissions()),
+		}
+		if names := appNames(settings.NameLocalizations); names != nil {
+			command.NameLocalizations = &names
+		}
+		if descriptions := appDescriptions(
+			settings.DescriptionLocalizations,
+		); descriptions != nil {
+			command.DescriptionLocalizations = &descriptions
+		}
+		commands = append(commands, command)
+		permissions[appCommandKey(command)] = c.Permissions()
+	}
+
+	for _, c := range m.contextMenus {
+		key := contextMenuKey(c.Type.commandType(), c.Name)
+		permissions[key] = c.Permissions
+	}
+	commands = append(commands, m.contextMenuCommands()...)
+
+	sort.Slice(commands, func(i, j int) bool {
+		return appCommandKey(commands[i]) < appCommandKey(commands[j])
+	})
+	return commands, permissions
+}
+
+// appOptions converts an argument spec into application command options
+func appOptions(arguments []Argument) []*discordgo.ApplicationCommandOption {
+	var options []*discordgo.ApplicationCommandOption
+	for _, a := range arguments {
+		option := &discordgo.ApplicationCommandOption{
+			Type:                     a.Type.optionType(),
+			Name:                     strings.ToLower(a.Name),
+			NameLocalizations:        appNames(a.NameLocalizations),
+			Description:              appDescription(a.Description),
+			DescriptionLocalizations: appDescriptions(a.DescriptionLocalizations),
+			Required:                 a.Required,
+			Autocomplete:             a.Autocomplete,
+		}
+
+		for _, c := range a.Choices {
+			option.Choices = append(
+				option.Choices, &discordgo.ApplicationCommandOptionChoice{
+					Name:  c.Name,
+					Value: c.Value,
+				},
+			)
+		}
+
+		/* Bounds are on the value of numbers, the length of strings */
+		switch a.Type {
+		case ArgumentInteger, ArgumentNumber:
+			option.MinValue = a.Min
+			if a.Max != nil {
+				option.MaxValue = *a.Max
+			}
+		case ArgumentString:
+			if a.Min != nil {
+				min := int(*a.Min)
+				option.MinLength = &min
+			}
+			if a.Max != nil {
+				option.MaxLength = int(*a.Max)
+			}
+		}
+
+		options = append(options, option)
+	}
+	return options
+}
+
+// appDescription fits a help text to an application command description,
+// which must be between 1 and 100 characters
+func appDescription(helpText string) string {
+	helpText = strings.TrimSpace(helpText)
+	if helpText == "" {
+		return defaultAppDescription
+	}
+	if i := strings.IndexByte(helpText, '\n'); i >= 0 {
+		helpText = helpText[:i]
+	}
+	return runePrefix(helpText, appCommandDescriptionLimit)
+}
+
+// appNames fits localized names to application command names, which must be
+// lowercase. Returns nil for no localizations.
+func appNames(names map[discordgo.Locale]string) map[discordgo.Locale]string {
+	if len(names) == 0 {
+		return nil
+	}
+
+	fitted := make(map[discordgo.Locale]string, len(names))
+	for locale, name := range names {
+		fitted[locale] = strings.ToLower(name)
+	}
+	return fitted
+}
+
+// appDescriptions fits localized help texts to application command
+// descriptions. Returns nil for no localizations.
+func appDescriptions(
+	descriptions map[discordgo.Locale]string,
+) map[discordgo.Locale]string {
+	if len(descriptions) == 0 {
+		return nil
+	}
+
+	fitted := make(map[discordgo.Locale]string, len(descriptions))
+	for locale, description := range descriptions {
+		fitted[locale] = appDescription(description)
+	}
+	return fitted
+}
+
+// appCommandKey identifies an application command. Commands of different
+// types may share a name.
+func appCommandKey(c *discordgo.ApplicationCommand) string {
+	t := c.Type
+	if t == 0 {
+		t = discordgo.ChatApplicationCommand
+	}
+	return fmt.Sprintf("%d:%s", t, c.Name)
+}
+
+// sameAppCommand reports whether the remote command already matches the local
+// one
+func sameAppCommand(remote, local *discordgo.ApplicationCommand) bool {
+	return remote.Name == local.Name &&
+		remote.Description == local.Description &&
+		sameAppOptions(remote.Options, local.Options) &&
+		sameLocalizations(
+			localizations(remote.NameLocalizations),
+			localizations(local.NameLocalizations),
+		) &&
+		sameLocalizations(
+			localizations(remote.DescriptionLocalizations),
+			localizations(local.DescriptionLocalizations),
+		) &&
+		(local.DefaultMemberPermissions == nil ||
+			sameInt64(remote.DefaultMemberPermissions, local.DefaultMemberPermissions))
+}
+
+// sameAppOptions reports whether two lists of options are equivalent
+func sameAppOptions(a, b []*discordgo.ApplicationCommandOption) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		x, y := a[i], b[i]
+		if x.Type != y.Type || x.Name != y.Name ||
+			x.Description != y.Description || x.Required != y.Required ||
+			x.Autocomplete != y.Autocomplete ||
+			!sameFloat(x.MinValue, y.MinValue) || x.MaxValue != y.MaxValue ||
+			!sameInt(x.MinLength, y.MinLength) || x.MaxLength != y.MaxLength ||
+			!sameAppChoices(x.Choices, y.Choices) ||
+			!sameLocalizations(x.NameLocalizations, y.NameLocalizations) ||
+			!sameLocalizations(x.DescriptionLocalizations, y.DescriptionLocalizations) ||
+			!sameAppOptions(x.Options, y.Options) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameAppChoices reports whether two lists of choices are equivalent. Values
+// are compared as text, as numbers come back from Discord as floats.
+func sameAppChoices(a, b []*discordgo.ApplicationCommandOptionChoice) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Name != b[i].Name ||
+			fmt.Sprint(a[i].Value) != fmt.Sprint(b[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// localizations dereferences optional localizations
+func localizations(l *map[discordgo.Locale]string) map[discordgo.Locale]string {
+	if l == nil {
+		return nil
+	}
+	return *l
+}
+
+// sameLocalizations reports whether two sets of localizations are equal
+func sameLocalizations(a, b map[discordgo.Locale]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for locale, text := range a {
+		if other, ok := b[locale]; !ok || other != text {
+			return false
+		}
+	}
+	return true
+}
+
+// sameInt64 reports whether two optional permission sets are equal
+func sameInt64(a, b *int64) bool {
+	return a == b || (a != nil && b != nil && *a == *b)
+}
+
+// sameFloat reports whether two optional bounds are equal
+func sameFloat(a, b *float64) bool {
+	return a == b || (a != nil && b != nil && *a == *b)
+}
+
+// sameInt reports whether two optional lengths are equal
+func sameInt(a, b *int) bool {
+	return a == b || (a != nil && b != nil && *a == *b)
+}
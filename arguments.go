@@ -0,0 +1,316 @@
+package disgomux
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ArgumentType is the type of a command argument
+type ArgumentType int
+
+// Argument types
+const (
+	ArgumentString ArgumentType = iota
+	ArgumentInteger
+	ArgumentNumber
+	ArgumentBoolean
+	ArgumentUser
+	ArgumentChannel
+	ArgumentRole
+)
+
+// Argument describes an argument a command takes
+type Argument struct {
+	Name, Description string
+	Type              ArgumentType
+	Required          bool
+
+	// Autocomplete makes slash commands ask the command for suggestions while
+	// the argument is typed. The command must be an AutocompleteCommand.
+	Autocomplete bool
+
+	// Choices restricts the argument to a set of values
+	Choices []Choice
+
+	// Min and Max bound the value of integer and number arguments, and the
+	// length of string arguments. nil is unbounded.
+	Min, Max *float64
+
+	// NameLocalizations and DescriptionLocalizations translate the argument
+	// in slash commands
+	NameLocalizations        map[discordgo.Locale]string
+	DescriptionLocalizations map[discordgo.Locale]string
+}
+
+// optionType returns the application command option type of the argument type
+func (t ArgumentType) optionType() discordgo.ApplicationCommandOptionType {
+	switch t {
+	case ArgumentInteger:
+		return discordgo.ApplicationCommandOptionInteger
+	case ArgumentNumber:
+		return discordgo.ApplicationCommandOptionNumber
+	case ArgumentBoolean:
+		return discordgo.ApplicationCommandOptionBoolean
+	case ArgumentUser:
+		return discordgo.ApplicationCommandOptionUser
+	case ArgumentChannel:
+		return discordgo.ApplicationCommandOptionChannel
+	case ArgumentRole:
+		return discordgo.ApplicationCommandOptionRole
+	default:
+		return discordgo.ApplicationCommandOptionString
+	}
+}
+
+var (
+	channelMentionPattern = regexp.MustCompile(`^<#(\d+)>$`)
+	roleMentionPattern    = regexp.MustCompile(`^<@&(\d+)>$`)
+)
+
+// Arg returns the argument with the name in the argument spec of the command,
+// or an empty string if it was not given. In messages, arguments are taken in
+// the order of the spec, the last one swallowing the rest of the message if
+// it is a string. In slash commands, they are the options of the same name.
+func (ctx *Context) Arg(name string) string {
+	v, _ := ctx.argument(name)
+	return v
+}
+
+// ArgInt returns the named argument as an integer
+func (ctx *Context) ArgInt(name string) (int64, error) {
+	v, ok := ctx.argument(name)
+	if !ok {
+		return 0, ErrArgumentMissing
+	}
+
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, &ArgumentError{name, "is not an integer"}
+	}
+	return i, nil
+}
+
+// ArgNumber returns the named argument as a number
+func (ctx *Context) ArgNumber(name string) (float64, error) {
+	v, ok := ctx.argument(name)
+	if !ok {
+		return 0, ErrArgumentMissing
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, &ArgumentError{name, "is not a number"}
+	}
+	return f, nil
+}
+
+// ArgBool returns the named argument as a boolean. yes/no and on/off are
+// accepted along with true/false.
+func (ctx *Context) ArgBool(name string) (bool, error) {
+	v, ok := ctx.argument(name)
+	if !ok {
+		return false, ErrArgumentMissing
+	}
+
+	switch strings.ToLower(v) {
+	case "yes", "on":
+		return true, nil
+	case "no", "off":
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, &ArgumentError{name, "is not a boolean"}
+	}
+	return b, nil
+}
+
+// ArgUser resolves the named argument to a member of the current guild, as
+// ResolveUser() does
+func (ctx *Context) ArgUser(name string) (*discordgo.Member, error) {
+	v, ok := ctx.argument(name)
+	if !ok {
+		return nil, ErrArgumentMissing
+	}
+	return ctx.ResolveUser(v)
+}
+
+// ArgChannel resolves the named argument, a channel mention or ID, to a
+// channel
+func (ctx *Context) ArgChannel(name string) (*discordgo.Channel, error) {
+	v, ok := ctx.argument(name)
+	if !ok {
+		return nil, ErrArgumentMissing
+	}
+
+	id := v
+	if match := channelMentionPattern.FindStringSubmatch(v); match != nil {
+		id = match[1]
+	}
+
+	if c, err := ctx.Session.State.Channel(id); err == nil {
+		return c, nil
+	}
+	return ctx.API().Channel(id)
+}
+
+// ArgRole resolves the named argument, a role mention or ID, to a role of the
+// current guild
+func (ctx *Context) ArgRole(name string) (*discordgo.Role, error) {
+	v, ok := ctx.argument(name)
+	if !ok {
+		return nil, ErrArgumentMissing
+	}
+
+	id := v
+	if match := roleMentionPattern.FindStringSubmatch(v); match != nil {
+		id = match[1]
+	}
+
+	guildID := ctx.Message.GuildID
+	if r, err := ctx.Session.State.Role(guildID, id); err == nil {
+		return r, nil
+	}
+
+	roles, err := ctx.API().GuildRoles(guildID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range roles {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("Role %s not found", id)
+}
+
+// Usage returns how the command of the Context is invoked, e.g.
+// "!remind <when> [message]": required arguments are in angle brackets,
+// optional ones in square brackets, and choices are separated by pipes
+func (ctx *Context) Usage() string {
+	command := ctx.Command
+	if ctx.Subcommand != "" {
+		command += " " + ctx.Subcommand
+	}
+	return usage(ctx.Prefix, command, ctx.spec)
+}
+
+// usage returns how a command with the argument spec is invoked
+func usage(prefix, command string, spec []Argument) string {
+	var sb strings.Builder
+	sb.WriteString(prefix + command)
+
+	for _, a := range spec {
+		name := strings.ToLower(a.Name)
+		if len(a.Choices) > 0 {
+			values := make([]string, len(a.Choices))
+			for i, c := range a.Choices {
+				values[i] = fmt.Sprint(c.Value)
+			}
+			name = strings.Join(values, "|")
+		}
+
+		if a.Required {
+			sb.WriteString(" <" + name + ">")
+		} else {
+			sb.WriteString(" [" + name + "]")
+		}
+	}
+	return sb.String()
+}
+
+// validateArguments checks the arguments of a message against the argument
+// spec of the command: required arguments, types, choices and bounds.
+// Users, channels and roles are only resolved when asked for.
+func (ctx *Context) validateArguments() error {
+	for _, a := range ctx.spec {
+		v, ok := ctx.argument(a.Name)
+		if !ok {
+			if a.Required {
+				return &ArgumentError{a.Name, "is required"}
+			}
+			continue
+		}
+
+		var value float64
+		switch a.Type {
+		case ArgumentInteger:
+			i, err := ctx.ArgInt(a.Name)
+			if err != nil {
+				return err
+			}
+			value = float64(i)
+
+		case ArgumentNumber:
+			f, err := ctx.ArgNumber(a.Name)
+			if err != nil {
+				return err
+			}
+			value = f
+
+		case ArgumentBoolean:
+			if _, err := ctx.ArgBool(a.Name); err != nil {
+				return err
+			}
+
+		case ArgumentString:
+			value = float64(len([]rune(v)))
+		}
+
+		if len(a.Choices) > 0 && !a.hasChoice(v) {
+			return &ArgumentError{a.Name, "must be one of the choices"}
+		}
+
+		if a.Min != nil && value < *a.Min {
+			return &ArgumentError{a.Name, "is too small"}
+		}
+		if a.Max != nil && value > *a.Max {
+			return &ArgumentError{a.Name, "is too large"}
+		}
+	}
+	return nil
+}
+
+// hasChoice reports whether the value is one of the choices of the argument
+func (a Argument) hasChoice(v string) bool {
+	for _, c := range a.Choices {
+		if strings.EqualFold(fmt.Sprint(c.Value), v) {
+			return true
+		}
+	}
+	return false
+}
+
+// argument returns the named argument, and whether it was given
+func (ctx *Context) argument(name string) (string, bool) {
+	ctx.argsOnce.Do(ctx.nameArguments)
+
+	v, ok := ctx.named[strings.ToLower(name)]
+	return v, ok
+}
+
+// nameArguments matches the arguments of a message to the argument spec.
+// Slash commands come with their arguments named.
+func (ctx *Context) nameArguments() {
+	if ctx.named != nil {
+		return
+	}
+
+	ctx.named = make(map[string]string, len(ctx.spec))
+	for i, a := range ctx.spec {
+		if i >= len(ctx.Arguments) {
+			break
+		}
+
+		v := ctx.Arguments[i]
+		if i == len(ctx.spec)-1 && a.Type == ArgumentString {
+			v = strings.Join(ctx.Arguments[i:], " ")
+		}
+		ctx.named[strings.ToLower(a.Name)] = v
+	}
+}
@@ -0,0 +1,98 @@
+package disgomux
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// AuditEntry describes a successful invocation of a privileged command, i.e.
+// one with permissions set
+type AuditEntry struct {
+	InvocationID string
+	Command      string
+	Arguments    []string
+
+	UserID, GuildID, ChannelID, MessageID string
+	Time                                  time.Time
+}
+
+// auditArgumentsLimit is the number of characters of arguments posted to the
+// audit channel, keeping the post within a single message
+const auditArgumentsLimit = 1500
+
+// OnAudit sets the hook called after every successful invocation of a
+// privileged command, e.g. to keep a moderation log
+func (m *Mux) OnAudit(hook func(entry AuditEntry)) {
+	m.onAudit = hook
+}
+
+// SetAuditChannel sets a channel which successful invocations of privileged
+// commands are posted to. An empty channel ID (the default) disables it.
+func (m *Mux) SetAuditChannel(channelID string) {
+	m.auditChannel = channelID
+}
+
+// audit records a successful invocation of the handler, if it is privileged
+func (m *Mux) audit(ctx *Context, handler Command) {
+	/* Guilds may keep a mod log of their own */
+	channelID := m.auditChannel
+	if ctx.config.ModLogChannel != "" {
+		channelID = ctx.config.ModLogChannel
+	}
+
+	if m.onAudit == nil && channelID == "" {
+		return
+	}
+
+	p := handler.Permissions()
+	if p == nil || len(p.UserIDs)+len(p.RoleIDs)+len(p.ChanIDs) == 0 && p.Permissions == 0 {
+		return
+	}
+
+	entry := AuditEntry{
+		InvocationID: ctx.id,
+		Command:      ctx.Command,
+		Arguments:    ctx.Arguments,
+		UserID:       ctx.Message.Author.ID,
+		GuildID:      ctx.Message.GuildID,
+		ChannelID:    ctx.Message.ChannelID,
+		MessageID:    ctx.Message.ID,
+		Time:         m.clock().Now(),
+	}
+
+	if m.onAudit != nil {
+		m.onAudit(entry)
+	}
+	if channelID != "" {
+		m.postAudit(ctx.Session, channelID, entry)
+	}
+}
+
+// postAudit posts an entry to the audit channel, without pinging anyone
+func (m *Mux) postAudit(
+	session *discordgo.Session,
+	channelID string,
+	entry AuditEntry,
+) {
+	content := fmt.Sprintf(
+		"`%s%s` run by <@%s> in <#%s> (invocation %s)",
+		m.prefix(), entry.Command, entry.UserID, entry.ChannelID,
+		entry.InvocationID,
+	)
+	if len(entry.Arguments) > 0 {
+		args := runePrefix(strings.Join(entry.Arguments, " "), auditArgumentsLimit)
+		content += "\n" + codeBlock("", args)
+	}
+
+	ms := &discordgo.MessageSend{
+		Content:         content,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	m.retry(channelID, func() error {
+		_, err := m.api(session).ChannelMessageSendComplex(channelID, ms)
+		return err
+	})
+}
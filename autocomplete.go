@@ -0,0 +1,97 @@
+package disgomux
+
+import "github.com/bwmarrin/discordgo"
+
+// autocompleteChoiceLimit is the most choices Discord shows for an argument
+const autocompleteChoiceLimit = 25
+
+type (
+	// AutocompleteCommand is a Command which suggests values for its
+	// arguments while a slash command is being typed. Autocomplete is called
+	// with the argument being typed, for arguments with Autocomplete set; the
+	// other arguments typed so far are available through Context.Arg().
+	AutocompleteCommand interface {
+		Command
+		Autocomplete(
+			ctx *Context,
+			focused *discordgo.ApplicationCommandInteractionDataOption,
+		) []Choice
+	}
+
+	// Choice is a value suggested for an argument. Value must match the type
+	// of the argument.
+	Choice struct {
+		Name  string
+		Value interface{}
+	}
+)
+
+// handleAutocomplete answers an autocomplete interaction with the choices of
+// the command
+func (m *Mux) handleAutocomplete(
+	session *discordgo.Session,
+	interaction *discordgo.InteractionCreate,
+) {
+	data := interaction.ApplicationCommandData()
+
+	m.mu.RLock()
+	handler, name, ok := m.lookup(data.Name)
+	m.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	ac, ok := handler.(AutocompleteCommand)
+	if !ok {
+		return
+	}
+
+	focused := focusedOption(data.Options)
+	if focused == nil {
+		return
+	}
+
+	ctx := m.interactionContext(session, interaction, name)
+	sub, options := slashSubcommand(ctx, handler, data.Options)
+	ctx.named = namedOptions(options)
+	ctx.spec = sub.Settings().Arguments
+	ctx.Arguments = specArguments(ctx.spec, options)
+
+	var err error
+	defer m.recoverPanic(ctx, &err)
+
+	choices := ac.Autocomplete(ctx, focused)
+	if len(choices) > autocompleteChoiceLimit {
+		choices = choices[:autocompleteChoiceLimit]
+	}
+
+	result := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(choices))
+	for _, c := range choices {
+		result = append(result, &discordgo.ApplicationCommandOptionChoice{
+			Name:  c.Name,
+			Value: c.Value,
+		})
+	}
+
+	if err := ctx.respondOnce(&discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: result},
+	}); err != nil {
+		m.reportCtx(ctx, err)
+	}
+}
+
+// focusedOption returns the option being typed, looking into subcommands
+func focusedOption(
+	options []*discordgo.ApplicationCommandInteractionDataOption,
+) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, o := range options {
+		if o.Focused {
+			return o
+		}
+		if f := focusedOption(o.Options); f != nil {
+			return f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package disgomux
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// Cache caches the responses of a command, so repeated identical
+	// invocations (same command, subcommand and arguments, whatever the case
+	// and spacing) are answered without running the handler again, e.g. for
+	// expensive lookups. Only invocations which succeed are cached, and not
+	// those sending files. See Context.BypassCache().
+	Cache struct {
+		TTL time.Duration
+
+		// PerGuild keeps separate responses for each guild
+		PerGuild bool
+	}
+
+	// responseCache holds the cached responses of the mux, keyed by
+	// invocation
+	responseCache struct {
+		mu      sync.Mutex
+		entries map[string]cachedResponse
+		sweep   time.Time
+	}
+
+	cachedResponse struct {
+		command  string
+		messages []cachedMessage
+		expires  time.Time
+	}
+
+	// cachedMessage is a response to replay. Replies are replayed as replies
+	// to the new invocation.
+	cachedMessage struct {
+		send  discordgo.MessageSend
+		reply bool
+	}
+
+	// responseCapture gathers the responses of an invocation to cache
+	responseCapture struct {
+		mu        sync.Mutex
+		on        bool
+		uncached  bool
+		messages  []cachedMessage
+		bypass    int32
+		cacheKey  string
+		cacheOpts *Cache
+	}
+)
+
+// BypassCache makes this invocation skip the response cache of its command:
+// called from a middleware, the handler runs even if a response is cached; in
+// any case the responses of the invocation are not cached
+func (ctx *Context) BypassCache() {
+	atomic.StoreInt32(&ctx.cache.bypass, 1)
+}
+
+// bypassing reports whether BypassCache() was called
+func (ctx *Context) bypassing() bool {
+	return atomic.LoadInt32(&ctx.cache.bypass) == 1
+}
+
+// ClearResponseCache drops the cached responses of the commands, or of every
+// command if none are given
+func (m *Mux) ClearResponseCache(commands ...string) {
+	c := &m.cached
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		if len(commands) == 0 || arrayContains(commands, entry.command) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// cachedEntry returns the unexpired cached responses of the invocation, and
+// its key
+func (m *Mux) cachedEntry(
+	ctx *Context,
+	opts *Cache,
+) (cachedResponse, string, bool) {
+	if opts == nil || opts.TTL <= 0 || ctx.bypassing() {
+		return cachedResponse{}, "", false
+	}
+
+	key := responseKey(ctx, opts)
+	now := m.clock().Now()
+
+	c := &m.cached
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if ok && !now.Before(entry.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	return entry, key, ok
+}
+
+// cacheHit reports whether the invocation will be served from the cache
+func (m *Mux) cacheHit(ctx *Context, opts *Cache) bool {
+	_, _, ok := m.cachedEntry(ctx, opts)
+	return ok
+}
+
+// serveCached replays the cached responses of the invocation, reporting
+// whether there were any. On a miss the responses the handler sends are
+// captured, to be cached by storeCached().
+func (m *Mux) serveCached(ctx *Context, opts *Cache) bool {
+	entry, key, ok := m.cachedEntry(ctx, opts)
+	if key == "" {
+		return false
+	}
+
+	if !ok {
+		ctx.cache.mu.Lock()
+		ctx.cache.on = true
+		ctx.cache.cacheKey = key
+		ctx.cache.cacheOpts = opts
+		ctx.cache.mu.Unlock()
+		return false
+	}
+
+	m.logCtx(ctx, LogDebug, "Serving cached response")
+	for _, cached := range entry.messages {
+		ms := cached.send
+		channelID := ctx.channelID()
+		if cached.reply && channelID == ctx.Message.ChannelID {
+			ms.Reference = ctx.Message.SoftReference()
+		}
+		if _, err := ctx.send(channelID, &ms); err != nil {
+			m.reportCtx(ctx, err)
+			break
+		}
+	}
+	return true
+}
+
+// storeCached caches the responses captured for the invocation, if it
+// succeeded
+func (m *Mux) storeCached(ctx *Context, err error) {
+	capture := &ctx.cache
+	capture.mu.Lock()
+	defer capture.mu.Unlock()
+
+	if !capture.on || capture.uncached || err != nil || ctx.bypassing() ||
+		len(capture.messages) == 0 {
+		return
+	}
+
+	now := m.clock().Now()
+	c := &m.cached
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]cachedResponse)
+	}
+
+	/* Drop expired entries every so often so the map doesn't grow forever */
+	if now.After(c.sweep) {
+		for key, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+		c.sweep = now.Add(time.Minute)
+	}
+
+	c.entries[capture.cacheKey] = cachedResponse{
+		command:  ctx.Command,
+		messages: capture.messages,
+		expires:  now.Add(capture.cacheOpts.TTL),
+	}
+}
+
+// capture records a message sent by a helper, if the responses of the
+// invocation are being cached
+func (ctx *Context) capture(channelID string, ms *discordgo.MessageSend) {
+	responseChannel, ack := ctx.responseChannel(channelID)
+	if ack {
+		return
+	}
+
+	ctx.cache.mu.Lock()
+	defer ctx.cache.mu.Unlock()
+
+	if !ctx.cache.on || ctx.cache.uncached {
+		return
+	}
+
+	/*
+		Files are read as they are sent, so they can't be sent again. Sends
+		elsewhere, e.g. DMs, would be replayed to the wrong channel.
+	*/
+	if len(ms.Files) != 0 || !responseChannel {
+		ctx.cache.uncached = true
+		return
+	}
+
+	cached := cachedMessage{send: *ms, reply: ms.Reference != nil}
+	cached.send.Reference = nil
+	cached.send.Embeds = append([]*discordgo.MessageEmbed(nil), ms.Embeds...)
+	ctx.cache.messages = append(ctx.cache.messages, cached)
+}
+
+// captureEdit replaces the content of the last captured message, as
+// EditResponse() does
+func (ctx *Context) captureEdit(content string) {
+	ctx.cache.mu.Lock()
+	defer ctx.cache.mu.Unlock()
+
+	if n := len(ctx.cache.messages); n != 0 {
+		ctx.cache.messages[n-1].send.Content = content
+	}
+}
+
+// responseChannel reports whether the channel is the one the helpers of this
+// Context send to: the invoking channel, the output channel or the redirect
+// thread. ack reports sends to the invoking channel while the output is
+// redirected, which acknowledge the redirection and are sent again by it.
+func (ctx *Context) responseChannel(channelID string) (ok, ack bool) {
+	ctx.output.mu.Lock()
+	output := ctx.output.channelID
+	ctx.output.mu.Unlock()
+
+	ctx.thread.mu.Lock()
+	thread := ctx.thread.threadID
+	ctx.thread.mu.Unlock()
+
+	if output != "" && output != ctx.Message.ChannelID {
+		return channelID == output, channelID == ctx.Message.ChannelID
+	}
+	return channelID == ctx.Message.ChannelID || channelID == thread, false
+}
+
+// responseKey identifies the cached responses of an invocation
+func responseKey(ctx *Context, opts *Cache) string {
+	args := strings.Fields(strings.ToLower(strings.Join(ctx.Arguments, " ")))
+	key := ctx.Command + "\x00" + strings.ToLower(ctx.Subcommand) + "\x00" +
+		strings.Join(args, "\x00")
+	if opts.PerGuild {
+		key += "\x00g:" + ctx.Message.GuildID
+	}
+	return key
+}
@@ -0,0 +1,155 @@
+package disgomux
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// cacheContext returns a Context invoking the command with the arguments
+func cacheContext(guildID, command, subcommand string, args ...string) *Context {
+	return &Context{
+		Command:    command,
+		Subcommand: subcommand,
+		Arguments:  args,
+		Message: &discordgo.MessageCreate{Message: &discordgo.Message{
+			ChannelID: "300",
+			GuildID:   guildID,
+			Author:    &discordgo.User{ID: "400"},
+		}},
+	}
+}
+
+func TestResponseKey(t *testing.T) {
+	opts := &Cache{TTL: time.Minute}
+	perGuild := &Cache{TTL: time.Minute, PerGuild: true}
+
+	same := []struct {
+		a, b *Context
+		opts *Cache
+	}{
+		{cacheContext("1", "lookup", "", "Thing"),
+			cacheContext("1", "lookup", "", "thing"), opts},
+		{cacheContext("1", "lookup", "", "a", "", "b"),
+			cacheContext("1", "lookup", "", "a", "b"), opts},
+		{cacheContext("1", "lookup", "", "a b"),
+			cacheContext("1", "lookup", "", "a", "b"), opts},
+		{cacheContext("1", "lookup", "User"),
+			cacheContext("1", "lookup", "user"), opts},
+		{cacheContext("1", "lookup", "", "x"),
+			cacheContext("2", "lookup", "", "x"), opts},
+	}
+	for _, tt := range same {
+		if responseKey(tt.a, tt.opts) != responseKey(tt.b, tt.opts) {
+			t.Errorf("%q and %q have different keys",
+				tt.a.Arguments, tt.b.Arguments)
+		}
+	}
+
+	different := []struct {
+		a, b *Context
+		opts *Cache
+	}{
+		{cacheContext("1", "lookup", "", "a"),
+			cacheContext("1", "lookup", "", "b"), opts},
+		{cacheContext("1", "lookup", "", "ab"),
+			cacheContext("1", "lookup", "", "a", "b"), opts},
+		{cacheContext("1", "lookup", "user"),
+			cacheContext("1", "lookup", "role"), opts},
+		{cacheContext("1", "lookup", "", "x"),
+			cacheContext("1", "find", "", "x"), opts},
+		{cacheContext("1", "lookup", "", "x"),
+			cacheContext("2", "lookup", "", "x"), perGuild},
+	}
+	for _, tt := range different {
+		if responseKey(tt.a, tt.opts) == responseKey(tt.b, tt.opts) {
+			t.Errorf("%q and %q share a key", tt.a.Arguments, tt.b.Arguments)
+		}
+	}
+}
+
+// cacheResponse caches a response to the invocation, as if its handler had
+// sent it
+func cacheResponse(m *Mux, ctx *Context, opts *Cache, err error) {
+	if m.serveCached(ctx, opts) {
+		return
+	}
+	ctx.cache.messages = append(ctx.cache.messages, cachedMessage{
+		send: discordgo.MessageSend{Content: "result"},
+	})
+	m.storeCached(ctx, err)
+}
+
+func TestResponseCacheInvalidation(t *testing.T) {
+	m := newMux()
+	clock := NewFakeClock(time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC))
+	m.SetClock(clock)
+	opts := &Cache{TTL: time.Minute}
+
+	lookup := cacheContext("1", "lookup", "", "thing")
+	find := cacheContext("1", "find", "", "thing")
+	cacheResponse(m, lookup, opts, nil)
+	cacheResponse(m, find, opts, nil)
+	if !m.cacheHit(cacheContext("1", "lookup", "", "THING"), opts) {
+		t.Fatal("response not cached")
+	}
+
+	/* Clearing a command leaves the others */
+	m.ClearResponseCache("lookup")
+	if m.cacheHit(cacheContext("1", "lookup", "", "thing"), opts) {
+		t.Error("cleared response still cached")
+	}
+	if !m.cacheHit(cacheContext("1", "find", "", "thing"), opts) {
+		t.Error("response of another command cleared")
+	}
+
+	/* Entries expire after the TTL */
+	clock.Advance(59 * time.Second)
+	if !m.cacheHit(cacheContext("1", "find", "", "thing"), opts) {
+		t.Error("response expired before its TTL")
+	}
+	clock.Advance(time.Second)
+	if m.cacheHit(cacheContext("1", "find", "", "thing"), opts) {
+		t.Error("response served after its TTL")
+	}
+
+	m.ClearResponseCache()
+	cacheResponse(m, cacheContext("1", "find", "", "x"), opts, nil)
+	cacheResponse(m, cacheContext("1", "lookup", "", "x"), opts, nil)
+	m.ClearResponseCache()
+	if m.cacheHit(cacheContext("1", "find", "", "x"), opts) ||
+		m.cacheHit(cacheContext("1", "lookup", "", "x"), opts) {
+		t.Error("responses cached after clearing every command")
+	}
+}
+
+func TestResponseCacheSkips(t *testing.T) {
+	m := newMux()
+	opts := &Cache{TTL: time.Minute}
+
+	cacheResponse(m, cacheContext("1", "lookup", "", "failed"), opts,
+		errors.New("failed"))
+	if m.cacheHit(cacheContext("1", "lookup", "", "failed"), opts) {
+		t.Error("failed invocation cached")
+	}
+
+	bypass := cacheContext("1", "lookup", "", "bypass")
+	bypass.BypassCache()
+	cacheResponse(m, bypass, opts, nil)
+	if m.cacheHit(cacheContext("1", "lookup", "", "bypass"), opts) {
+		t.Error("invocation bypassing the cache cached")
+	}
+
+	cacheResponse(m, cacheContext("1", "lookup", "", "x"), opts, nil)
+	hit := cacheContext("1", "lookup", "", "x")
+	hit.BypassCache()
+	if m.cacheHit(hit, opts) {
+		t.Error("cache hit while bypassing the cache")
+	}
+	if m.cacheHit(cacheContext("1", "lookup", "", "x"), nil) ||
+		m.cacheHit(cacheContext("1", "lookup", "", "x"), &Cache{}) {
+		t.Error("cache hit without a TTL")
+	}
+}
@@ -0,0 +1,260 @@
+package disgomux
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Keys of the mux-generated texts in a Catalog. The translations of error
+// texts are rendered like the texts themselves, with ErrorTemplateData.
+const (
+	MessageCommandNotFound  = "disgomux.command_not_found"
+	MessageSuggestions      = "disgomux.suggestions"
+	MessageNoPermissions    = "disgomux.no_permissions"
+	MessageCooldown         = "disgomux.cooldown"
+	MessageHandlerError     = "disgomux.handler_error"
+	MessageErrorRef         = "disgomux.error_ref"
+	MessageBusy             = "disgomux.busy"
+	MessageInvalidArguments = "disgomux.invalid_arguments"
+	MessageChainTooLong     = "disgomux.chain_too_long"
+	MessageQuotaExceeded    = "disgomux.quota_exceeded"
+	MessageMaintenance      = "disgomux.maintenance"
+	MessageRedirected       = "disgomux.redirected"
+	MessageAccountTooNew    = "disgomux.account_too_new"
+	MessageMemberTooNew     = "disgomux.member_too_new"
+	MessageNotInVoice       = "disgomux.not_in_voice"
+	MessageNotInSameVoice   = "disgomux.not_in_same_voice"
+	MessageVerifyPrompt     = "disgomux.verify_prompt"
+	MessageVerifyReact      = "disgomux.verify_react"
+	MessageVerifyCode       = "disgomux.verify_code"
+	MessageVerifyRetry      = "disgomux.verify_retry"
+	MessageVerified         = "disgomux.verified"
+	MessageVerifyFailed     = "disgomux.verify_failed"
+	MessageWizardConfirm    = "disgomux.wizard_confirm"
+	MessageWizardRetries    = "disgomux.wizard_retries"
+	MessageTagUsage         = "disgomux.tag_usage"
+	MessageTagIsCommand     = "disgomux.tag_is_command"
+	MessageTagExists        = "disgomux.tag_exists"
+	MessageTagMissing       = "disgomux.tag_missing"
+	MessageTagNotTag        = "disgomux.tag_not_tag"
+	MessageTagAdded         = "disgomux.tag_added"
+	MessageTagUpdated       = "disgomux.tag_updated"
+	MessageTagRemoved       = "disgomux.tag_removed"
+	MessageTagNotSaved      = "disgomux.tag_not_saved"
+	MessageTagList          = "disgomux.tag_list"
+	MessageNoTags           = "disgomux.no_tags"
+)
+
+// Catalog holds translations keyed by message key and locale. Translations
+// are text/template templates. Safe for concurrent use.
+type Catalog struct {
+	mu       sync.RWMutex
+	fallback string
+	messages map[string]map[string]*catalogEntry
+}
+
+// catalogEntry is a translation, parsed on first use
+type catalogEntry struct {
+	text string
+	once sync.Once
+	tmpl *template.Template
+}
+
+// NewCatalog creates an empty catalog. Messages missing in a locale are taken
+// from its language (pt for pt-BR), then from the fallback locale.
+func NewCatalog(fallback string) *Catalog {
+	return &Catalog{
+		fallback: fallback,
+		messages: make(map[string]map[string]*catalogEntry),
+	}
+}
+
+// Add registers the translation of a message in a locale, e.g.
+// Add("fr", "greeting", "Bonjour {{.name}} !"). The translation is checked to
+// be a valid template.
+func (c *Catalog) Add(locale, key, text string) error {
+	if _, err := template.New(key).Parse(text); err != nil {
+		return fmt.Errorf("Translation %s for %s: %v", key, locale, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.messages[key] == nil {
+		c.messages[key] = make(map[string]*catalogEntry)
+	}
+	c.messages[key][locale] = &catalogEntry{text: text}
+	return nil
+}
+
+// AddAll registers the translations of messages in a locale, keyed by message
+// key
+func (c *Catalog) AddAll(locale string, messages map[string]string) error {
+	for key, text := range messages {
+		if err := c.Add(locale, key, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lookup returns the untranslated template of a message in the locale
+func (c *Catalog) Lookup(locale, key string) (string, bool) {
+	if e := c.entry(locale, key); e != nil {
+		return e.text, true
+	}
+	return "", false
+}
+
+// T renders the message in the locale with args, as Context.T() does. The key
+// itself is returned for unknown messages.
+func (c *Catalog) T(locale, key string, args ...interface{}) string {
+	e := c.entry(locale, key)
+	if e == nil {
+		return key
+	}
+
+	e.once.Do(func() {
+		e.tmpl, _ = template.New(key).Option("missingkey=zero").Parse(e.text)
+	})
+
+	var sb strings.Builder
+	if err := e.tmpl.Execute(&sb, templateArgs(args)); err != nil {
+		return e.text
+	}
+	return sb.String()
+}
+
+// Localizations returns the translations of a message in every locale, e.g.
+// for the NameLocalizations of a command
+func (c *Catalog) Localizations(key string) map[discordgo.Locale]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	l := make(map[discordgo.Locale]string, len(c.messages[key]))
+	for locale, e := range c.messages[key] {
+		l[discordgo.Locale(locale)] = e.text
+	}
+	return l
+}
+
+// entry finds the translation of a message for the locale, falling back to its
+// language, then to the fallback locale
+func (c *Catalog) entry(locale, key string) *catalogEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	translations := c.messages[key]
+	if translations == nil {
+		return nil
+	}
+
+	candidates := []string{locale}
+	if i := strings.IndexByte(locale, '-'); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	candidates = append(candidates, c.fallback)
+
+	for _, l := range candidates {
+		if e, ok := translations[l]; ok {
+			return e
+		}
+	}
+	return nil
+}
+
+// templateArgs turns the arguments of T into the data of a template: a single
+// argument is used as is, others are taken as name/value pairs
+func templateArgs(args []interface{}) interface{} {
+	if len(args) == 1 {
+		return args[0]
+	}
+
+	data := make(map[string]interface{}, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		data[fmt.Sprint(args[i])] = args[i+1]
+	}
+	return data
+}
+
+// SetCatalog sets the catalog mux-generated texts are translated with, in the
+// locale of the invocation. Texts missing from the catalog are the error texts
+// of the mux.
+func (m *Mux) SetCatalog(catalog *Catalog) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.catalog = catalog
+}
+
+// T translates a message into the locale of the invocation, rendering it with
+// args: a single value (e.g. a struct or map), or name/value pairs, e.g.
+// ctx.T("reminder_set", "when", "in 10 minutes") for "Reminder set
+// {{.when}}". The key itself is returned if there is no catalog or message.
+func (ctx *Context) T(key string, args ...interface{}) string {
+	c := ctx.mux.getCatalog()
+	if c == nil {
+		return key
+	}
+	return c.T(ctx.Locale(), key, args...)
+}
+
+// getCatalog returns the catalog of the mux, if any
+func (m *Mux) getCatalog() *Catalog {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.catalog
+}
+
+// localize returns the translation of a mux-generated text for the invocation,
+// or fallback if there is none
+func (m *Mux) localize(ctx *Context, key, fallback string) string {
+	c := m.getCatalog()
+	if c == nil {
+		return fallback
+	}
+
+	if text, ok := c.Lookup(ctx.Locale(), key); ok {
+		return text
+	}
+	return fallback
+}
+
+// errorText returns an error text for the invocation: its translation, or else
+// the text set with SetErrors()
+func (m *Mux) errorText(ctx *Context, key string) string {
+	texts := m.texts()
+	if ctx.Message.GuildID == "" {
+		if dms := m.dmRules(); dms != nil {
+			texts = dms.texts(texts)
+		}
+	}
+
+	var text string
+	switch key {
+	case MessageCommandNotFound:
+		text = texts.CommandNotFound
+	case MessageNoPermissions:
+		text = texts.NoPermissions
+	case MessageCooldown:
+		text = texts.Cooldown
+	case MessageHandlerError:
+		text = texts.HandlerError
+	case MessageBusy:
+		text = texts.Busy
+	case MessageInvalidArguments:
+		text = texts.InvalidArguments
+	case MessageQuotaExceeded:
+		text = texts.QuotaExceeded
+	}
+	return m.localize(ctx, key, text)
+}
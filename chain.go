@@ -0,0 +1,149 @@
+package disgomux
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultChainLimit is the number of commands a message may chain unless set
+// otherwise
+const defaultChainLimit = 5
+
+type (
+	// Chain describes the chained message an invocation is part of. Values
+	// set on it are seen by the invocations after it, e.g. for a command to
+	// pass on what it did.
+	Chain struct {
+		// Commands are the chained commands and their arguments without the
+		// prefix, in order, e.g. "clean 10" and "lock"
+		Commands []string
+
+		// Index is the position of the invocation in Commands
+		Index int
+
+		values *chainValues
+	}
+
+	// chainValues are the values shared by the invocations of a chain
+	chainValues struct {
+		mu     sync.Mutex
+		values map[string]interface{}
+	}
+
+	// chaining is the configuration of command chaining, see SetChaining()
+	chaining struct {
+		delimiter string
+		limit     int
+	}
+)
+
+// SetChaining lets a message run several commands separated by the delimiter,
+// e.g. "!clean 10 && !lock" with "&&". The prefix may be omitted after the
+// delimiter. The commands run one after another, each once the previous one
+// is done, and the chain stops at the first which is rejected (e.g. for lack
+// of permission) or fails. A message chaining more than limit commands is
+// rejected with ErrChainTooLong; zero keeps a limit of 5. An empty delimiter
+// disables chaining, the default.
+func (m *Mux) SetChaining(delimiter string, limit int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if limit <= 0 {
+		limit = defaultChainLimit
+	}
+	m.chaining = chaining{delimiter: delimiter, limit: limit}
+}
+
+// WithChaining lets a message run several commands, as SetChaining()
+func WithChaining(delimiter string, limit int) Option {
+	return func(m *Mux) error {
+		if delimiter == "" {
+			return &OptionError{Option: "WithChaining", Reason: "delimiter is empty"}
+		}
+		if limit < 0 {
+			return &OptionError{Option: "WithChaining", Reason: "limit is negative"}
+		}
+		m.SetChaining(delimiter, limit)
+		return nil
+	}
+}
+
+// Set stores a value under the key for the invocations after this one
+func (c *Chain) Set(key string, value interface{}) {
+	c.values.mu.Lock()
+	defer c.values.mu.Unlock()
+
+	c.values.values[key] = value
+}
+
+// Get returns the value stored under the key by an earlier invocation
+func (c *Chain) Get(key string) (interface{}, bool) {
+	c.values.mu.Lock()
+	defer c.values.mu.Unlock()
+
+	value, ok := c.values.values[key]
+	return value, ok
+}
+
+// chainSegments splits content, the message without the prefix, into the
+// commands it chains. The chain is nil unless there are several.
+func (m *Mux) chainSegments(content, prefix string) ([]string, *Chain) {
+	m.mu.RLock()
+	delimiter := m.chaining.delimiter
+	m.mu.RUnlock()
+
+	if delimiter == "" || !strings.Contains(content, delimiter) {
+		return []string{content}, nil
+	}
+
+	var segments []string
+	for _, s := range strings.Split(content, delimiter) {
+		s = strings.TrimSpace(s)
+		if len(segments) > 0 && prefix != "" {
+			s = strings.TrimPrefix(s, prefix)
+		}
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	switch len(segments) {
+	case 0:
+		return []string{content}, nil
+	case 1:
+		return segments, nil
+	}
+
+	return segments, &Chain{
+		Commands: segments,
+		values:   &chainValues{values: make(map[string]interface{})},
+	}
+}
+
+// at returns the chain as seen by the invocation at the index, nil without a
+// chain
+func (c *Chain) at(index int) *Chain {
+	if c == nil {
+		return nil
+	}
+
+	chain := *c
+	chain.Index = index
+	return &chain
+}
+
+// chainLimit returns the number of commands a message may chain
+func (m *Mux) chainLimit() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.chaining.limit
+}
+
+// chainTooLongText returns the text rejecting a chain over the limit
+func (m *Mux) chainTooLongText(ctx *Context) string {
+	return m.localize(
+		ctx, MessageChainTooLong,
+		"You can chain at most "+strconv.Itoa(m.chainLimit())+" commands.",
+	)
+}
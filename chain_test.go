@@ -0,0 +1,51 @@
+package disgomux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChainSegments(t *testing.T) {
+	m := newMux()
+	m.SetChaining("&&", 0)
+
+	tests := []struct {
+		content string
+		want    []string
+		chained bool
+	}{
+		{"ping", []string{"ping"}, false},
+		{"clean 10 && lock", []string{"clean 10", "lock"}, true},
+		{"clean 10 && !lock", []string{"clean 10", "lock"}, true},
+		{"clean 10&&!lock&&  say hi  ", []string{"clean 10", "lock", "say hi"}, true},
+
+		/* Empty segments are dropped, leaving a single command unchained */
+		{"ping &&", []string{"ping"}, false},
+		{"&& ping && &&", []string{"ping"}, false},
+		{"&&", []string{"&&"}, false},
+
+		/* Only the prefix of later segments is optional */
+		{"say !hi && say !there", []string{"say !hi", "say !there"}, true},
+	}
+
+	for _, tt := range tests {
+		got, chain := m.chainSegments(tt.content, "!")
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("chainSegments(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+		if (chain != nil) != tt.chained {
+			t.Errorf("chainSegments(%q) chained %v, want %v",
+				tt.content, chain != nil, tt.chained)
+		}
+		if chain != nil && !reflect.DeepEqual(chain.Commands, tt.want) {
+			t.Errorf("chain of %q has commands %q", tt.content, chain.Commands)
+		}
+	}
+}
+
+func TestChainSegmentsDisabled(t *testing.T) {
+	got, chain := newMux().chainSegments("clean 10 && lock", "!")
+	if len(got) != 1 || chain != nil {
+		t.Errorf("chained %q without a delimiter", got)
+	}
+}
@@ -0,0 +1,185 @@
+package disgomux
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// Clock is the time source of the mux, used for cooldowns, temporary
+	// messages, scheduled tasks, prompts, paginators and deduplication. Set a
+	// FakeClock with SetClock() to advance time in tests instead of sleeping.
+	Clock interface {
+		Now() time.Time
+		NewTimer(d time.Duration) Timer
+		AfterFunc(d time.Duration, f func()) Timer
+	}
+
+	// Timer is a timer made by a Clock, like *time.Timer
+	Timer interface {
+		// C delivers the time once the timer fires. It is nil for timers made
+		// with AfterFunc.
+		C() <-chan time.Time
+
+		// Stop prevents the timer from firing, reporting whether it stopped
+		// it
+		Stop() bool
+	}
+
+	// realClock is the Clock of the time package
+	realClock struct{}
+
+	// realTimer is a Timer backed by a *time.Timer
+	realTimer struct {
+		timer *time.Timer
+	}
+
+	// FakeClock is a Clock whose time only moves when advanced. Timers due
+	// are fired by Advance(), and AfterFunc functions called synchronously.
+	FakeClock struct {
+		mu     sync.Mutex
+		now    time.Time
+		timers []*fakeTimer
+	}
+
+	// fakeTimer is a Timer of a FakeClock
+	fakeTimer struct {
+		clock  *FakeClock
+		when   time.Time
+		ch     chan time.Time
+		f      func()
+		active bool
+	}
+)
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// NewFakeClock creates a fake clock at the time
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the time of the clock
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// NewTimer creates a timer firing once the clock is advanced by d
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	return c.add(d, make(chan time.Time, 1), nil)
+}
+
+// AfterFunc creates a timer calling f once the clock is advanced by d
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	return c.add(d, nil, f)
+}
+
+// add creates a timer of the clock
+func (c *FakeClock) add(d time.Duration, ch chan time.Time, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, when: c.now.Add(d), ch: ch, f: f, active: true}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, firing the timers which fall due in
+// the order they are due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+
+	var due, pending []*fakeTimer
+	for _, t := range c.timers {
+		if t.active && !t.when.After(end) {
+			t.active = false
+			due = append(due, t)
+		} else if t.active {
+			pending = append(pending, t)
+		}
+	}
+	c.timers = pending
+	c.mu.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].when.Before(due[j].when)
+	})
+	for _, t := range due {
+		c.mu.Lock()
+		c.now = t.when
+		c.mu.Unlock()
+
+		if t.f != nil {
+			t.f()
+			continue
+		}
+		t.ch <- t.when
+	}
+
+	c.mu.Lock()
+	c.now = end
+	c.mu.Unlock()
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := t.active
+	t.active = false
+	return active
+}
+
+// SetClock sets the time source of the mux. Defaults to the system clock.
+func (m *Mux) SetClock(clock Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.clockSource = clock
+}
+
+// WithClock sets the time source of the mux, as SetClock()
+func WithClock(clock Clock) Option {
+	return func(m *Mux) error {
+		m.SetClock(clock)
+		return nil
+	}
+}
+
+// clock returns the time source of the mux
+func (m *Mux) clock() Clock {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.clockSource == nil {
+		return realClock{}
+	}
+	return m.clockSource
+}
@@ -0,0 +1,26 @@
+// Package commands provides ready-made utility commands for a disgomux.Mux.
+//
+//	mux.Register(commands.All(discordgo.PermissionSendMessages)...)
+//	mux.Initialize()
+//
+// Each command may also be registered on its own, e.g.
+// mux.Register(commands.NewPing()).
+package commands
+
+import "github.com/CS-5/disgomux"
+
+// All returns every command of the package, with the invite command asking for
+// the permission bits
+func All(invitePermissions int64) []disgomux.Command {
+	return []disgomux.Command{
+		NewPing(),
+		NewUptime(),
+		NewStats(),
+		NewPrefix(nil),
+		NewSetup(nil),
+		NewMacro(nil),
+		NewHistory(nil),
+		NewRepeat(),
+		NewInvite(invitePermissions),
+	}
+}
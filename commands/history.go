@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CS-5/disgomux"
+	"github.com/bwmarrin/discordgo"
+)
+
+// historyLength is the number of invocations listed by the history command
+const historyLength = 10
+
+// History lists the recent invocations of the author, or of another user, from
+// the history store of the mux (see Mux.SetHistoryStore()):
+//
+//	!history
+//	!history <user>
+//
+// Viewing the history of others is restricted to the bot owners and to members
+// with the permission bits supplied to NewHistory.
+type History struct {
+	mux         *disgomux.Mux
+	permissions int64
+}
+
+// NewHistory creates a history command. Viewing the history of others requires
+// the permissions, or Manage Messages if nil.
+func NewHistory(permissions *int64) *History {
+	h := &History{permissions: discordgo.PermissionManageMessages}
+	if permissions != nil {
+		h.permissions = *permissions
+	}
+	return h
+}
+
+// Init stores the multiplexer whose history is listed
+func (h *History) Init(m *disgomux.Mux) {
+	h.mux = m
+}
+
+// Handle lists the history
+func (h *History) Handle(ctx *disgomux.Context) {
+	if h.mux == nil {
+		return
+	}
+
+	user := ctx.Message.Author
+	if len(ctx.Arguments) != 0 {
+		if !ctx.IsOwner() && !h.allowed(ctx) {
+			ctx.ChannelSend("You do not have permission to view the history of others.")
+			return
+		}
+		member, err := ctx.ResolveUser(ctx.Arguments[0])
+		if err != nil {
+			ctx.ChannelSendf("I couldn't find the user %s.", ctx.Arguments[0])
+			return
+		}
+		user = member.User
+	}
+
+	history, err := h.mux.History(user.ID, historyLength)
+	if err != nil {
+		ctx.ChannelSend("The history could not be loaded.")
+		return
+	}
+	if len(history) == 0 {
+		ctx.ChannelSendf("No commands from %s.", user.Username)
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Recent commands from %s:\n", user.Username)
+	for _, entry := range history {
+		invocation := strings.Join(
+			append([]string{entry.Command, entry.Subcommand}, entry.Arguments...),
+			" ",
+		)
+		invocation = strings.Join(strings.Fields(invocation), " ")
+
+		status := "ok"
+		if entry.Error != "" {
+			status = "failed: " + entry.Error
+		}
+		fmt.Fprintf(&b, "- `%s%s` %s ago, %s\n",
+			ctx.Prefix, invocation,
+			time.Since(entry.Time).Round(time.Second), status,
+		)
+	}
+	ctx.ChannelSend(b.String())
+}
+
+// allowed reports whether the author may view the history of others
+func (h *History) allowed(ctx *disgomux.Context) bool {
+	return ctx.HasPermissions(h.permissions)
+}
+
+// HandleHelp sends the usage of the history command
+func (h *History) HandleHelp(ctx *disgomux.Context) bool {
+	ctx.ChannelSendf("Usage: `%[1]shistory`, `%[1]shistory <user>`", ctx.Prefix)
+	return true
+}
+
+// Settings returns the history command settings
+func (h *History) Settings() *disgomux.CommandSettings {
+	return &disgomux.CommandSettings{
+		Command:  "history",
+		HelpText: "List your recent commands",
+		Arguments: []disgomux.Argument{{
+			Name:        "user",
+			Description: "The user whose commands to list",
+			Type:        disgomux.ArgumentUser,
+		}},
+	}
+}
+
+// Permissions returns nil, allowing everyone to view their own history
+func (h *History) Permissions() *disgomux.CommandPermissions {
+	return nil
+}
+
+// Repeat runs the last command of the author again, see Context.RepeatLast()
+type Repeat struct{}
+
+// NewRepeat creates a repeat command
+func NewRepeat() *Repeat {
+	return &Repeat{}
+}
+
+// Init does nothing
+func (r *Repeat) Init(m *disgomux.Mux) {}
+
+// Handle repeats the last command
+func (r *Repeat) Handle(ctx *disgomux.Context) {
+	err := ctx.RepeatLast()
+	if errors.Is(err, disgomux.ErrNoHistory) {
+		ctx.ChannelSend("There is no command to repeat.")
+		return
+	}
+	if err != nil {
+		ctx.ChannelSend("The last command could not be repeated.")
+	}
+}
+
+// HandleHelp sends the usage of the repeat command
+func (r *Repeat) HandleHelp(ctx *disgomux.Context) bool {
+	ctx.ChannelSendf("Usage: `%sagain`", ctx.Prefix)
+	return true
+}
+
+// Settings returns the repeat command settings
+func (r *Repeat) Settings() *disgomux.CommandSettings {
+	return &disgomux.CommandSettings{
+		Command:  "again",
+		HelpText: "Run your last command again",
+	}
+}
+
+// Permissions returns nil, allowing everyone to repeat their commands
+func (r *Repeat) Permissions() *disgomux.CommandPermissions {
+	return nil
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/CS-5/disgomux"
+)
+
+// Invite replies with the OAuth2 link adding the bot to a guild
+type Invite struct {
+	permissions int64
+}
+
+// NewInvite creates an invite command whose link asks for the permission bits
+func NewInvite(permissions int64) *Invite {
+	return &Invite{permissions: permissions}
+}
+
+// Init does nothing
+func (i *Invite) Init(m *disgomux.Mux) {}
+
+// Handle replies with the invite link
+func (i *Invite) Handle(ctx *disgomux.Context) {
+	bot := ctx.BotUser()
+	if bot == nil {
+		return
+	}
+
+	ctx.ChannelSend(InviteURL(bot.ID, i.permissions))
+}
+
+// HandleHelp sends the usage of the invite command
+func (i *Invite) HandleHelp(ctx *disgomux.Context) bool {
+	ctx.ChannelSendf("Usage: `%sinvite`", ctx.Prefix)
+	return true
+}
+
+// Settings returns the invite command settings
+func (i *Invite) Settings() *disgomux.CommandSettings {
+	return &disgomux.CommandSettings{
+		Command:  "invite",
+		HelpText: "Get a link to add the bot to a server",
+	}
+}
+
+// Permissions returns nil, allowing everyone
+func (i *Invite) Permissions() *disgomux.CommandPermissions {
+	return nil
+}
+
+// InviteURL returns the link adding the application to a guild with the bot
+// and application commands scopes, asking for the permission bits
+func InviteURL(clientID string, permissions int64) string {
+	return fmt.Sprintf(
+		"https://discord.com/oauth2/authorize?client_id=%s"+
+			"&scope=bot%%20applications.commands&permissions=%d",
+		clientID, permissions,
+	)
+}
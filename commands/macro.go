@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/CS-5/disgomux"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Macro manages the macros of the guild, commands standing for another
+// command with arguments filled in (see Mux.SetMacro()):
+//
+//	!macro add mute7d mute {1} 7d spam
+//	!macro remove mute7d
+//	!macro list
+//
+// Anyone may list the macros. Adding and removing them is restricted to the
+// bot owners and to members with the permission bits supplied to NewMacro.
+type Macro struct {
+	mux         *disgomux.Mux
+	permissions int64
+}
+
+// NewMacro creates a macro command. Managing macros requires the permissions,
+// or Manage Server if nil.
+func NewMacro(permissions *int64) *Macro {
+	c := &Macro{permissions: discordgo.PermissionManageServer}
+	if permissions != nil {
+		c.permissions = *permissions
+	}
+	return c
+}
+
+// Init stores the multiplexer whose macros are managed
+func (c *Macro) Init(m *disgomux.Mux) {
+	c.mux = m
+}
+
+// Handle dispatches the macro subcommands
+func (c *Macro) Handle(ctx *disgomux.Context) {
+	if c.mux == nil || len(ctx.Arguments) == 0 || ctx.Message.GuildID == "" {
+		c.HandleHelp(ctx)
+		return
+	}
+
+	args := ctx.Arguments[1:]
+	switch strings.ToLower(ctx.Arguments[0]) {
+	case "add":
+		c.add(ctx, args)
+	case "remove", "delete":
+		c.remove(ctx, args)
+	case "list":
+		c.list(ctx)
+	default:
+		c.HandleHelp(ctx)
+	}
+}
+
+func (c *Macro) add(ctx *disgomux.Context, args []string) {
+	if !c.allowed(ctx) {
+		ctx.ChannelSend("You do not have permission to manage macros.")
+		return
+	}
+	if len(args) < 2 {
+		c.HandleHelp(ctx)
+		return
+	}
+
+	name := strings.ToLower(args[0])
+	err := c.mux.SetMacro(
+		ctx.Message.GuildID, name, strings.Join(args[1:], " "),
+	)
+	switch {
+	case errors.Is(err, disgomux.ErrDuplicateCommand):
+		ctx.ChannelSendf("`%s` is already a command.", name)
+	case errors.Is(err, disgomux.ErrNotFound):
+		ctx.ChannelSendf("`%s` is not a command.", args[1])
+	case err != nil:
+		ctx.ChannelSendf("Macro `%s` could not be saved.", name)
+	default:
+		ctx.ChannelSendf("Macro `%s` added.", name)
+	}
+}
+
+func (c *Macro) remove(ctx *disgomux.Context, args []string) {
+	if !c.allowed(ctx) {
+		ctx.ChannelSend("You do not have permission to manage macros.")
+		return
+	}
+	if len(args) < 1 {
+		c.HandleHelp(ctx)
+		return
+	}
+
+	name := strings.ToLower(args[0])
+	if _, ok := ctx.GuildConfig().Macros[name]; !ok {
+		ctx.ChannelSendf("Macro `%s` does not exist.", name)
+		return
+	}
+	if err := c.mux.RemoveMacro(ctx.Message.GuildID, name); err != nil {
+		ctx.ChannelSendf("Macro `%s` could not be removed.", name)
+		return
+	}
+	ctx.ChannelSendf("Macro `%s` removed.", name)
+}
+
+func (c *Macro) list(ctx *disgomux.Context) {
+	macros := ctx.GuildConfig().Macros
+	if len(macros) == 0 {
+		ctx.ChannelSend("There are no macros.")
+		return
+	}
+
+	var sb strings.Builder
+	for _, name := range c.mux.Macros(ctx.Message.GuildID) {
+		sb.WriteString(
+			"- `" + ctx.Prefix + name + "` → `" + ctx.Prefix + macros[name] + "`\n",
+		)
+	}
+	ctx.ChannelSend(sb.String())
+}
+
+// allowed reports whether the author may manage macros
+func (c *Macro) allowed(ctx *disgomux.Context) bool {
+	if ctx.IsOwner() {
+		return true
+	}
+	return ctx.HasPermissions(c.permissions)
+}
+
+// HandleHelp sends the usage of the macro command
+func (c *Macro) HandleHelp(ctx *disgomux.Context) bool {
+	ctx.ChannelSendf(
+		"Usage: `%[1]smacro add <name> <command>`, `%[1]smacro remove "+
+			"<name>`, `%[1]smacro list`. In the command, `{1}`, `{2}`... "+
+			"stand for the arguments, and `{*}` for all of them.",
+		ctx.Prefix,
+	)
+	return true
+}
+
+// Settings returns the macro command settings
+func (c *Macro) Settings() *disgomux.CommandSettings {
+	return &disgomux.CommandSettings{
+		Command:  "macro",
+		HelpText: "Manage the macros of the guild",
+		Examples: []string{"macro add mute7d mute {1} 7d spam", "macro list"},
+	}
+}
+
+// Permissions returns nil, allowing everyone to list the macros
+func (c *Macro) Permissions() *disgomux.CommandPermissions {
+	return nil
+}
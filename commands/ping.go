@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/CS-5/disgomux"
+)
+
+// Ping replies with the gateway heartbeat latency and the time taken to send
+// the reply
+type Ping struct{}
+
+// NewPing creates a ping command
+func NewPing() *Ping {
+	return &Ping{}
+}
+
+// Init does nothing
+func (p *Ping) Init(m *disgomux.Mux) {}
+
+// Handle replies with the latencies
+func (p *Ping) Handle(ctx *disgomux.Context) {
+	gateway := ctx.Latency().Round(time.Millisecond)
+
+	start := time.Now()
+	if _, err := ctx.ChannelSendf("Pong! Gateway: %s", gateway); err != nil {
+		return
+	}
+
+	api := time.Since(start).Round(time.Millisecond)
+	ctx.EditResponse(fmt.Sprintf("Pong! Gateway: %s, API: %s", gateway, api))
+}
+
+// HandleHelp sends the usage of the ping command
+func (p *Ping) HandleHelp(ctx *disgomux.Context) bool {
+	ctx.ChannelSendf("Usage: `%sping`", ctx.Prefix)
+	return true
+}
+
+// Settings returns the ping command settings
+func (p *Ping) Settings() *disgomux.CommandSettings {
+	return &disgomux.CommandSettings{
+		Command:  "ping",
+		HelpText: "Show the latency of the bot",
+	}
+}
+
+// Permissions returns nil, allowing everyone
+func (p *Ping) Permissions() *disgomux.CommandPermissions {
+	return nil
+}
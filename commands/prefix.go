@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"github.com/CS-5/disgomux"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Prefix shows the prefix of the guild, or sets it in the configuration store
+// of the mux:
+//
+//	!prefix
+//	!prefix <prefix>
+//
+// Anyone may view the prefix. Setting it is restricted to the bot owners and
+// to members with the permission bits supplied to NewPrefix.
+type Prefix struct {
+	mux         *disgomux.Mux
+	permissions int64
+}
+
+// NewPrefix creates a prefix command. Setting the prefix requires the
+// permissions, or Manage Server if nil.
+func NewPrefix(permissions *int64) *Prefix {
+	p := &Prefix{permissions: discordgo.PermissionManageServer}
+	if permissions != nil {
+		p.permissions = *permissions
+	}
+	return p
+}
+
+// Init stores the multiplexer whose guild configuration is changed
+func (p *Prefix) Init(m *disgomux.Mux) {
+	p.mux = m
+}
+
+// Handle shows or sets the prefix
+func (p *Prefix) Handle(ctx *disgomux.Context) {
+	guildID := ctx.Message.GuildID
+	if len(ctx.Arguments) == 0 || p.mux == nil || guildID == "" {
+		prefix := ctx.GuildConfig().Prefix
+		if prefix == "" {
+			prefix = ctx.Prefix
+		}
+		ctx.ChannelSendf("The prefix is `%s`.", prefix)
+		return
+	}
+
+	if !ctx.IsOwner() && !p.allowed(ctx) {
+		ctx.ChannelSend("You do not have permission to change the prefix.")
+		return
+	}
+
+	prefix := ctx.Arguments[0]
+	if prefix == "" || disgomux.ValidatePrefix(prefix) != nil {
+		ctx.ChannelSend("The prefix must be a single character or emoji.")
+		return
+	}
+
+	_, err := p.mux.UpdateGuildConfig(guildID, func(c *disgomux.GuildConfig) {
+		c.Prefix = prefix
+	})
+	if err != nil {
+		ctx.ChannelSend("The prefix could not be saved.")
+		return
+	}
+	ctx.ChannelSendf("The prefix is now `%s`.", prefix)
+}
+
+// allowed reports whether the author has the permissions to set the prefix
+func (p *Prefix) allowed(ctx *disgomux.Context) bool {
+	return ctx.HasPermissions(p.permissions)
+}
+
+// HandleHelp sends the usage of the prefix command
+func (p *Prefix) HandleHelp(ctx *disgomux.Context) bool {
+	ctx.ChannelSendf("Usage: `%[1]sprefix`, `%[1]sprefix <prefix>`", ctx.Prefix)
+	return true
+}
+
+// Settings returns the prefix command settings
+func (p *Prefix) Settings() *disgomux.CommandSettings {
+	return &disgomux.CommandSettings{
+		Command:  "prefix",
+		HelpText: "Show or change the prefix of the guild",
+		Arguments: []disgomux.Argument{{
+			Name:        "prefix",
+			Description: "The new prefix",
+			Type:        disgomux.ArgumentString,
+		}},
+	}
+}
+
+// Permissions returns nil, allowing everyone to view the prefix
+func (p *Prefix) Permissions() *disgomux.CommandPermissions {
+	return nil
+}
@@ -0,0 +1,397 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/CS-5/disgomux"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Answers of the setup wizard which keep or clear a setting
+const (
+	setupSkip  = "skip"
+	setupClear = "none"
+)
+
+// Names of the setup wizard steps, which label the answers in its summary
+const (
+	stepPrefix   = "Prefix"
+	stepLocale   = "Locale"
+	stepModRoles = "Mod roles"
+	stepLogs     = "Log channel"
+	stepDisabled = "Disabled commands"
+)
+
+var (
+	localePattern         = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+	roleMentionPattern    = regexp.MustCompile(`^<@&(\d+)>$`)
+	channelMentionPattern = regexp.MustCompile(`^<#(\d+)>$`)
+	idPattern             = regexp.MustCompile(`^\d{15,21}$`)
+
+	errPrefix = errors.New("The prefix must be a single character or emoji.")
+)
+
+// Setup walks a server admin through the configuration of their guild, one
+// question at a time, and saves the answers in the configuration store of
+// the mux:
+//
+//	!setup
+//
+// It asks for the prefix, the locale, the moderator roles, the log channel and
+// the disabled commands. Each setting may be kept as it is or cleared. Setup
+// is restricted to the bot owners and to members with the permission bits
+// supplied to NewSetup.
+type Setup struct {
+	mux         *disgomux.Mux
+	permissions int64
+}
+
+// NewSetup creates a setup command. Running it requires the permissions, or
+// Manage Server if nil.
+func NewSetup(permissions *int64) *Setup {
+	s := &Setup{permissions: discordgo.PermissionManageServer}
+	if permissions != nil {
+		s.permissions = *permissions
+	}
+	return s
+}
+
+// Init stores the multiplexer whose guild configuration is changed
+func (s *Setup) Init(m *disgomux.Mux) {
+	s.mux = m
+}
+
+// Handle runs the setup wizard
+func (s *Setup) Handle(ctx *disgomux.Context) {
+	guildID := ctx.Message.GuildID
+	if s.mux == nil || guildID == "" {
+		ctx.ChannelSend("Setup only works in a server.")
+		return
+	}
+
+	current := ctx.GuildConfig()
+	prefix := current.Prefix
+	if prefix == "" {
+		prefix = ctx.Prefix
+	}
+
+	wizard := &disgomux.Wizard{
+		Confirm: true,
+		Steps: []disgomux.WizardStep{
+			{
+				Name: stepPrefix,
+				Question: question(
+					"Which prefix should I answer to?", "`"+prefix+"`", false,
+				),
+				Validate: func(answer string) error {
+					if skipped(answer) {
+						return nil
+					}
+					if disgomux.ValidatePrefix(answer) != nil {
+						return errPrefix
+					}
+					return nil
+				},
+			},
+			{
+				Name: stepLocale,
+				Question: question(
+					"Which language should I use, e.g. `en-US`?",
+					orNone(current.Locale), true,
+				),
+				Validate: func(answer string) error {
+					if skipped(answer) || cleared(answer) ||
+						localePattern.MatchString(answer) {
+						return nil
+					}
+					return errors.New("That doesn't look like a language code.")
+				},
+			},
+			{
+				Name: stepModRoles,
+				Question: question(
+					"Which roles do the moderators have? Mention them, or "+
+						"list their names separated by commas.",
+					roleMentions(current.ModRoles), true,
+				),
+				Validate: func(answer string) error {
+					if skipped(answer) || cleared(answer) {
+						return nil
+					}
+					_, err := s.roles(ctx, answer)
+					return err
+				},
+			},
+			{
+				Name: stepLogs,
+				Question: question(
+					"Which channel should moderation actions be logged to?",
+					channelName(current.ModLogChannel), true,
+				),
+				Validate: func(answer string) error {
+					if skipped(answer) || cleared(answer) {
+						return nil
+					}
+					_, err := s.channel(ctx, answer)
+					return err
+				},
+			},
+			{
+				Name: stepDisabled,
+				Question: question(
+					"Which commands should be disabled? List them separated "+
+						"by commas.",
+					codes(current.DisabledCommands), true,
+				),
+				Validate: func(answer string) error {
+					if skipped(answer) || cleared(answer) {
+						return nil
+					}
+					_, err := s.commands(ctx, answer)
+					return err
+				},
+			},
+		},
+	}
+
+	answers, err := wizard.Run(ctx)
+	switch {
+	case errors.Is(err, disgomux.ErrTimeout):
+		ctx.ChannelSend("Setup timed out, nothing was changed.")
+		return
+	case err != nil:
+		ctx.ChannelSend("Setup cancelled, nothing was changed.")
+		return
+	}
+
+	_, err = s.mux.UpdateGuildConfig(guildID, func(c *disgomux.GuildConfig) {
+		s.apply(ctx, c, answers)
+	})
+	if err != nil {
+		ctx.ChannelSend("The configuration could not be saved.")
+		return
+	}
+	ctx.ChannelSend("The configuration is saved.")
+}
+
+// apply sets the answers of the wizard in the configuration, which were
+// validated
+func (s *Setup) apply(
+	ctx *disgomux.Context,
+	c *disgomux.GuildConfig,
+	answers map[string]string,
+) {
+	if answer := answers[stepPrefix]; !skipped(answer) {
+		c.Prefix = answer
+	}
+
+	if answer := answers[stepLocale]; cleared(answer) {
+		c.Locale = ""
+	} else if !skipped(answer) {
+		c.Locale = answer
+	}
+
+	if answer := answers[stepModRoles]; cleared(answer) {
+		c.ModRoles = nil
+	} else if !skipped(answer) {
+		c.ModRoles, _ = s.roles(ctx, answer)
+	}
+
+	if answer := answers[stepLogs]; cleared(answer) {
+		c.ModLogChannel = ""
+	} else if !skipped(answer) {
+		c.ModLogChannel, _ = s.channel(ctx, answer)
+	}
+
+	if answer := answers[stepDisabled]; cleared(answer) {
+		c.DisabledCommands = nil
+	} else if !skipped(answer) {
+		c.DisabledCommands, _ = s.commands(ctx, answer)
+	}
+}
+
+// roles resolves a list of role mentions, IDs or names to role IDs
+func (s *Setup) roles(ctx *disgomux.Context, answer string) ([]string, error) {
+	guild, err := ctx.Guild()
+	if err != nil {
+		return nil, errors.New("I couldn't look up the roles of the server.")
+	}
+
+	var ids []string
+	for _, item := range list(answer) {
+		id := item
+		if match := roleMentionPattern.FindStringSubmatch(item); match != nil {
+			id = match[1]
+		}
+
+		found := ""
+		for _, r := range guild.Roles {
+			if r.ID == id || strings.EqualFold(r.Name, item) {
+				found = r.ID
+				break
+			}
+		}
+		if found == "" {
+			return nil, fmt.Errorf("I couldn't find the role %s.", item)
+		}
+		ids = append(ids, found)
+	}
+	return ids, nil
+}
+
+// channel resolves a channel mention, ID or name to a channel ID
+func (s *Setup) channel(ctx *disgomux.Context, answer string) (string, error) {
+	guild, err := ctx.Guild()
+	if err != nil {
+		return "", errors.New("I couldn't look up the channels of the server.")
+	}
+
+	id := strings.TrimPrefix(answer, "#")
+	if match := channelMentionPattern.FindStringSubmatch(answer); match != nil {
+		id = match[1]
+	}
+	for _, c := range guild.Channels {
+		if c.ID == id || strings.EqualFold(c.Name, id) {
+			return c.ID, nil
+		}
+	}
+
+	/* Channels may be missing from the state, IDs are taken as they are */
+	if idPattern.MatchString(id) {
+		return id, nil
+	}
+	return "", fmt.Errorf("I couldn't find the channel %s.", answer)
+}
+
+// commands checks a list of command names against the commands of the mux.
+// The setup command itself may not be disabled.
+func (s *Setup) commands(
+	ctx *disgomux.Context,
+	answer string,
+) ([]string, error) {
+	known := make(map[string]bool)
+	for _, c := range s.mux.Describe().Commands {
+		known[c.Name] = true
+	}
+
+	var names []string
+	for _, item := range list(answer) {
+		name := strings.ToLower(strings.TrimPrefix(item, ctx.Prefix))
+		if name == ctx.Command {
+			return nil, errors.New("The setup command can't be disabled.")
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("There is no command named %s.", item)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// roleMentions formats a role list setting
+func roleMentions(ids []string) string {
+	if len(ids) == 0 {
+		return orNone("")
+	}
+
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = "<@&" + id + ">"
+	}
+	return strings.Join(names, ", ")
+}
+
+// question phrases a wizard question with the current value of the setting
+// and how to keep it or clear it
+func question(text, current string, clearable bool) string {
+	hint := "`" + setupSkip + "` keeps it"
+	if clearable {
+		hint += ", `" + setupClear + "` clears it"
+	}
+	return fmt.Sprintf("%s\nCurrently: %s (%s)", text, current, hint)
+}
+
+// list splits a comma separated answer. Items without commas between them
+// are split on spaces if they are all mentions or IDs.
+func list(answer string) []string {
+	var items []string
+	for _, part := range strings.Split(answer, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Fields(part)
+		mentions := len(fields) > 1
+		for _, f := range fields {
+			if !roleMentionPattern.MatchString(f) &&
+				!channelMentionPattern.MatchString(f) &&
+				!idPattern.MatchString(f) {
+				mentions = false
+				break
+			}
+		}
+		if mentions {
+			items = append(items, fields...)
+			continue
+		}
+		items = append(items, part)
+	}
+	return items
+}
+
+// skipped reports whether the answer keeps the setting as it is
+func skipped(answer string) bool {
+	return strings.EqualFold(answer, setupSkip)
+}
+
+// cleared reports whether the answer clears the setting
+func cleared(answer string) bool {
+	return strings.EqualFold(answer, setupClear)
+}
+
+// orNone formats the current value of a setting, "none" if it is empty
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return "`" + value + "`"
+}
+
+// channelName formats a channel setting
+func channelName(channelID string) string {
+	if channelID == "" {
+		return orNone("")
+	}
+	return "<#" + channelID + ">"
+}
+
+// codes formats a list setting
+func codes(values []string) string {
+	if len(values) == 0 {
+		return orNone("")
+	}
+	return "`" + strings.Join(values, "`, `") + "`"
+}
+
+// HandleHelp sends the usage of the setup command
+func (s *Setup) HandleHelp(ctx *disgomux.Context) bool {
+	ctx.ChannelSendf("Usage: `%ssetup`", ctx.Prefix)
+	return true
+}
+
+// Settings returns the setup command settings
+func (s *Setup) Settings() *disgomux.CommandSettings {
+	return &disgomux.CommandSettings{
+		Command:  "setup",
+		HelpText: "Configure the bot for this server",
+	}
+}
+
+// Permissions restricts setup to members with the permissions
+func (s *Setup) Permissions() *disgomux.CommandPermissions {
+	return &disgomux.CommandPermissions{Permissions: s.permissions}
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/CS-5/disgomux"
+)
+
+// statsTop is the number of commands listed by the stats command
+const statsTop = 10
+
+// Stats replies with the counters of the mux and its most used commands
+type Stats struct {
+	mux *disgomux.Mux
+}
+
+// NewStats creates a stats command
+func NewStats() *Stats {
+	return &Stats{}
+}
+
+// Init stores the multiplexer the stats are read from
+func (s *Stats) Init(m *disgomux.Mux) {
+	s.mux = m
+}
+
+// Handle replies with the stats
+func (s *Stats) Handle(ctx *disgomux.Context) {
+	if s.mux == nil {
+		return
+	}
+
+	debug := s.mux.DebugStats()
+	var b strings.Builder
+	fmt.Fprintf(&b,
+		"Messages seen: %d\nCommands dispatched: %d\nActive handlers: %d\n",
+		debug.MessagesSeen, debug.CommandsDispatched, debug.ActiveHandlers,
+	)
+
+	stats, err := s.mux.Stats()
+	if err != nil {
+		ctx.ChannelSend(b.String())
+		return
+	}
+
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		x, y := stats[names[i]], stats[names[j]]
+		if x.Invocations != y.Invocations {
+			return x.Invocations > y.Invocations
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > statsTop {
+		names = names[:statsTop]
+	}
+
+	for _, name := range names {
+		c := stats[name]
+		fmt.Fprintf(&b, "`%s`: %d uses, %d users, %.0f%% errors, %s avg\n",
+			name, c.Invocations, c.UniqueUsers, c.ErrorRate()*100,
+			c.AverageLatency.Round(time.Millisecond),
+		)
+	}
+	ctx.ChannelSend(b.String())
+}
+
+// HandleHelp sends the usage of the stats command
+func (s *Stats) HandleHelp(ctx *disgomux.Context) bool {
+	ctx.ChannelSendf("Usage: `%sstats`", ctx.Prefix)
+	return true
+}
+
+// Settings returns the stats command settings
+func (s *Stats) Settings() *disgomux.CommandSettings {
+	return &disgomux.CommandSettings{
+		Command:  "stats",
+		HelpText: "Show usage statistics of the bot",
+	}
+}
+
+// Permissions returns nil, allowing everyone
+func (s *Stats) Permissions() *disgomux.CommandPermissions {
+	return nil
+}
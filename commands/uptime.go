@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/CS-5/disgomux"
+)
+
+// Uptime replies with the time since the command was initialized, which is
+// usually when the bot started
+type Uptime struct {
+	started time.Time
+}
+
+// NewUptime creates an uptime command
+func NewUptime() *Uptime {
+	return &Uptime{started: time.Now()}
+}
+
+// Init records the start time
+func (u *Uptime) Init(m *disgomux.Mux) {
+	u.started = time.Now()
+}
+
+// Handle replies with the uptime
+func (u *Uptime) Handle(ctx *disgomux.Context) {
+	ctx.ChannelSendf("Up for %s.", time.Since(u.started).Round(time.Second))
+}
+
+// HandleHelp sends the usage of the uptime command
+func (u *Uptime) HandleHelp(ctx *disgomux.Context) bool {
+	ctx.ChannelSendf("Usage: `%suptime`", ctx.Prefix)
+	return true
+}
+
+// Settings returns the uptime command settings
+func (u *Uptime) Settings() *disgomux.CommandSettings {
+	return &disgomux.CommandSettings{
+		Command:  "uptime",
+		HelpText: "Show how long the bot has been running",
+	}
+}
+
+// Permissions returns nil, allowing everyone
+func (u *Uptime) Permissions() *disgomux.CommandPermissions {
+	return nil
+}
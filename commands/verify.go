@@ -0,0 +1,52 @@
+package commands
+
+import "github.com/CS-5/disgomux"
+
+// Verify challenges the invoking member with a verification, e.g. for members
+// who missed or failed the one issued when they joined:
+//
+//	mux.Register(commands.NewVerify(&disgomux.Verification{
+//		Challenge: disgomux.ChallengeCode,
+//		OnSuccess: disgomux.AssignRole(verifiedRoleID),
+//	}))
+//
+// It is not part of All(), as it needs a verification to run.
+type Verify struct {
+	verification *disgomux.Verification
+}
+
+// NewVerify creates a verify command running the verification
+func NewVerify(v *disgomux.Verification) *Verify {
+	return &Verify{verification: v}
+}
+
+// Init does nothing
+func (v *Verify) Init(m *disgomux.Mux) {}
+
+// Handle runs the verification
+func (v *Verify) Handle(ctx *disgomux.Context) {
+	if ctx.Message.GuildID == "" {
+		ctx.ChannelSend("Verification only works in a server.")
+		return
+	}
+	v.verification.Run(ctx)
+}
+
+// HandleHelp sends the usage of the verify command
+func (v *Verify) HandleHelp(ctx *disgomux.Context) bool {
+	ctx.ChannelSendf("Usage: `%sverify`", ctx.Prefix)
+	return true
+}
+
+// Settings returns the verify command settings
+func (v *Verify) Settings() *disgomux.CommandSettings {
+	return &disgomux.CommandSettings{
+		Command:  "verify",
+		HelpText: "Verify that you are human",
+	}
+}
+
+// Permissions returns nil, allowing everyone
+func (v *Verify) Permissions() *disgomux.CommandPermissions {
+	return nil
+}
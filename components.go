@@ -0,0 +1,236 @@
+package disgomux
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// ComponentHandler handles a component interaction, such as a button click
+	ComponentHandler func(ctx *Context)
+
+	// customIDRouter finds the handler registered for a custom ID, either
+	// exactly or by prefix. The longest matching prefix wins.
+	customIDRouter struct {
+		exact    map[string]ComponentHandler
+		prefixes []customIDRoute
+	}
+
+	customIDRoute struct {
+		prefix  string
+		handler ComponentHandler
+	}
+
+	// funcCommand adapts a handler function to a Command, so it can be
+	// dispatched like one
+	funcCommand struct {
+		settings CommandSettings
+		handle   func(ctx *Context)
+	}
+)
+
+// customIDSeparator separates the parts of a custom ID, e.g. poll_vote:42:yes
+const customIDSeparator = ":"
+
+// HandleComponent registers the handler of the message components (buttons
+// and select menus) whose custom ID matches pattern. The pattern is either a
+// custom ID, or a prefix followed by * to match a family of custom IDs, e.g.
+// "poll_vote:*". Use Context.CustomIDParts() to read the parts of the custom
+// ID, and Context.Values() and friends to read the choices made in a select
+// menu. Safe to call while interactions are being handled.
+func (m *Mux) HandleComponent(pattern string, handler ComponentHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.components.add(pattern, handler)
+}
+
+// add registers the handler under pattern. Must be called with the mux's mu
+// held.
+func (r *customIDRouter) add(pattern string, handler ComponentHandler) {
+	if strings.HasSuffix(pattern, "*") {
+		r.prefixes = append(r.prefixes, customIDRoute{
+			prefix:  strings.TrimSuffix(pattern, "*"),
+			handler: handler,
+		})
+		return
+	}
+
+	if r.exact == nil {
+		r.exact = make(map[string]ComponentHandler)
+	}
+	r.exact[pattern] = handler
+}
+
+// match returns the handler registered for the custom ID, along with the
+// pattern it was registered under. Must be called with the mux's mu held.
+func (r *customIDRouter) match(
+	customID string,
+) (ComponentHandler, string, bool) {
+	if h, ok := r.exact[customID]; ok {
+		return h, customID, true
+	}
+
+	var best *customIDRoute
+	for i, route := range r.prefixes {
+		if strings.HasPrefix(customID, route.prefix) &&
+			(best == nil || len(route.prefix) > len(best.prefix)) {
+			best = &r.prefixes[i]
+		}
+	}
+	if best == nil {
+		return nil, "", false
+	}
+	return best.handler, best.prefix + "*", true
+}
+
+// handleComponent routes a message component interaction to its handler
+func (m *Mux) handleComponent(
+	session *discordgo.Session,
+	interaction *discordgo.InteractionCreate,
+) {
+	customID := interaction.MessageComponentData().CustomID
+
+	m.mu.RLock()
+	handler, pattern, ok := m.components.match(customID)
+	m.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	m.dispatchInteraction(session, interaction, pattern, handler)
+}
+
+// dispatchInteraction dispatches the handler of an interaction, registered
+// under pattern. The pattern stands in for the command name of the invocation.
+func (m *Mux) dispatchInteraction(
+	session *discordgo.Session,
+	interaction *discordgo.InteractionCreate,
+	pattern string,
+	handler ComponentHandler,
+) {
+	m.countMessage(session)
+
+	ctx := m.interactionContext(session, interaction, pattern)
+	invocationCtx, span := m.startSpan(ctx, spanInvocation)
+	ctx.invocation = invocationCtx
+
+	m.dispatch(ctx, &funcCommand{
+		settings: CommandSettings{Command: pattern},
+		handle:   handler,
+	}, span)
+}
+
+// CustomID returns the custom ID of the component or modal interacted with.
+// Empty for other invocations.
+func (ctx *Context) CustomID() string {
+	if data, ok := ctx.componentData(); ok {
+		return data.CustomID
+	}
+	if ctx.Interaction != nil &&
+		ctx.Interaction.Type == discordgo.InteractionModalSubmit {
+		return ctx.Interaction.ModalSubmitData().CustomID
+	}
+	return ""
+}
+
+// CustomIDParts returns the custom ID split on colons, e.g. ["poll_vote", "42"]
+// for "poll_vote:42"
+func (ctx *Context) CustomIDParts() []string {
+	customID := ctx.CustomID()
+	if customID == "" {
+		return nil
+	}
+	return strings.Split(customID, customIDSeparator)
+}
+
+// Values returns the values chosen in a select menu: the option values of a
+// string select, or the IDs picked in a user, role or channel select
+func (ctx *Context) Values() []string {
+	if data, ok := ctx.componentData(); ok {
+		return data.Values
+	}
+	return nil
+}
+
+// SelectedUsers returns the users picked in a user (or mentionable) select, in
+// the order they were picked
+func (ctx *Context) SelectedUsers() []*discordgo.User {
+	data, ok := ctx.componentData()
+	if !ok {
+		return nil
+	}
+
+	var users []*discordgo.User
+	for _, id := range data.Values {
+		if u, ok := data.Resolved.Users[id]; ok {
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+// SelectedRoles returns the roles picked in a role (or mentionable) select, in
+// the order they were picked
+func (ctx *Context) SelectedRoles() []*discordgo.Role {
+	data, ok := ctx.componentData()
+	if !ok {
+		return nil
+	}
+
+	var roles []*discordgo.Role
+	for _, id := range data.Values {
+		if r, ok := data.Resolved.Roles[id]; ok {
+			roles = append(roles, r)
+		}
+	}
+	return roles
+}
+
+// SelectedChannels returns the channels picked in a channel select, in the
+// order they were picked
+func (ctx *Context) SelectedChannels() []*discordgo.Channel {
+	data, ok := ctx.componentData()
+	if !ok {
+		return nil
+	}
+
+	var channels []*discordgo.Channel
+	for _, id := range data.Values {
+		if c, ok := data.Resolved.Channels[id]; ok {
+			channels = append(channels, c)
+		}
+	}
+	return channels
+}
+
+// componentData returns the data of a message component interaction
+func (ctx *Context) componentData() (
+	discordgo.MessageComponentInteractionData,
+	bool,
+) {
+	if ctx.Interaction == nil ||
+		ctx.Interaction.Type != discordgo.InteractionMessageComponent {
+		return discordgo.MessageComponentInteractionData{}, false
+	}
+	return ctx.Interaction.MessageComponentData(), true
+}
+
+// UpdateMessage responds to a component interaction by editing the message
+// the component is attached to
+func (ctx *Context) UpdateMessage(ms *discordgo.MessageSend) error {
+	return ctx.respondWith(discordgo.InteractionResponseUpdateMessage, ms)
+}
+
+// Acknowledge responds to a component interaction without changing anything,
+// so Discord doesn't show it as failed
+func (ctx *Context) Acknowledge() error {
+	return ctx.respondWith(discordgo.InteractionResponseDeferredMessageUpdate, nil)
+}
+
+func (c *funcCommand) Init(m *Mux)                      {}
+func (c *funcCommand) Handle(ctx *Context)              { c.handle(ctx) }
+func (c *funcCommand) HandleHelp(ctx *Context) bool     { return false }
+func (c *funcCommand) Settings() *CommandSettings       { return &c.settings }
+func (c *funcCommand) Permissions() *CommandPermissions { return nil }
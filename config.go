@@ -0,0 +1,85 @@
+package disgomux
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Format is the encoding of a configuration file or command manifest
+type Format int
+
+// Supported formats. Markdown is only supported by ExportManifest().
+const (
+	FormatJSON Format = iota
+	FormatYAML
+	FormatMarkdown
+)
+
+// simpleCommandConfig is the representation of a simple command within a
+// configuration file
+type simpleCommandConfig struct {
+	Name       string   `json:"name" yaml:"name"`
+	Content    string   `json:"content" yaml:"content"`
+	Contents   []string `json:"contents" yaml:"contents"`
+	RoundRobin bool     `json:"round_robin" yaml:"round_robin"`
+	HelpText   string   `json:"helptext" yaml:"helptext"`
+	Aliases    []string `json:"aliases" yaml:"aliases"`
+}
+
+// LoadSimpleCommands parses a list of simple commands from r and registers them
+// to the multiplexer. Each entry has a name, content (or list of contents),
+// helptext and optional aliases.
+func (m *Mux) LoadSimpleCommands(r io.Reader, format Format) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var configs []simpleCommandConfig
+	if err := decode(data, format, &configs); err != nil {
+		return err
+	}
+
+	simple, err := simpleCommands(configs)
+	if err != nil {
+		return err
+	}
+
+	return m.RegisterSimple(simple...)
+}
+
+// simpleCommands converts simple commands read from a configuration file
+func simpleCommands(configs []simpleCommandConfig) ([]SimpleCommand, error) {
+	simple := make([]SimpleCommand, 0, len(configs))
+	for i, c := range configs {
+		if len(c.Name) == 0 {
+			return nil, fmt.Errorf("Simple command %d has no name", i)
+		}
+
+		simple = append(simple, SimpleCommand{
+			Command:    c.Name,
+			Content:    c.Content,
+			Contents:   c.Contents,
+			RoundRobin: c.RoundRobin,
+			HelpText:   c.HelpText,
+			Aliases:    c.Aliases,
+		})
+	}
+	return simple, nil
+}
+
+// decode unmarshals data in the specified format into v
+func decode(data []byte, format Format, v interface{}) error {
+	switch format {
+	case FormatJSON:
+		return json.Unmarshal(data, v)
+	case FormatYAML:
+		return yaml.Unmarshal(data, v)
+	default:
+		return fmt.Errorf("Unknown config format %d", format)
+	}
+}
@@ -0,0 +1,253 @@
+package disgomux
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ChannelSend is a helper function for easily sending a message to the current
+// channel.
+func (ctx *Context) ChannelSend(message string) (*discordgo.Message, error) {
+	return ctx.ChannelSendComplex(&discordgo.MessageSend{Content: message})
+}
+
+// ChannelSendf is a helper function like ChannelSend for sending a formatted
+// message to the current channel.
+func (ctx *Context) ChannelSendf(
+	format string,
+	a ...interface{},
+) (*discordgo.Message, error) {
+	return ctx.ChannelSend(fmt.Sprintf(format, a...))
+}
+
+// ChannelSendEmbed is a helper function for easily sending an embed to the
+// current channel. See NewEmbed() for building one.
+func (ctx *Context) ChannelSendEmbed(
+	embed *discordgo.MessageEmbed,
+) (*discordgo.Message, error) {
+	return ctx.ChannelSendComplex(&discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	})
+}
+
+// ChannelSendFile is a helper function for sending a file, with optional
+// content, to the current channel
+func (ctx *Context) ChannelSendFile(
+	name string,
+	r io.Reader,
+	content string,
+) (*discordgo.Message, error) {
+	return ctx.ChannelSendComplex(&discordgo.MessageSend{
+		Content: content,
+		Files:   []*discordgo.File{{Name: name, Reader: r}},
+	})
+}
+
+// ChannelSendComplex sends an arbitrary message to the current channel. The
+// mux's allowed mentions policy is applied unless ms sets its own.
+func (ctx *Context) ChannelSendComplex(
+	ms *discordgo.MessageSend,
+) (*discordgo.Message, error) {
+	return ctx.send(ctx.channelID(), ms)
+}
+
+// Reply sends a message to the current channel as an inline reply to the
+// invoking message, mentioning its author.
+func (ctx *Context) Reply(content string) (*discordgo.Message, error) {
+	return ctx.reply(content, true)
+}
+
+// Replyf is a helper function like Reply for sending a formatted reply
+func (ctx *Context) Replyf(
+	format string,
+	a ...interface{},
+) (*discordgo.Message, error) {
+	return ctx.reply(fmt.Sprintf(format, a...), true)
+}
+
+// ReplySilent is like Reply, but does not mention the author of the invoking
+// message.
+func (ctx *Context) ReplySilent(content string) (*discordgo.Message, error) {
+	return ctx.reply(content, false)
+}
+
+func (ctx *Context) reply(
+	content string,
+	mention bool,
+) (*discordgo.Message, error) {
+	policy := ctx.allowedMentions()
+	policy.RepliedUser = mention
+
+	/* Replies can't reference a message outside the thread they're sent to */
+	channelID := ctx.channelID()
+	var ref *discordgo.MessageReference
+	if channelID == ctx.Message.ChannelID {
+		ref = ctx.Message.SoftReference()
+	}
+
+	return ctx.send(channelID, &discordgo.MessageSend{
+		Content:         content,
+		Reference:       ref,
+		AllowedMentions: policy,
+	})
+}
+
+// React adds a reaction to the invoking message. emoji is either a unicode
+// emoji or a custom emoji in the name:id format.
+func (ctx *Context) React(emoji string) error {
+	return ctx.API().MessageReactionAdd(
+		ctx.Message.ChannelID, ctx.Message.ID, emoji,
+	)
+}
+
+// ReactSuccess reacts to the invoking message with the success emoji set by
+// Mux.SetReactions()
+func (ctx *Context) ReactSuccess() error {
+	success, _ := ctx.mux.reactions()
+	return ctx.React(success)
+}
+
+// ReactFailure reacts to the invoking message with the failure emoji set by
+// Mux.SetReactions()
+func (ctx *Context) ReactFailure() error {
+	_, failure := ctx.mux.reactions()
+	return ctx.React(failure)
+}
+
+// DeleteInvocation deletes the invoking message. Nothing is attempted if the
+// session state shows the bot lacks the Manage Messages permission.
+func (ctx *Context) DeleteInvocation() error {
+	perms, err := ctx.Session.State.UserChannelPermissions(
+		ctx.Session.State.User.ID, ctx.Message.ChannelID,
+	)
+	if err == nil && perms&discordgo.PermissionManageMessages == 0 {
+		return fmt.Errorf("Missing permission to delete messages")
+	}
+
+	return ctx.API().ChannelMessageDelete(ctx.Message.ChannelID, ctx.Message.ID)
+}
+
+// send is the path every helper send goes through
+func (ctx *Context) send(
+	channelID string,
+	ms *discordgo.MessageSend,
+) (*discordgo.Message, error) {
+	if ms.AllowedMentions == nil {
+		ms.AllowedMentions = ctx.allowedMentions()
+	}
+
+	var msg *discordgo.Message
+	err := ctx.mux.retry(channelID, func() (err error) {
+		/* Rewind files consumed by a failed attempt, where possible */
+		for _, f := range ms.Files {
+			if s, ok := f.Reader.(io.Seeker); ok {
+				s.Seek(0, io.SeekStart)
+			}
+		}
+
+		/* Interactions must be answered through the interaction */
+		if ctx.Invocation != nil && channelID == ctx.Invocation.ChannelID() {
+			msg, err = ctx.Invocation.Respond(ms)
+			return err
+		}
+
+		msg, err = ctx.API().ChannelMessageSendComplex(channelID, ms)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.recordResponse(msg)
+	ctx.capture(channelID, ms)
+	ctx.publish(msg)
+	return msg, nil
+}
+
+// allowedMentions returns a copy of the mux's allowed mentions policy, or of
+// the default one letting only users be mentioned
+func (ctx *Context) allowedMentions() *discordgo.MessageAllowedMentions {
+	var mentions *discordgo.MessageAllowedMentions
+	if ctx.mux != nil {
+		mentions = ctx.mux.mentionPolicy()
+	}
+	if mentions == nil {
+		return &discordgo.MessageAllowedMentions{
+			Parse: []discordgo.AllowedMentionType{
+				discordgo.AllowedMentionTypeUsers,
+			},
+		}
+	}
+
+	policy := *mentions
+	return &policy
+}
+
+// Context returns the context.Context of the invocation. It is cancelled when
+// the handler returns, when the command exceeds its Timeout, or when
+// Mux.Shutdown() gives up waiting. Pass it to downstream calls so they are
+// abandoned along with the invocation.
+func (ctx *Context) Context() context.Context {
+	ctx.invocationMu.Lock()
+	defer ctx.invocationMu.Unlock()
+
+	if ctx.invocation == nil {
+		return context.Background()
+	}
+	return ctx.invocation
+}
+
+// setContext replaces the context.Context of the invocation
+func (ctx *Context) setContext(c context.Context) {
+	ctx.invocationMu.Lock()
+	ctx.invocation = c
+	ctx.invocationMu.Unlock()
+}
+
+// OnDone registers a function called once the invocation is over: after its
+// handler returned, or once it was rejected, e.g. for lacking permissions. err
+// is the error the invocation failed with, if any. If the invocation is
+// already over, f is called right away.
+func (ctx *Context) OnDone(f func(err error)) {
+	ctx.doneMu.Lock()
+	if !ctx.done {
+		ctx.onDone = append(ctx.onDone, f)
+		ctx.doneMu.Unlock()
+		return
+	}
+	err := ctx.doneErr
+	ctx.doneMu.Unlock()
+
+	f(err)
+}
+
+// finish marks the invocation as over and calls the OnDone functions
+func (ctx *Context) finish(err error) {
+	ctx.doneMu.Lock()
+	if ctx.done {
+		ctx.doneMu.Unlock()
+		return
+	}
+	ctx.done, ctx.doneErr = true, err
+	onDone := ctx.onDone
+	ctx.onDone = nil
+	ctx.doneMu.Unlock()
+
+	for _, f := range onDone {
+		f(err)
+	}
+}
+
+// doneSpan finishes the invocation of a Context when the span ends
+type doneSpan struct {
+	Span
+	ctx *Context
+}
+
+func (s *doneSpan) End(err error) {
+	s.Span.End(err)
+	s.ctx.finish(err)
+}
@@ -0,0 +1,140 @@
+package disgomux
+
+import "github.com/bwmarrin/discordgo"
+
+// ContextMenuType is the kind of thing a context menu command applies to
+type ContextMenuType int
+
+// Context menu types
+const (
+	ContextMenuUser ContextMenuType = iota
+	ContextMenuMessage
+)
+
+// ContextMenuCommand is a command shown in the context (right-click) menu of
+// users or messages. Context.TargetUser() and Context.TargetMessage() return
+// what it was used on.
+type ContextMenuCommand struct {
+	Name        string
+	Type        ContextMenuType
+	Handler     func(ctx *Context)
+	Permissions *CommandPermissions
+}
+
+// RegisterContextMenu registers one or more context menu commands. Like slash
+// commands, they are created on Discord by Mux.SyncApplicationCommands().
+func (m *Mux) RegisterContextMenu(commands ...ContextMenuCommand) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.contextMenus == nil {
+		m.contextMenus = make(map[string]ContextMenuCommand)
+	}
+	for _, c := range commands {
+		if len(c.Name) != 0 {
+			m.contextMenus[contextMenuKey(c.Type.commandType(), c.Name)] = c
+		}
+	}
+}
+
+// commandType returns the application command type of the context menu type
+func (t ContextMenuType) commandType() discordgo.ApplicationCommandType {
+	if t == ContextMenuMessage {
+		return discordgo.MessageApplicationCommand
+	}
+	return discordgo.UserApplicationCommand
+}
+
+// contextMenuKey identifies a context menu command by type and name
+func contextMenuKey(t discordgo.ApplicationCommandType, name string) string {
+	return appCommandKey(&discordgo.ApplicationCommand{Type: t, Name: name})
+}
+
+// contextMenuCommands converts the registered context menu commands into
+// application commands. Must be called with mu held.
+func (m *Mux) contextMenuCommands() []*discordgo.ApplicationCommand {
+	var commands []*discordgo.ApplicationCommand
+	for _, c := range m.contextMenus {
+		commands = append(commands, &discordgo.ApplicationCommand{
+			Type:                     c.Type.commandType(),
+			Name:                     c.Name,
+			DefaultMemberPermissions: m.defaultMemberPermissions(c.Permissions),
+		})
+	}
+	return commands
+}
+
+// handleContextMenu routes a context menu interaction to its command
+func (m *Mux) handleContextMenu(
+	session *discordgo.Session,
+	interaction *discordgo.InteractionCreate,
+	data discordgo.ApplicationCommandInteractionData,
+) {
+	m.mu.RLock()
+	c, ok := m.contextMenus[contextMenuKey(data.CommandType, data.Name)]
+	m.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	m.countMessage(session)
+
+	ctx := m.interactionContext(session, interaction, c.Name)
+	invocationCtx, span := m.startSpan(ctx, spanInvocation)
+	ctx.invocation = invocationCtx
+
+	if err := m.admit(ctx, c.Name, admission{permissions: c.Permissions}); err != nil {
+		span.End(err)
+		return
+	}
+
+	m.dispatch(ctx, &funcCommand{
+		settings: CommandSettings{Command: c.Name},
+		handle:   c.Handler,
+	}, span)
+}
+
+// TargetUser returns the user a user context menu command was used on
+func (ctx *Context) TargetUser() *discordgo.User {
+	data, ok := ctx.targetData()
+	if !ok {
+		return nil
+	}
+	return data.Resolved.Users[data.TargetID]
+}
+
+// TargetMember returns the guild member a user context menu command was used
+// on. Its User field is not set; see TargetUser().
+func (ctx *Context) TargetMember() *discordgo.Member {
+	data, ok := ctx.targetData()
+	if !ok {
+		return nil
+	}
+	return data.Resolved.Members[data.TargetID]
+}
+
+// TargetMessage returns the message a message context menu command was used on
+func (ctx *Context) TargetMessage() *discordgo.Message {
+	data, ok := ctx.targetData()
+	if !ok {
+		return nil
+	}
+	return data.Resolved.Messages[data.TargetID]
+}
+
+// targetData returns the data of a context menu interaction
+func (ctx *Context) targetData() (
+	discordgo.ApplicationCommandInteractionData,
+	bool,
+) {
+	if ctx.Interaction == nil ||
+		ctx.Interaction.Type != discordgo.InteractionApplicationCommand {
+		return discordgo.ApplicationCommandInteractionData{}, false
+	}
+
+	data := ctx.Interaction.ApplicationCommandData()
+	if data.TargetID == "" || data.Resolved == nil {
+		return discordgo.ApplicationCommandInteractionData{}, false
+	}
+	return data, true
+}
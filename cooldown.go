@@ -0,0 +1,143 @@
+package disgomux
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// CooldownScope determines which invocations share a cooldown
+type CooldownScope int
+
+// Cooldown scopes
+const (
+	CooldownUser CooldownScope = iota
+	CooldownChannel
+	CooldownGuild
+	CooldownGlobal
+)
+
+type (
+	// Cooldown limits a command to one use per Duration within its Scope
+	Cooldown struct {
+		Duration time.Duration
+		Scope    CooldownScope
+	}
+
+	// CooldownStore tracks usage buckets for cooldowns. Implementations must be
+	// safe for concurrent use.
+	CooldownStore interface {
+		// Take consumes one use of the bucket identified by key, which allows
+		// limit uses per window. It reports whether the use was allowed and
+		// when the bucket resets.
+		Take(
+			key string, limit int, window time.Duration, now time.Time,
+		) (bool, time.Time, error)
+	}
+
+	// MemoryCooldownStore is a CooldownStore which keeps buckets in memory
+	MemoryCooldownStore struct {
+		mu      sync.Mutex
+		buckets map[string]*cooldownBucket
+		sweep   time.Time
+	}
+
+	cooldownBucket struct {
+		uses  int
+		reset time.Time
+	}
+)
+
+// NewMemoryCooldownStore creates an empty in-memory cooldown store
+func NewMemoryCooldownStore() *MemoryCooldownStore {
+	return &MemoryCooldownStore{buckets: make(map[string]*cooldownBucket)}
+}
+
+// Take consumes one use of the bucket identified by key
+func (s *MemoryCooldownStore) Take(
+	key string, limit int, window time.Duration, now time.Time,
+) (bool, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	/* Drop expired buckets every so often so the map doesn't grow forever */
+	if now.After(s.sweep) {
+		for k, b := range s.buckets {
+			if !now.Before(b.reset) {
+				delete(s.buckets, k)
+			}
+		}
+		s.sweep = now.Add(time.Minute)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok || !now.Before(b.reset) {
+		b = &cooldownBucket{reset: now.Add(window)}
+		s.buckets[key] = b
+	}
+
+	if b.uses >= limit {
+		return false, b.reset, nil
+	}
+
+	b.uses++
+	return true, b.reset, nil
+}
+
+// SetCooldownStore sets the store used to track cooldowns. Defaults to a
+// MemoryCooldownStore.
+func (m *Mux) SetCooldownStore(store CooldownStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cooldowns = store
+}
+
+// cooldownStore returns the store used to track cooldowns
+func (m *Mux) cooldownStore() CooldownStore {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.cooldowns
+}
+
+// onCooldown consumes a use of the cooldown c for the message author, and
+// returns how long is left if the command is still cooling down. Store errors
+// let the invocation through.
+func (m *Mux) onCooldown(
+	name string,
+	c *Cooldown,
+	message *discordgo.MessageCreate,
+) time.Duration {
+	if c == nil || c.Duration <= 0 {
+		return 0
+	}
+
+	now := m.clock().Now()
+	ok, reset, err := m.cooldownStore().Take(
+		cooldownKey(name, c.Scope, message), 1, c.Duration, now,
+	)
+	if err != nil || ok {
+		return 0
+	}
+	return reset.Sub(now)
+}
+
+// cooldownKey identifies the bucket an invocation of a command falls into
+func cooldownKey(
+	name string,
+	scope CooldownScope,
+	message *discordgo.MessageCreate,
+) string {
+	switch scope {
+	case CooldownChannel:
+		return name + ":c:" + message.ChannelID
+	case CooldownGuild:
+		return name + ":g:" + message.GuildID
+	case CooldownGlobal:
+		return name
+	default:
+		return name + ":u:" + message.Author.ID
+	}
+}
@@ -0,0 +1,140 @@
+package disgomux
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		want     []int
+	}{
+		{"*", 0, 5, []int{0, 1, 2, 3, 4, 5}},
+		{"3", 0, 59, []int{3}},
+		{"1,4,7", 0, 59, []int{1, 4, 7}},
+		{"2-5", 0, 59, []int{2, 3, 4, 5}},
+		{"*/15", 0, 59, []int{0, 15, 30, 45}},
+		{"10-20/5", 0, 59, []int{10, 15, 20}},
+		{"50/4", 0, 59, []int{50, 54, 58}},
+		{"*/2", 1, 7, []int{1, 3, 5, 7}},
+		{"1-3,10-11", 1, 31, []int{1, 2, 3, 10, 11}},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCronField(tt.field, tt.min, tt.max)
+		if err != nil {
+			t.Errorf("parseCronField(%q): %v", tt.field, err)
+			continue
+		}
+
+		var want uint64
+		for _, v := range tt.want {
+			want |= 1 << uint(v)
+		}
+		if got != want {
+			t.Errorf("parseCronField(%q) = %b, want %b", tt.field, got, want)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"@every",
+		"@every -1m",
+		"@every soon",
+	} {
+		if _, err := parseSchedule(spec); err == nil {
+			t.Errorf("parseSchedule(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	at := func(s string) time.Time {
+		t, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	tests := []struct {
+		spec, after, want string
+	}{
+		{"* * * * *", "2024-03-10 12:30", "2024-03-10 12:31"},
+		{"*/15 * * * *", "2024-03-10 12:31", "2024-03-10 12:45"},
+		{"*/15 * * * *", "2024-03-10 12:50", "2024-03-10 13:00"},
+		{"0 9-17/4 * * *", "2024-03-10 13:00", "2024-03-10 17:00"},
+		{"0 9-17/4 * * *", "2024-03-10 17:00", "2024-03-11 09:00"},
+		{"@daily", "2024-12-31 23:59", "2025-01-01 00:00"},
+		{"@hourly", "2024-03-10 12:00", "2024-03-10 13:00"},
+
+		/* Month ends roll over to the next month with the day */
+		{"0 0 31 * *", "2024-04-15 00:00", "2024-05-31 00:00"},
+		{"0 0 29 2 *", "2023-03-01 00:00", "2024-02-29 00:00"},
+		{"0 12 1 * *", "2024-01-31 12:00", "2024-02-01 12:00"},
+
+		/* Sunday is both 0 and 7 */
+		{"0 0 * * 7", "2024-03-11 00:00", "2024-03-17 00:00"},
+		{"@weekly", "2024-03-11 00:00", "2024-03-17 00:00"},
+
+		/* Restricted day of month and day of week: either matches */
+		{"0 0 13 * 5", "2024-03-01 00:00", "2024-03-08 00:00"},
+		{"0 0 13 * 5", "2024-09-07 00:00", "2024-09-13 00:00"},
+		{"0 0 13 * 5", "2024-03-08 00:00", "2024-03-13 00:00"},
+
+		/* A step over the full range is unrestricted: both must match */
+		{"0 0 */2 * 1", "2024-03-01 00:00", "2024-03-11 00:00"},
+		{"0 0 13 * */2", "2024-03-01 00:00", "2024-04-13 00:00"},
+	}
+
+	for _, tt := range tests {
+		s, err := parseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("parseSchedule(%q): %v", tt.spec, err)
+			continue
+		}
+
+		got := s.next(at(tt.after))
+		if want := at(tt.want); !got.Equal(want) {
+			t.Errorf("%q after %s = %s, want %s",
+				tt.spec, tt.after, got.Format("2006-01-02 15:04 Mon"), tt.want)
+		}
+	}
+}
+
+func TestScheduleNextNever(t *testing.T) {
+	s, err := parseSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := s.next(time.Now()); !next.IsZero() {
+		t.Errorf("February 30th scheduled at %s", next)
+	}
+}
+
+func TestEveryScheduleNext(t *testing.T) {
+	s, err := parseSchedule("@every 90s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after := time.Date(2024, 3, 10, 12, 0, 30, 0, time.UTC)
+	if got, want := s.next(after), after.Add(90*time.Second); !got.Equal(want) {
+		t.Errorf("next = %s, want %s", got, want)
+	}
+}
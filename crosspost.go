@@ -0,0 +1,63 @@
+package disgomux
+
+import (
+	"sync/atomic"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// SendAndCrosspost sends content to the current channel and, if it is an
+// announcement channel, publishes the message to the channels following it
+func (ctx *Context) SendAndCrosspost(content string) (*discordgo.Message, error) {
+	msg, err := ctx.ChannelSend(content)
+	if err != nil || ctx.crossposting() {
+		return msg, err
+	}
+	return ctx.Crosspost(msg)
+}
+
+// Crosspost publishes a message the bot sent in an announcement channel to the
+// channels following it. Messages in other channels are returned unchanged.
+func (ctx *Context) Crosspost(
+	msg *discordgo.Message,
+) (*discordgo.Message, error) {
+	if !ctx.announcementChannel(msg.ChannelID) {
+		return msg, nil
+	}
+	return ctx.API().ChannelMessageCrosspost(msg.ChannelID, msg.ID)
+}
+
+// CrosspostReplies publishes all further messages the helpers of this Context
+// send to an announcement channel, as Crosspost(). Failures to publish are
+// reported through the error hook rather than failing the send.
+func (ctx *Context) CrosspostReplies() {
+	atomic.StoreInt32(&ctx.crosspost, 1)
+}
+
+// crossposting reports whether helper sends are published
+func (ctx *Context) crossposting() bool {
+	return atomic.LoadInt32(&ctx.crosspost) == 1
+}
+
+// publish crossposts a message sent by a helper if CrosspostReplies() is in
+// effect
+func (ctx *Context) publish(msg *discordgo.Message) {
+	if !ctx.crossposting() || msg == nil || msg.ID == "" {
+		return
+	}
+	if _, err := ctx.Crosspost(msg); err != nil {
+		ctx.mux.logCtx(ctx, LogWarn, "Failed to crosspost: "+err.Error())
+		ctx.mux.reportCtx(ctx, err)
+	}
+}
+
+// announcementChannel reports whether the channel is an announcement channel.
+// Only the invoking channel is looked up; threads and DMs never are.
+func (ctx *Context) announcementChannel(channelID string) bool {
+	if channelID != ctx.Message.ChannelID || ctx.Message.GuildID == "" {
+		return false
+	}
+
+	channel, err := ctx.Channel()
+	return err == nil && channel.Type == discordgo.ChannelTypeGuildNews
+}
@@ -0,0 +1,45 @@
+package disgomux
+
+import (
+	"expvar"
+	"sync/atomic"
+)
+
+// DebugStats is a snapshot of the basic counters of a mux
+type DebugStats struct {
+	MessagesSeen       uint64
+	CommandsDispatched uint64
+	NotFound           uint64
+	ActiveHandlers     int64
+	QueueDepth         int
+}
+
+// debugCounters hold the counters behind DebugStats
+type debugCounters struct {
+	messages, dispatched, notFound uint64
+	active                         int64
+}
+
+// DebugStats returns a snapshot of the basic counters of the mux, for
+// visibility without a metrics system
+func (m *Mux) DebugStats() DebugStats {
+	s := DebugStats{
+		MessagesSeen:       atomic.LoadUint64(&m.counters.messages),
+		CommandsDispatched: atomic.LoadUint64(&m.counters.dispatched),
+		NotFound:           atomic.LoadUint64(&m.counters.notFound),
+		ActiveHandlers:     atomic.LoadInt64(&m.counters.active),
+	}
+	if p := m.workers(); p != nil {
+		s.QueueDepth = len(p.jobs)
+	}
+	return s
+}
+
+// PublishExpvar publishes DebugStats() as an expvar variable with the name, so
+// it is served on /debug/vars. Like expvar.Publish, it panics if the name is
+// already in use.
+func (m *Mux) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return m.DebugStats()
+	}))
+}
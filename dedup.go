@@ -0,0 +1,59 @@
+package disgomux
+
+import (
+	"sync"
+	"time"
+)
+
+// dedup remembers recently handled message IDs
+type dedup struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[string]time.Time
+	sweep  time.Time
+}
+
+// SetDedupWindow makes the mux ignore a message it has already handled within
+// window, such as one replayed after a reconnect or delivered by several
+// sessions sharing the mux. Zero disables deduplication, the default.
+func (m *Mux) SetDedupWindow(window time.Duration) {
+	m.dedup.mu.Lock()
+	defer m.dedup.mu.Unlock()
+
+	m.dedup.window = window
+	if window <= 0 {
+		m.dedup.seen = nil
+		return
+	}
+	if m.dedup.seen == nil {
+		m.dedup.seen = make(map[string]time.Time)
+	}
+}
+
+// duplicate records the message ID, and reports whether it was already seen
+// within the window
+func (d *dedup) duplicate(messageID string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.window <= 0 {
+		return false
+	}
+
+	/* Forget expired IDs every so often so the map doesn't grow forever */
+	if now.After(d.sweep) {
+		for id, expires := range d.seen {
+			if !now.Before(expires) {
+				delete(d.seen, id)
+			}
+		}
+		d.sweep = now.Add(d.window)
+	}
+
+	if expires, ok := d.seen[messageID]; ok && now.Before(expires) {
+		return true
+	}
+
+	d.seen[messageID] = now.Add(d.window)
+	return false
+}
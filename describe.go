@@ -0,0 +1,112 @@
+package disgomux
+
+import (
+	"sort"
+	"time"
+)
+
+type (
+	// RouterDescription is a snapshot of the routing table of a mux
+	RouterDescription struct {
+		Prefix         string
+		Commands       []CommandDescription
+		SimpleCommands []SimpleCommandDescription
+		Middleware     int
+		Options        Options
+	}
+
+	// CommandDescription describes a registered command
+	CommandDescription struct {
+		Name, HelpText, Category string
+		Aliases                  []string
+		Permissions              *CommandPermissions
+		Cooldown                 *Cooldown
+		Timeout                  time.Duration
+
+		// Usage is how the command is invoked, as Context.Usage() returns
+		Usage string
+
+		// Examples are sample invocations, from the command settings
+		Examples []string
+	}
+
+	// SimpleCommandDescription describes a registered simple command. GuildID
+	// is empty for global simple commands.
+	SimpleCommandDescription struct {
+		Name, HelpText, GuildID string
+		Aliases                 []string
+		Permissions             *CommandPermissions
+		Cooldown                *Cooldown
+	}
+)
+
+// Describe returns the routing table of the mux as structured data, e.g. for a
+// dashboard or a debug command. Commands are sorted by name, simple commands
+// by guild then name.
+func (m *Mux) Describe() RouterDescription {
+	m.mu.RLock()
+	d := RouterDescription{
+		Prefix:     m.Prefix,
+		Middleware: len(m.Middleware),
+		Options:    *m.options,
+	}
+	for _, c := range m.Commands {
+		settings := c.Settings()
+		d.Commands = append(d.Commands, CommandDescription{
+			Name:        settings.Command,
+			HelpText:    settings.HelpText,
+			Category:    settings.Category,
+			Aliases:     settings.Aliases,
+			Permissions: c.Permissions(),
+			Cooldown:    settings.Cooldown,
+			Timeout:     settings.Timeout,
+			Usage:       usage(m.Prefix, settings.Command, settings.Arguments),
+			Examples:    settings.Examples,
+		})
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(d.Commands, func(i, j int) bool {
+		return d.Commands[i].Name < d.Commands[j].Name
+	})
+
+	m.simpleMu.RLock()
+	d.SimpleCommands = describeSimple(d.SimpleCommands, m.SimpleCommands)
+	for _, table := range m.GuildSimpleCommands {
+		d.SimpleCommands = describeSimple(d.SimpleCommands, table)
+	}
+	m.simpleMu.RUnlock()
+
+	sort.Slice(d.SimpleCommands, func(i, j int) bool {
+		a, b := d.SimpleCommands[i], d.SimpleCommands[j]
+		if a.GuildID != b.GuildID {
+			return a.GuildID < b.GuildID
+		}
+		return a.Name < b.Name
+	})
+
+	return d
+}
+
+// describeSimple appends the descriptions of the simple commands in table,
+// skipping the entries of aliases
+func describeSimple(
+	descriptions []SimpleCommandDescription,
+	table map[string]SimpleCommand,
+) []SimpleCommandDescription {
+	for k, c := range table {
+		if k != c.Command {
+			continue
+		}
+
+		descriptions = append(descriptions, SimpleCommandDescription{
+			Name:        c.Command,
+			HelpText:    c.HelpText,
+			GuildID:     c.GuildID,
+			Aliases:     c.Aliases,
+			Permissions: c.Permissions,
+			Cooldown:    c.Cooldown,
+		})
+	}
+	return descriptions
+}
@@ -1,24 +1,110 @@
 package disgomux
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/sahilm/fuzzy"
 )
 
 type (
 	// Mux is the multiplexer object. Initialized with New().
+	//
+	// Its methods are safe to call while Handle() runs, e.g. to register a
+	// command at runtime, except for the ones wiring up integrations, which
+	// must be called before: SetLogger, SetTracer, SetMetrics,
+	// SetErrorReporter, SetStatsStore, SetConfigStore, SetPermissionSync,
+	// SetAuditChannel, OnAudit, OnSendFailure and SetErrorRefs. Commands,
+	// SimpleCommands, Middleware and GuildSimpleCommands must not be modified
+	// directly once Handle() may be called; use the Register and Use methods
+	// instead.
 	Mux struct {
 		Prefix         string
 		Commands       map[string]Command
 		SimpleCommands map[string]SimpleCommand
 		Middleware     []Middleware
-		options        *Options
-		fuzzyMatch     bool
-		commandNames   []string
-		errorTexts     ErrorTexts
+
+		// GuildSimpleCommands holds the simple commands scoped to a guild,
+		// keyed by guild ID
+		GuildSimpleCommands map[string]map[string]SimpleCommand
+
+		mu               sync.RWMutex
+		options          *Options
+		simpleMu         sync.RWMutex
+		simpleStore      SimpleCommandStore
+		cooldowns        CooldownStore
+		fuzzyMatch       bool
+		matcher          Matcher
+		commandNames     []string
+		aliases          map[string]string
+		registration     []LintProblem
+		components       customIDRouter
+		modals           customIDRouter
+		contextMenus     map[string]ContextMenuCommand
+		memberJoin       []func(ctx *Context)
+		memberLeave      []func(ctx *Context)
+		eventHandlers    map[string][]func(ctx *Context)
+		guildJoin        func(ctx *Context)
+		knownGuilds      sync.Map
+		configs          ConfigStore
+		configSource     ConfigSource
+		locale           string
+		catalog          *Catalog
+		owners           []string
+		session          Session
+		dryRun           *MockSession
+		clockSource      Clock
+		modules          map[string]*loadedModule
+		moduleMiddleware []Middleware
+		reactSuccess     string
+		reactFailure     string
+		dmChannels       sync.Map
+		webhooks         sync.Map
+		dmFailure        func(*Context, error)
+		onError          func(*Context, error)
+		onReject         func(*Context, error)
+		maintenance      maintenance
+		cached           responseCache
+		inhibitors       []Inhibitor
+		dmRouting        *dmRouting
+		chaining         chaining
+		notFound         NotFoundHandler
+		mentions         *discordgo.MessageAllowedMentions
+		events           events
+		tracked          *responseTracker
+		temps            temporaries
+		disabled         map[string]map[string]bool
+		suppressed       map[string]bool
+		errorTexts       ErrorTexts
+		errorTemplates   sync.Map
+		errorStyle       ErrorStyle
+		errorTTL         time.Duration
+		errorRefs        bool
+		sendFailure      func(channelID string, err error)
+		sendRetries      int
+		reporter         ErrorReporter
+		logger           Logger
+		tracer           Tracer
+		metrics          Metrics
+		stats            StatsStore
+		history          HistoryStore
+		counters         debugCounters
+		shards           shardCounters
+		panicChannel     string
+		onAudit          func(AuditEntry)
+		auditChannel     string
+		pool             *workerPool
+		overflow         OverflowPolicy
+		channelQueues    channelQueues
+		lifecycle        lifecycle
+		dedup            dedup
+		permissionSync   bool
 	}
 
 	// Command specifies the functions for a multiplexed command
@@ -30,6 +116,31 @@ type (
 		Permissions() *CommandPermissions
 	}
 
+	// ErrorCommand is a Command whose handler reports failures. The mux calls
+	// HandleErr instead of Handle, and passes any error to the OnError hook.
+	ErrorCommand interface {
+		Command
+		HandleErr(ctx *Context) error
+	}
+
+	// ErrorTexts holds strings used when an error occurs. Each text may contain
+	// text/template actions, which are rendered with ErrorTemplateData. An
+	// empty text sends nothing, e.g. to ignore cooldowns silently.
+	ErrorTexts struct {
+		CommandNotFound, NoPermissions, Cooldown, HandlerError string
+
+		// Busy is sent when an invocation is rejected because the worker pool
+		// queue is full, with the OverflowReject policy
+		Busy string
+
+		// InvalidArguments is sent when the arguments of a message do not
+		// match the argument spec of the command
+		InvalidArguments string
+
+		// QuotaExceeded is sent when the quota of a command is used up
+		QuotaExceeded string
+	}
+
 	// CommandPermissions holds permissions for a given command in whitelist
 	// format. UserID takes priority over all other permissions. RoleID takes
 	// priority over ChanID.
@@ -37,134 +148,807 @@ type (
 		UserIDs []string
 		RoleIDs []string
 		ChanIDs []string
+
+		// Permissions allows members who have all of these permission bits
+		// (discordgo.PermissionManageMessages...) in the channel
+		Permissions int64
 	}
 
 	// CommandSettings contain command-specific settings the multiplexer should
 	// know.
 	CommandSettings struct {
 		Command, HelpText string
+		Cooldown          *Cooldown
+
+		// Quota limits the uses of the command per day or week, on top of
+		// its cooldown
+		Quota *Quota
+
+		// Cache serves repeated identical invocations from the responses of
+		// the first, for Cache.TTL
+		Cache *Cache
+
+		// Aliases are alternative names the command can be invoked with
+		Aliases []string
+
+		// Category groups related commands, e.g. in help listings
+		Category string
+
+		// Examples are sample invocations of the command without the prefix,
+		// e.g. "remind 10m stretch", listed in the command manifest
+		Examples []string
+
+		// Arguments describe the arguments the command takes, in order. They
+		// become the options of the command when synced as a slash command.
+		Arguments []Argument
+
+		// NameLocalizations and DescriptionLocalizations translate the name
+		// and help text of the command when synced as a slash command
+		NameLocalizations        map[discordgo.Locale]string
+		DescriptionLocalizations map[discordgo.Locale]string
+
+		// Typing shows the typing indicator in the channel while the handler runs
+		Typing bool
+
+		// DeleteInvocation deletes the invoking message once the command is
+		// dispatched, if the bot has permission to manage messages
+		DeleteInvocation bool
+
+		// ReplyInThread sends the responses of Context helpers to a thread
+		// started from the invoking message, named ThreadName (defaults to the
+		// command name). The thread is only created once something is sent.
+		ReplyInThread bool
+		ThreadName    string
+
+		// OutputChannel is the ID of a channel the responses of Context
+		// helpers are sent to instead of the invoking channel, which gets a
+		// short acknowledgement. GuildConfig.OutputChannels overrides it.
+		// See Context.RedirectOutput().
+		OutputChannel string
+
+		// OwnerOnly restricts the command to the owners of the bot (see
+		// Mux.SetOwners()). Owner-only commands stay available in
+		// maintenance mode.
+		OwnerOnly bool
+
+		// Inhibitors decide whether an invocation may go ahead, after those
+		// added with Mux.UseInhibitor(), e.g. RequireVoice()
+		Inhibitors []Inhibitor
+
+		// Crosspost publishes the responses of Context helpers sent to an
+		// announcement channel to the channels following it. See
+		// Context.CrosspostReplies().
+		Crosspost bool
+
+		// Priority runs the handler on its own goroutine right away,
+		// bypassing the worker pool queue and SerializeChannels, so e.g.
+		// moderation commands still work while a flood backlogs the others
+		Priority bool
+
+		// Synchronous runs the handler in the goroutine calling Handle(), so
+		// invocations are handled strictly in the order they arrive
+		Synchronous bool
+
+		// Defer acknowledges slash command invocations before the handler
+		// runs, for handlers which may take longer than the three seconds
+		// Discord waits for a response. See Context.Defer().
+		Defer bool
+
+		// Timeout is the maximum time the handler may run. Once exceeded, the
+		// invocation's context is cancelled and ErrCommandTimeout is reported
+		// through the error hook. Zero means no limit.
+		Timeout time.Duration
 	}
 
 	// SimpleCommand contains the content and helptext of a logic-less command.
-	// Simple commands have no support for permissions.
+	// Permissions are optional and evaluated the same way as for a Command.
+	// Content may contain text/template actions which are rendered with
+	// SimpleTemplateData at send time. If Contents is set, one entry is picked
+	// at random (or in order, with RoundRobin) for each invocation instead.
+	// Embed and File, if set, are sent along with the (optional) content.
+	// Reactions are added to the invoking message; a simple command with
+	// reactions and nothing to send only reacts.
 	SimpleCommand struct {
 		Command, Content, HelpText string
+		GuildID                    string
+		Aliases                    []string
+		Contents                   []string
+		RoundRobin                 bool
+		Embed                      *discordgo.MessageEmbed
+		File                       *SimpleFile
+		Reactions                  []string
+		Permissions                *CommandPermissions
+		Cooldown                   *Cooldown
+
+		// Tag marks the simple commands created with the TagCommand, the only
+		// ones it edits and removes. The contents of tags are rendered with
+		// the placeholders of TagCommand rather than as templates.
+		Tag bool
+
+		state *simpleState
 	}
 
-	// ErrorTexts holds strings used when an error occurs
-	ErrorTexts struct {
-		CommandNotFound, NoPermissions string
+	// SimpleFile is a file attached to the response of a simple command. The
+	// content is taken from Data, or else read from Path or fetched from URL
+	// and cached when the mux is initialized.
+	SimpleFile struct {
+		Name, Path, URL string
+		Data            []byte
 	}
 
 	// Context is the contexual values supplied to middlewares and handlers
 	Context struct {
 		Prefix, Command string
 		Arguments       []string
-		Session         *discordgo.Session
-		Message         *discordgo.MessageCreate
+
+		// Session is the gateway session the invocation arrived on.
+		//
+		// Deprecated: send requests through API(), which honours
+		// SetSession() and dry runs, and use State(), BotUser() and
+		// Latency() for what only the gateway session has.
+		Session *discordgo.Session
+		Message *discordgo.MessageCreate
+
+		// Interaction is set when the invocation is a slash command, in which
+		// case Message is built from the interaction: it has the ID, channel,
+		// guild and author of the interaction, and no content
+		Interaction *discordgo.InteractionCreate
+
+		// Subcommand is the path of the subcommand invoked below Command, e.g.
+		// "set prefix". Empty if no subcommand was invoked.
+		Subcommand string
+
+		// Chain is set when the invocation is one of several chained in a
+		// message, see Mux.SetChaining()
+		Chain *Chain
+
+		// Macro is the name of the guild macro the invocation was expanded
+		// from, in which case Command is the command it expands to. Empty if
+		// no macro was invoked.
+		Macro string
+
+		// Invocation abstracts over the message or interaction behind the
+		// Context
+		Invocation Invocation
+
+		// Event is the gateway event behind a Context built for an event
+		// other than a message or interaction, e.g. *discordgo.GuildMemberAdd
+		Event interface{}
+
+		spec         []Argument
+		named        map[string]string
+		argsOnce     sync.Once
+		mux          *Mux
+		id           string
+		perms        *permissionCheck
+		config       GuildConfig
+		typing       *typing
+		lookups      lookups
+		responses    []*discordgo.Message
+		responsesMu  sync.Mutex
+		thread       threadRedirect
+		output       outputRedirect
+		cache        responseCapture
+		crosspost    int32
+		invocation   context.Context
+		invocationMu sync.Mutex
+		onDone       []func(error)
+		done         bool
+		doneErr      error
+		doneMu       sync.Mutex
 	}
 
+	// NotFoundHandler is called when a message has the prefix but does not
+	// match any command
+	NotFoundHandler func(*Context)
+
 	// Middleware specifies a special middleware function that is called anytime
 	// handle() is called from DiscordGo
 	Middleware func(*Context)
 
 	// Options is a set of config options to use when handling a message. All
-	// properties true by default.
+	// properties except IgnoreUnknown and IgnoreThreads true by default. New
+	// fields may be added, so use keyed literals or NewOptions().
 	Options struct {
 		IgnoreBots       bool
 		IgnoreDMs        bool
 		IgnoreEmpty      bool
 		IgnoreNonDefault bool
+
+		// IgnoreUnknown makes the mux ignore prefixed messages which don't
+		// match a command, instead of replying, for bots sharing a prefix
+		IgnoreUnknown bool
+
+		// IgnoreThreads makes the mux ignore messages in threads, including
+		// forum posts. Otherwise threads are handled as part of their parent
+		// channel: ignored along with it, and evaluated with its permissions.
+		IgnoreThreads bool
+
+		// Synchronous runs middleware and handlers in the goroutine calling
+		// Handle() rather than in new goroutines, e.g. for deterministic tests
+		Synchronous bool
+
+		// SerializeChannels runs at most one handler per channel at a time,
+		// queueing further invocations in the channel in arrival order
+		SerializeChannels bool
 	}
 )
 
-// New initlaizes a new Mux object
+// New initlaizes a new Mux object. See NewMux() to configure it with options.
 func New(prefix string) (*Mux, error) {
-	if len(prefix) > 1 {
-		return &Mux{}, fmt.Errorf("Prefix %s greater than 1 character", prefix)
+	if err := ValidatePrefix(prefix); err != nil {
+		return &Mux{}, err
 	}
 
+	m := newMux()
+	m.Prefix = prefix
+	return m, nil
+}
+
+// newMux creates a mux with the default configuration
+func newMux() *Mux {
 	return &Mux{
-		Prefix:         prefix,
-		Commands:       make(map[string]Command),
-		SimpleCommands: make(map[string]SimpleCommand),
-		Middleware:     []Middleware{},
+		Commands:            make(map[string]Command),
+		SimpleCommands:      make(map[string]SimpleCommand),
+		GuildSimpleCommands: make(map[string]map[string]SimpleCommand),
+		Middleware:          []Middleware{},
+		disabled:            make(map[string]map[string]bool),
+		suppressed:          make(map[string]bool),
+		aliases:             make(map[string]string),
 		errorTexts: ErrorTexts{
-			CommandNotFound: "Command not found.",
-			NoPermissions:   "You do not have permission to use that command.",
+			CommandNotFound:  "Command not found.",
+			NoPermissions:    "You do not have permission to use that command.",
+			Cooldown:         "You are using that command too quickly.",
+			HandlerError:     "Something went wrong running that command.",
+			Busy:             "I'm a little busy right now, try again in a moment.",
+			InvalidArguments: "Invalid arguments, usage: `{{.Usage}}`",
+			QuotaExceeded:    "You have used up your quota for that command, it resets <t:{{.ResetsAt.Unix}}:R>.",
+		},
+		cooldowns:    NewMemoryCooldownStore(),
+		stats:        NewMemoryStatsStore(),
+		configs:      NewMemoryConfigStore(),
+		reactSuccess: "✅",
+		reactFailure: "❌",
+		sendRetries:  defaultSendRetries,
+		options: &Options{
+			IgnoreBots:       true,
+			IgnoreDMs:        true,
+			IgnoreEmpty:      true,
+			IgnoreNonDefault: true,
 		},
-		options:    &Options{true, true, true, true},
 		fuzzyMatch: false,
-	}, nil
+		matcher:    SubsequenceMatcher,
+	}
 }
 
-// Options allows configuration of the multiplexer. Must be called before
-// Initialize()
+// Options allows configuration of the multiplexer. Safe to call while messages
+// are being handled; invocations already routed keep the options they started
+// with. See also the setters of single options, such as SetIgnoreDMs().
 func (m *Mux) Options(opt *Options) {
-	m.options = opt
+	/* Keep a copy, so the options can't change under a running Handle() */
+	o := *opt
+
+	m.mu.Lock()
+	m.options = &o
+	m.mu.Unlock()
+}
+
+// opts returns the current options. They must not be modified.
+func (m *Mux) opts() *Options {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.options
 }
 
 // UseMiddleware adds a middleware to the multiplexer. //TODO: Improve this desc
 func (m *Mux) UseMiddleware(mw Middleware) {
-	m.Middleware = append(m.Middleware, mw)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	/* Copy on write, so a running Handle() keeps its own slice */
+	middleware := make([]Middleware, len(m.Middleware), len(m.Middleware)+1)
+	copy(middleware, m.Middleware)
+	m.Middleware = append(middleware, mw)
 }
 
 // SetErrors sets the error texts for the multiplexer using the supplied struct
 func (m *Mux) SetErrors(errorTexts ErrorTexts) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.errorTexts = errorTexts
 }
 
+// texts returns the error texts of the mux
+func (m *Mux) texts() ErrorTexts {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.errorTexts
+}
+
+// prefix returns the prefix of the mux
+func (m *Mux) prefix() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.Prefix
+}
+
+// SetReactions sets the emojis used by Context.ReactSuccess() and
+// Context.ReactFailure(). Defaults to ✅ and ❌.
+func (m *Mux) SetReactions(success, failure string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reactSuccess = success
+	m.reactFailure = failure
+}
+
+// reactions returns the success and failure emojis
+func (m *Mux) reactions() (success, failure string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.reactSuccess, m.reactFailure
+}
+
+// OnDMFailure sets a hook which is called when a Context DM helper fails
+// because the user has DMs disabled, e.g. to tell them in the channel instead
+func (m *Mux) OnDMFailure(hook func(ctx *Context, err error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.dmFailure = hook
+}
+
+// dmFailureHook returns the OnDMFailure hook, if any
+func (m *Mux) dmFailureHook() func(*Context, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.dmFailure
+}
+
+// SetAllowedMentions sets the default allowed mentions policy applied to every
+// message sent through a Context helper. A nil policy restores the default,
+// which only lets users be mentioned, so echoed user input can't ping
+// @everyone or roles. Opt into them with e.g.
+//
+//	mux.SetAllowedMentions(&discordgo.MessageAllowedMentions{
+//		Parse: []discordgo.AllowedMentionType{
+//			discordgo.AllowedMentionTypeUsers,
+//			discordgo.AllowedMentionTypeRoles,
+//			discordgo.AllowedMentionTypeEveryone,
+//		},
+//	})
+func (m *Mux) SetAllowedMentions(policy *discordgo.MessageAllowedMentions) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.mentions = policy
+}
+
+// mentionPolicy returns the default allowed mentions policy
+func (m *Mux) mentionPolicy() *discordgo.MessageAllowedMentions {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.mentions
+}
+
+// OnError sets the hook called with the error returned by an ErrorCommand.
+// Without a hook, the HandlerError text is sent to the channel.
+func (m *Mux) OnError(hook func(ctx *Context, err error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.onError = hook
+}
+
+// errorHook returns the OnError hook, if any
+func (m *Mux) errorHook() func(*Context, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.onError
+}
+
+// OnReject sets the hook called when an invocation is rejected before its
+// handler runs, replacing the reply the mux would send. err matches one of
+// ErrNotFound, ErrNoPermission, ErrMaintenance, ErrCooldown (as a
+// *CooldownError) or ErrInvalidArguments (as an *ArgumentError), e.g.
+//
+//	mux.OnReject(func(ctx *disgomux.Context, err error) {
+//		var cooldown *disgomux.CooldownError
+//		if errors.As(err, &cooldown) {
+//			ctx.Replyf("Try again in %s.", cooldown.Remaining.Round(time.Second))
+//		}
+//	})
+//
+// A handler set with NotFound() takes precedence for unknown commands. The
+// hook must answer rejected interactions itself.
+func (m *Mux) OnReject(hook func(ctx *Context, err error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.onReject = hook
+}
+
+// rejectHook returns the OnReject hook, if any
+func (m *Mux) rejectHook() func(*Context, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.onReject
+}
+
+// NotFound sets the handler called for unknown commands, replacing the default
+// reply (which lists fuzzy suggestions, if enabled). Use Context.Suggestions()
+// to build custom suggestions.
+func (m *Mux) NotFound(handler NotFoundHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.notFound = handler
+}
+
+// notFoundHandler returns the NotFound handler, if any
+func (m *Mux) notFoundHandler() NotFoundHandler {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.notFound
+}
+
 // Register registers one or more commands to the multiplexer
 func (m *Mux) Register(commands ...Command) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	for _, c := range commands {
-		cString := c.Settings().Command
+		settings := c.Settings()
+		cString := settings.Command
+		if len(cString) == 0 {
+			/* Reported by Lint(), as the command can't be routed */
+			m.registration = append(m.registration, LintProblem{
+				Problem: fmt.Sprintf("%T registered without a name", c),
+			})
+		}
 		if len(cString) != 0 {
+			_, ok := m.Commands[cString]
+			if !ok && m.fuzzyMatch {
+				m.commandNames = append(m.commandNames, cString)
+			}
+			if ok {
+				m.registration = append(m.registration, LintProblem{
+					Command: cString,
+					Problem: "registered more than once, replacing the " +
+						"earlier command",
+					Err: ErrDuplicateCommand,
+				})
+			}
 			m.Commands[cString] = c
+
+			for _, a := range settings.Aliases {
+				if len(a) != 0 {
+					m.aliases[a] = cString
+				}
+			}
 		}
 	}
 }
 
-// RegisterSimple registers one or more simple commands to the multiplexer
-func (m *Mux) RegisterSimple(simpleCommands ...SimpleCommand) {
+// command looks up a registered command by name or alias
+func (m *Mux) command(name string) (Command, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, _, ok := m.lookup(name)
+	return c, ok
+}
+
+// lookup finds the command registered under the name or alias, along with its
+// name. Must be called with mu held.
+func (m *Mux) lookup(name string) (Command, string, bool) {
+	if c, ok := m.Commands[name]; ok {
+		return c, name, true
+	}
+
+	if canonical, ok := m.aliases[name]; ok {
+		c, ok := m.Commands[canonical]
+		return c, canonical, ok
+	}
+	return nil, "", false
+}
+
+// RegisterSimple registers one or more simple commands, along with their
+// aliases, to the multiplexer. Simple commands with a GuildID are only available
+// in that guild, and take precedence over global ones of the same name.
+//
+// Contents which are not valid templates are registered to be sent verbatim,
+// and reported with a *LintError, also returned by Initialize().
+func (m *Mux) RegisterSimple(simpleCommands ...SimpleCommand) error {
+	var problems []LintProblem
+
+	m.simpleMu.Lock()
 	for _, c := range simpleCommands {
-		cString := c.Command
-		if len(cString) != 0 {
-			m.SimpleCommands[cString] = c
+		if len(c.Command) == 0 {
+			continue
+		}
+		if err := addSimple(m.simpleTable(c.GuildID, true), c); err != nil {
+			problems = append(problems, LintProblem{
+				Command: c.Command,
+				Problem: err.Error(),
+			})
+		}
+	}
+	m.simpleMu.Unlock()
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	m.registration = append(m.registration, problems...)
+	m.mu.Unlock()
+	return &LintError{Problems: problems}
+}
+
+// addSimple adds a simple command to a table, along with its aliases,
+// returning the error its contents failed to parse with, if any
+func addSimple(table map[string]SimpleCommand, c SimpleCommand) error {
+	var err error
+	c.state, err = newSimpleState(c)
+	table[c.Command] = c
+	for _, a := range c.Aliases {
+		if len(a) != 0 {
+			table[a] = c
+		}
+	}
+	return err
+}
+
+// UnregisterSimple removes one or more global simple commands, along with their
+// aliases, from the multiplexer. Safe to call while messages are being handled.
+func (m *Mux) UnregisterSimple(commands ...string) {
+	m.UnregisterGuildSimple("", commands...)
+}
+
+// UnregisterGuildSimple removes one or more simple commands scoped to the
+// specified guild, along with their aliases, from the multiplexer
+func (m *Mux) UnregisterGuildSimple(guildID string, commands ...string) {
+	m.simpleMu.Lock()
+	defer m.simpleMu.Unlock()
+
+	table := m.simpleTable(guildID, false)
+	for _, name := range commands {
+		c, ok := table[name]
+		if !ok {
+			continue
+		}
+
+		delete(table, c.Command)
+		for _, a := range c.Aliases {
+			delete(table, a)
+		}
+	}
+}
+
+// Simple returns the global simple command registered under the name or alias
+func (m *Mux) Simple(name string) (SimpleCommand, bool) {
+	return m.GuildSimple("", name)
+}
+
+// GuildSimple returns the simple command scoped to the specified guild which is
+// registered under the name or alias
+func (m *Mux) GuildSimple(guildID, name string) (SimpleCommand, bool) {
+	m.simpleMu.RLock()
+	defer m.simpleMu.RUnlock()
+
+	c, ok := m.simpleTable(guildID, false)[name]
+	return c, ok
+}
+
+// SimpleNames returns the sorted names of all global simple commands, excluding
+// aliases
+func (m *Mux) SimpleNames() []string {
+	return m.GuildSimpleNames("")
+}
+
+// GuildSimpleNames returns the sorted names of all simple commands scoped to the
+// specified guild, excluding aliases
+func (m *Mux) GuildSimpleNames(guildID string) []string {
+	m.simpleMu.RLock()
+	defer m.simpleMu.RUnlock()
+
+	var names []string
+	for k, c := range m.simpleTable(guildID, false) {
+		if k == c.Command {
+			names = append(names, k)
 		}
 	}
+
+	sort.Strings(names)
+	return names
+}
+
+// resolveSimple finds the simple command a message in the guild refers to,
+// preferring guild scoped commands over global ones
+func (m *Mux) resolveSimple(guildID, name string) (SimpleCommand, bool) {
+	if guildID != "" {
+		if c, ok := m.GuildSimple(guildID, name); ok {
+			return c, true
+		}
+	}
+	return m.Simple(name)
+}
+
+// simpleTable returns the simple commands of the guild, or the global ones for
+// an empty guild ID. Must be called with simpleMu held.
+func (m *Mux) simpleTable(
+	guildID string,
+	create bool,
+) map[string]SimpleCommand {
+	if guildID == "" {
+		return m.SimpleCommands
+	}
+
+	table, ok := m.GuildSimpleCommands[guildID]
+	if !ok && create {
+		table = make(map[string]SimpleCommand)
+		m.GuildSimpleCommands[guildID] = table
+	}
+	return table
+}
+
+// DisableCommand disables one or more commands in the specified guild. Disabled
+// commands are treated as if they were not registered, and are not suggested.
+func (m *Mux) DisableCommand(guildID string, commands ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.disabled[guildID] == nil {
+		m.disabled[guildID] = make(map[string]bool)
+	}
+
+	for _, c := range commands {
+		m.disabled[guildID][c] = true
+	}
+}
+
+// EnableCommand re-enables one or more commands previously disabled in the
+// specified guild
+func (m *Mux) EnableCommand(guildID string, commands ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range commands {
+		delete(m.disabled[guildID], c)
+	}
+}
+
+// CommandEnabled reports whether the command is enabled in the specified
+// guild, by DisableCommand() or the configuration of the guild
+func (m *Mux) CommandEnabled(guildID, command string) bool {
+	if m.guildConfig(guildID).disables(command) {
+		return false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.commandEnabled(guildID, command)
+}
+
+// commandEnabled reports whether the command was not disabled in the guild with
+// DisableCommand(). Must be called with mu held.
+func (m *Mux) commandEnabled(guildID, command string) bool {
+	return !m.disabled[guildID][command]
+}
+
+// SuppressNotFound silences the command not found reply (including fuzzy
+// suggestions) in the specified channels or guilds. Channel and guild IDs may be
+// mixed freely.
+func (m *Mux) SuppressNotFound(ids ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		m.suppressed[id] = true
+	}
+}
+
+// UnsuppressNotFound restores the command not found reply in the specified
+// channels or guilds
+func (m *Mux) UnsuppressNotFound(ids ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		delete(m.suppressed, id)
+	}
 }
 
 // InitializeFuzzy both enables and builds a list of commands to fuzzy match
 // against. This _will_ mean taking a performance hit, so use with caution.
 func (m *Mux) InitializeFuzzy() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.fuzzyMatch = true
 
+	/* A fresh slice, as suggest() may still be reading the old one */
+	names := make([]string, 0, len(m.Commands))
 	for k := range m.Commands {
-		m.commandNames = append(m.commandNames, k)
+		names = append(names, k)
 	}
+	m.commandNames = names
+}
+
+// SetMatcher sets the matcher used to build suggestions when fuzzy matching is
+// enabled. Defaults to SubsequenceMatcher.
+func (m *Mux) SetMatcher(matcher Matcher) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.matcher = matcher
 }
 
 // Initialize calls the init functions of all registered commands to do any
-// preloading or setup before commands are to be handled. Must be called before
-// Mux.Handle() and after Mux.Register()
-func (m *Mux) Initialize(commands ...Command) {
-	/* If no commands are loaded, and none are specified, return */
-	if len(commands) == 0 && len(m.Commands) == 0 {
-		return
+// preloading or setup before commands are to be handled, and caches the files
+// of simple commands. Must be called before Mux.Handle() and after
+// Mux.Register(). Commands are then checked with Lint(), and a *LintError
+// listing any problems, including files which could not be loaded, is
+// returned; the commands are initialized regardless.
+func (m *Mux) Initialize(commands ...Command) error {
+	/* Files of simple commands are loaded up front, reporting those which
+	can't be */
+	var problems []LintProblem
+	load := func(table map[string]SimpleCommand) {
+		for name, c := range table {
+			if name != c.Command || c.File == nil || c.state == nil {
+				continue
+			}
+			if _, err := c.state.loadFile(c.File); err != nil {
+				problems = append(problems, LintProblem{
+					Command: c.Command,
+					Problem: fmt.Sprintf("file not loaded: %v", err),
+				})
+			}
+		}
 	}
 
+	m.simpleMu.RLock()
+	load(m.SimpleCommands)
+	for _, table := range m.GuildSimpleCommands {
+		load(table)
+	}
+	m.simpleMu.RUnlock()
+
 	/* If no commands are specified, init the loaded ones */
 	if len(commands) == 0 {
+		m.mu.RLock()
 		for _, c := range m.Commands {
-			c.Init(m)
+			commands = append(commands, c)
 		}
-		return
+		m.mu.RUnlock()
 	}
 
 	/* Init the specified commands */
 	for _, c := range commands {
 		c.Init(m)
 	}
+
+	err := m.Lint()
+	if len(problems) == 0 {
+		return err
+	}
+	if lint, ok := err.(*LintError); ok {
+		problems = append(lint.Problems, problems...)
+	}
+	return &LintError{Problems: problems}
 }
 
 // Handle is passed to DiscordGo to handle actions
@@ -172,145 +956,358 @@ func (m *Mux) Handle(
 	session *discordgo.Session,
 	message *discordgo.MessageCreate,
 ) {
+	m.countMessage(session)
+
+	/* Ignore malformed events, which can't be routed */
+	if message.Message == nil || message.Author == nil {
+		return
+	}
+
 	/* Ignore if the message being handled originated from the bot */
 	if message.Author.ID == session.State.User.ID {
 		return
 	}
 
-	/* Ignore if the message has no content */
-	if m.options.IgnoreEmpty && len(message.Content) == 0 {
+	/* Ignore if the message was already handled, e.g. replayed on reconnect */
+	if m.dedup.duplicate(message.ID, m.clock().Now()) {
 		return
 	}
 
-	/* Ignore if the message is not default */
-	if m.options.IgnoreNonDefault && message.Type != discordgo.MessageTypeDefault {
+	/* Hand the message to a pending prompt instead, if one is waiting for it */
+	if m.events.deliver(message) {
 		return
 	}
 
-	/* Ignore if the message originated from a bot */
-	if m.options.IgnoreBots && message.Author.Bot {
+	/* Ignore if the mux is shutting down */
+	if m.lifecycle.isClosed() {
 		return
 	}
 
-	/* Ignore if the message is in a DM */
-	if m.options.IgnoreDMs && message.GuildID == "" {
+	opts := m.opts()
+
+	/* Ignore if the message has no content */
+	if opts.IgnoreEmpty && len(message.Content) == 0 {
 		return
 	}
 
-	/* Ignore if the message doesn't have the prefix */
-	if !strings.HasPrefix(message.Content, m.Prefix) {
+	/* Ignore if the message is not default */
+	if opts.IgnoreNonDefault && message.Type != discordgo.MessageTypeDefault {
 		return
 	}
 
-	/* Split the message on the space */
-	args := strings.Split(message.Content, " ")
-	command := strings.ToLower(args[0][1:])
-
-	simple, ok := m.SimpleCommands[command]
-	if ok {
-		session.ChannelMessageSend(message.ChannelID, simple.Content)
+	/* Ignore if the message originated from a bot */
+	if opts.IgnoreBots && message.Author.Bot {
 		return
 	}
 
-	handler, ok := m.Commands[command]
-	if !ok {
-		if m.fuzzyMatch {
-			var sb strings.Builder
-
-			for _, fzy := range fuzzy.Find(command, m.commandNames) {
-				sb.WriteString("- `!" + fzy.Str + "`\n")
-			}
+	/* Ignore if the message is in a DM, unless DMs are routed separately */
+	dms := m.dmRules()
+	if dms == nil && opts.IgnoreDMs && message.GuildID == "" {
+		return
+	}
 
-			if sb.Len() != 0 {
-				session.ChannelMessageSend(
-					message.ChannelID,
-					fmt.Sprintf(
-						"Command not found. Did you mean: \n%s", sb.String(),
-					),
-				)
+	/* Threads are ignored, or else handled as part of their parent channel */
+	config := m.guildConfig(message.GuildID)
+	var parentID string
+	if message.GuildID != "" &&
+		(opts.IgnoreThreads || len(config.IgnoredChannels) != 0) {
+		channel, err := lookupChannel(
+			session.State, m.api(session), message.ChannelID,
+		)
+		if err == nil && channel.IsThread() {
+			if opts.IgnoreThreads {
 				return
 			}
-
+			parentID = channel.ParentID
 		}
+	}
 
-		session.ChannelMessageSend(
-			message.ChannelID,
-			m.errorTexts.CommandNotFound,
-		)
+	/* Ignore if the guild ignores the channel or author */
+	if config.ignores(message.ChannelID, message.Author.ID, message.Member) ||
+		(parentID != "" && arrayContains(config.IgnoredChannels, parentID)) {
+		return
+	}
+
+	/* Guilds may have a prefix of their own */
+	prefix := m.prefix()
+	if config.Prefix != "" {
+		prefix = config.Prefix
+	}
 
+	/* Ignore if the message doesn't have the prefix, which DMs may omit */
+	content := message.Content
+	unprefixed := !strings.HasPrefix(content, prefix)
+	switch {
+	case !unprefixed:
+		content = content[len(prefix):]
+	case message.GuildID == "" && dms != nil && dms.noPrefix:
+		prefix = ""
+	default:
 		return
 	}
 
+	/* Chained commands run one after another, each once the previous is done */
+	segments, chain := m.chainSegments(content, prefix)
+	var next func(i int)
+	next = func(i int) {
+		m.handleSegment(
+			session, message, config, opts, prefix, segments[i], unprefixed,
+			chain.at(i), func(err error) {
+				if err == nil && i+1 < len(segments) {
+					next(i + 1)
+				}
+			},
+		)
+	}
+	next(0)
+}
+
+// handleSegment routes a command of the message, content being the command
+// and its arguments without the prefix. done is called once the invocation is
+// over, with its error.
+func (m *Mux) handleSegment(
+	session *discordgo.Session,
+	message *discordgo.MessageCreate,
+	config GuildConfig,
+	opts *Options,
+	prefix, content string,
+	unprefixed bool,
+	chain *Chain,
+	done func(err error),
+) {
+	/* Slice out the command, leaving the arguments until a command matches */
+	command, rest := splitCommand(content)
+	command = strings.ToLower(command)
+	dms := m.dmRules()
+
+	check := newPermissionCheck(session, m.api(session), message)
 	ctx := &Context{
-		Prefix:    m.Prefix,
-		Command:   command,
-		Arguments: args[1:],
-		Session:   session,
-		Message:   message,
+		Prefix:  prefix,
+		Command: command,
+		Session: session,
+		Message: message,
+		Invocation: &messageInvocation{
+			session: m.api(session),
+			message: message,
+		},
+		mux:        m,
+		id:         newInvocationID(),
+		perms:      check,
+		config:     config,
+		invocation: m.lifecycle.context(),
+		Chain:      chain,
 	}
 
-	/* Call middlewares */
-	if len(m.Middleware) > 0 {
-		for _, mw := range m.Middleware {
-			go mw(ctx)
-		}
+	invocationCtx, span := m.startSpan(ctx, spanInvocation)
+	ctx.invocation = invocationCtx
+
+	if chain != nil && len(chain.Commands) > m.chainLimit() {
+		defer span.End(ErrChainTooLong)
+		m.reject(ctx, ErrChainTooLong, m.chainTooLongText(ctx), 0)
+		done(ErrChainTooLong)
+		return
 	}
 
-	p := handler.Permissions()
-	if len(p.RoleIDs) != 0 {
-		member, err := session.GuildMember(message.GuildID, message.Author.ID)
+	/* Macros of the guild stand for another command with arguments filled in */
+	if expansion, ok := m.macro(config, command); ok {
+		ctx.Macro = command
+		expanded, err := expandMacro(expansion, splitArguments(rest))
 		if err != nil {
-			session.ChannelMessageSend(
-				message.ChannelID,
-				"There was a weird issue. Maybe report it on Github?",
-			)
+			defer span.End(err)
+			ctx.spec = macroSpec(expansion)
+			m.reject(ctx, err, m.errorText(ctx, MessageInvalidArguments), 0)
+			done(err)
 			return
 		}
 
-		/* Check if user explicitly has permission */
-		if arrayContains(p.UserIDs, member.User.ID) {
-			go handler.Handle(ctx)
+		command, rest = splitCommand(expanded)
+		command = strings.ToLower(command)
+		ctx.Command = command
+	}
+
+	simple, ok := m.resolveSimple(message.GuildID, command)
+	if ok && dms.allows(message.GuildID, strings.ToLower(simple.Command)) {
+		defer span.End(nil)
+		m.logCtx(ctx, LogDebug, "Handling simple command")
+		ctx.Arguments = splitArguments(rest)
+		done(m.handleSimple(ctx, simple))
+		return
+	}
+
+	m.mu.RLock()
+	handler, name, ok := m.lookup(command)
+	ok = ok && m.commandEnabled(message.GuildID, name) && !config.disables(name)
+	ok = ok && dms.allows(message.GuildID, name)
+	suppressed := m.suppressed[message.ChannelID] || m.suppressed[message.GuildID]
+	middleware := m.middleware()
+	m.mu.RUnlock()
+
+	if !ok {
+		defer span.End(ErrNotFound)
+		m.countNotFound(session)
+		m.logCtx(ctx, LogDebug, "Command not found")
+
+		defer done(ErrNotFound)
+
+		/* Stay quiet if not found replies are disabled or suppressed here, or
+		the message is unprefixed chat in a DM */
+		if opts.IgnoreUnknown || suppressed || unprefixed {
 			return
 		}
 
-		/* Check if one of the user's roles has permission */
-		for _, r := range member.Roles {
-			if arrayContains(p.RoleIDs, r) {
-				go handler.Handle(ctx)
-				return
-			}
+		ctx.Arguments = splitArguments(rest)
+		notFound, onReject := m.notFoundHandler(), m.rejectHook()
+		switch {
+		case notFound != nil:
+			notFound(ctx)
+		case onReject != nil:
+			onReject(ctx, ErrNotFound)
+		default:
+			m.defaultNotFound(ctx)
 		}
+		return
+	}
 
-		/* Check if the channel has permission */
-		if arrayContains(p.ChanIDs, message.ChannelID) {
-			go handler.Handle(ctx)
-			return
+	/* Aliases are handled as the command they stand for */
+	ctx.Command = name
+	ctx.Arguments = splitArguments(rest)
+	handler = resolveSubcommand(ctx, handler)
+
+	ctx.OnDone(done)
+	m.route(ctx, handler, middleware, opts, span)
+}
+
+// route calls the middlewares for an invocation of a registered command, then
+// dispatches it if it is admitted
+func (m *Mux) route(
+	ctx *Context,
+	handler Command,
+	middleware []Middleware,
+	opts *Options,
+	span Span,
+) {
+	ctx.spec = handler.Settings().Arguments
+
+	/* The invocation is over once its span ends, whichever way it ends */
+	span = &doneSpan{Span: span, ctx: ctx}
+
+	/* Call middlewares */
+	if len(middleware) > 0 {
+		for _, mw := range middleware {
+			if opts.Synchronous {
+				m.runMiddleware(ctx, mw)
+				continue
+			}
+			go m.runMiddleware(ctx, mw)
 		}
+	}
 
-		/* Clearly the user doesn't have the correct permissions */
-		session.ChannelMessageSend(
-			message.ChannelID, m.errorTexts.NoPermissions,
-		)
+	settings := handler.Settings()
+
+	/* Cached responses don't run the handler, so they don't use the quota */
+	quota := settings.Quota
+	if m.cacheHit(ctx, settings.Cache) {
+		quota = nil
+	}
+
+	err := m.admit(ctx, ctx.Command, admission{
+		permissions: handler.Permissions(),
+		cooldown:    settings.Cooldown,
+		inhibitors:  settings.Inhibitors,
+		quota:       quota,
+		ownerOnly:   settings.OwnerOnly,
+
+		/* Slash command options are validated by Discord */
+		arguments: ctx.Interaction == nil,
+	})
+	if err != nil {
+		span.End(err)
 		return
 	}
-	go handler.Handle(ctx)
+
+	m.dispatch(ctx, handler, span)
 }
 
-// ChannelSend is a helper function for easily sending a message to the current
-// channel.
-func (ctx *Context) ChannelSend(message string) (*discordgo.Message, error) {
-	return ctx.Session.ChannelMessageSend(ctx.Message.ChannelID, message)
+// runMiddleware calls a middleware within its own span. Panics are recovered
+// and reported as for handlers.
+func (m *Mux) runMiddleware(ctx *Context, mw Middleware) {
+	_, span := m.startSpan(ctx, spanMiddleware)
+
+	var err error
+	defer func() { span.End(err) }()
+	defer m.recoverPanic(ctx, &err)
+
+	mw(ctx)
 }
 
-// ChannelSendf is a helper function like ChannelSend for sending a formatted
-// message to the current channel.
-func (ctx *Context) ChannelSendf(
-	format string,
-	a ...interface{},
-) (*discordgo.Message, error) {
-	return ctx.Session.ChannelMessageSend(
-		ctx.Message.ChannelID, fmt.Sprintf(format, a...),
-	)
+// splitCommand cuts the command token off the content following the prefix, at
+// the first whitespace, e.g. a space or a line break
+func splitCommand(content string) (command, rest string) {
+	if i := strings.IndexFunc(content, unicode.IsSpace); i >= 0 {
+		_, size := utf8.DecodeRuneInString(content[i:])
+		return content[:i], content[i+size:]
+	}
+	return content, ""
+}
+
+// splitArguments splits the content following the command on spaces. The
+// content is empty when the command has no arguments.
+func splitArguments(rest string) []string {
+	if rest == "" {
+		return []string{}
+	}
+	return strings.Split(rest, " ")
+}
+
+// defaultNotFound replies that the command was not found, listing suggestions
+// if fuzzy matching is enabled
+func (m *Mux) defaultNotFound(ctx *Context) {
+	var sb strings.Builder
+	for _, match := range ctx.Suggestions() {
+		sb.WriteString("- `" + ctx.Prefix + match + "`\n")
+	}
+
+	if sb.Len() != 0 {
+		header := m.localize(
+			ctx, MessageSuggestions, "Command not found. Did you mean: ",
+		)
+		m.replyError(ctx, header+"\n"+sb.String(), 0)
+		return
+	}
+
+	m.replyError(ctx, m.errorText(ctx, MessageCommandNotFound), 0)
+}
+
+// suggest returns the fuzzy matches for command, excluding commands which are
+// disabled in the guild or which the author has no permission to use
+func (m *Mux) suggest(
+	command, guildID string,
+	check *permissionCheck,
+) []string {
+	var suggestions []string
+
+	m.mu.RLock()
+	matcher, names := m.matcher, m.commandNames
+	m.mu.RUnlock()
+
+	config := m.guildConfig(guildID)
+	for _, match := range matcher(command, names) {
+		m.mu.RLock()
+		c, ok := m.Commands[match]
+		ok = ok && m.commandEnabled(guildID, match) && !config.disables(match)
+		m.mu.RUnlock()
+		if !ok {
+			continue
+		}
+
+		if allowed, err := check.allowed(c.Permissions()); err != nil || !allowed {
+			continue
+		}
+
+		suggestions = append(suggestions, match)
+	}
+
+	return suggestions
 }
 
 func arrayContains(array []string, value string) bool {
@@ -0,0 +1,177 @@
+package disgomux_test
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CS-5/disgomux"
+	"github.com/CS-5/disgomux/muxtest"
+	"github.com/bwmarrin/discordgo"
+)
+
+// noop is a command whose handler does nothing, so benchmarks measure the mux
+type noop struct{ name string }
+
+func (c *noop) Init(m *disgomux.Mux)                      {}
+func (c *noop) Handle(ctx *disgomux.Context)              {}
+func (c *noop) HandleHelp(ctx *disgomux.Context) bool     { return false }
+func (c *noop) Permissions() *disgomux.CommandPermissions { return nil }
+func (c *noop) Settings() *disgomux.CommandSettings {
+	return &disgomux.CommandSettings{Command: c.name, HelpText: "Does nothing"}
+}
+
+// benchMessage returns a message event from the harness user
+func benchMessage(h *muxtest.Harness, content string) *discordgo.MessageCreate {
+	return &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        "500",
+		ChannelID: muxtest.ChannelID,
+		GuildID:   muxtest.GuildID,
+		Author:    h.User,
+		Content:   content,
+	}}
+}
+
+func benchHarness(b *testing.B) *muxtest.Harness {
+	m, err := disgomux.New("!")
+	if err != nil {
+		b.Fatal(err)
+	}
+	m.Register(&noop{name: "ping"}, &noop{name: "echo"})
+	if err := m.Initialize(); err != nil {
+		b.Fatal(err)
+	}
+	return muxtest.New(m)
+}
+
+func BenchmarkHandleNonCommand(b *testing.B) {
+	h := benchHarness(b)
+	event := benchMessage(h, "just chatting, nothing to see here")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Mux.Handle(h.Session, event)
+	}
+}
+
+func BenchmarkHandleCommand(b *testing.B) {
+	h := benchHarness(b)
+	event := benchMessage(h, "!echo some arguments here")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Mux.Handle(h.Session, event)
+	}
+}
+
+func TestUseWorkerPoolReplacesWorkers(t *testing.T) {
+	m, err := disgomux.New("!")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := runtime.NumGoroutine()
+	m.UseWorkerPool(20, 0)
+	m.UseWorkerPool(1, 0)
+
+	/* The first pool's workers exit in their own time */
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before+1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine() - before; n > 1 {
+		t.Errorf("%d goroutines left running, want the one worker", n)
+	}
+}
+
+// counter is a command counting its invocations
+type counter struct {
+	noop
+	calls int64
+}
+
+func (c *counter) Handle(ctx *disgomux.Context) { atomic.AddInt64(&c.calls, 1) }
+
+func TestUseWorkerPoolWhileHandling(t *testing.T) {
+	m, err := disgomux.New("!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &counter{noop: noop{name: "count"}}
+	m.Register(c)
+	if err := m.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	h := muxtest.New(m)
+	m.SetSynchronous(false)
+	m.SetOverflowPolicy(disgomux.OverflowBlock)
+	m.UseWorkerPool(2, 1)
+
+	const invocations = 200
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < invocations; i++ {
+			m.Handle(h.Session, benchMessage(h, "!count"))
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		m.UseWorkerPool(2, 1)
+		m.QueueStats()
+	}
+	<-done
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt64(&c.calls); n != invocations {
+		t.Errorf("ran %d invocations, want %d", n, invocations)
+	}
+}
+
+// blocker is a command whose handler waits for release to be closed
+type blocker struct {
+	noop
+	release chan struct{}
+}
+
+func (c *blocker) Handle(ctx *disgomux.Context) { <-c.release }
+
+func TestShutdownTimeoutStopsWorkers(t *testing.T) {
+	m, err := disgomux.New("!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &blocker{noop: noop{name: "block"}, release: make(chan struct{})}
+	m.Register(c)
+	if err := m.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	h := muxtest.New(m)
+	m.SetSynchronous(false)
+
+	before := runtime.NumGoroutine()
+	m.UseWorkerPool(4, 1)
+	m.Handle(h.Session, benchMessage(h, "!block"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Shutdown() returned %v while the handler still runs", err)
+	}
+	close(c.release)
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine() - before; n > 0 {
+		t.Errorf("%d goroutines left running after Shutdown() timed out", n)
+	}
+}
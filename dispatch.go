@@ -0,0 +1,328 @@
+package disgomux
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// dispatch schedules the handler of an invocation to run, either right away
+// when synchronous, or on the worker pool if one is in use. With
+// SerializeChannels, invocations in the same channel run one after another.
+func (m *Mux) dispatch(ctx *Context, handler Command, span Span) {
+	if !m.lifecycle.acquire() {
+		span.End(nil)
+		return
+	}
+
+	m.countDispatched(ctx.Session)
+	m.observeDispatched(ctx)
+	m.logCtx(ctx, LogDebug, "Dispatching command")
+
+	job := task{
+		run: func() {
+			defer m.lifecycle.release()
+			span.End(m.run(ctx, handler))
+		},
+		drop: func() {
+			defer m.lifecycle.release()
+			defer span.End(nil)
+			m.logCtx(ctx, LogWarn, "Dropped invocation, worker queue is full")
+			if m.overflowPolicy() == OverflowReject {
+				m.replyError(ctx, m.errorText(ctx, MessageBusy), 0)
+			}
+		},
+	}
+
+	opts := m.opts()
+	settings := handler.Settings()
+	if opts.Synchronous || settings.Synchronous {
+		job.run()
+		return
+	}
+
+	/* Priority invocations skip the queues, so they run even when backlogged */
+	if settings.Priority {
+		m.startPriority(job)
+		return
+	}
+
+	if opts.SerializeChannels {
+		m.channelQueues.enqueue(ctx.Message.ChannelID, job, m.start)
+		return
+	}
+
+	m.start(job)
+}
+
+// start launches t on the worker pool, or on a new goroutine without one
+func (m *Mux) start(t task) {
+	for {
+		p := m.workers()
+		if p == nil {
+			go t.run()
+			return
+		}
+
+		if p.submit(t, m.overflowPolicy()) {
+			m.observeQueue()
+			return
+		}
+
+		/*
+			A replaced pool is retried on its replacement; a pool stopped
+			but still in use means the mux is shutting down
+		*/
+		if m.workers() == p {
+			t.drop()
+			return
+		}
+	}
+}
+
+// startPriority launches t on a new goroutine, bypassing the worker pool
+func (m *Mux) startPriority(t task) {
+	if p := m.workers(); p != nil {
+		atomic.AddUint64(&p.prioritized, 1)
+	}
+	go t.run()
+}
+
+// run executes the handler of an invocation, returning the error it produced
+func (m *Mux) run(ctx *Context, handler Command) error {
+	m.countActive(ctx.Session, 1)
+	defer m.countActive(ctx.Session, -1)
+	defer ctx.stopTyping()
+
+	settings := handler.Settings()
+	if settings.DeleteInvocation && ctx.Interaction == nil {
+		go ctx.DeleteInvocation()
+	}
+	if settings.Typing {
+		ctx.Typing()
+	}
+	if settings.Defer && ctx.Interaction != nil {
+		ctx.Defer()
+	}
+	if settings.ReplyInThread && ctx.Interaction == nil {
+		name := settings.ThreadName
+		if name == "" {
+			name = settings.Command
+		}
+		ctx.ReplyInThread(name)
+	}
+	if channelID := m.outputChannelFor(ctx, settings); channelID != "" {
+		ctx.RedirectOutput(channelID)
+	}
+	if settings.Crosspost {
+		ctx.CrosspostReplies()
+	}
+
+	start := time.Now()
+	var err error
+	if !m.serveCached(ctx, settings.Cache) {
+		handlerCtx, span := m.startSpan(ctx, spanHandler)
+		ctx.setContext(handlerCtx)
+
+		err = m.invoke(ctx, handler, settings.Timeout)
+		span.End(err)
+		m.storeCached(ctx, err)
+	}
+
+	latency := time.Since(start)
+	m.recordStats(ctx, latency, err)
+	m.recordHistory(ctx, err)
+	m.observeCompleted(ctx, latency, err)
+	if err != nil {
+		fields := ctx.fields()
+		fields["error"] = err.Error()
+		m.log(LogError, "Command failed", fields)
+		return err
+	}
+
+	m.audit(ctx, handler)
+	return nil
+}
+
+// invoke runs the handler within the timeout, if any, and returns the error it
+// produced
+func (m *Mux) invoke(
+	ctx *Context,
+	handler Command,
+	timeout time.Duration,
+) error {
+	if timeout <= 0 {
+		invocation, cancel := context.WithCancel(ctx.Context())
+		defer cancel()
+		ctx.setContext(invocation)
+
+		return m.execute(ctx, handler)
+	}
+
+	invocation, cancel := context.WithTimeout(ctx.Context(), timeout)
+	defer cancel()
+	ctx.setContext(invocation)
+
+	/*
+		Run the handler on its own goroutine so the invocation can be given up
+		on. The handler keeps running until it notices the cancelled context,
+		and shutdown waits for it as it does for the invocation.
+	*/
+	done := make(chan error, 1)
+	m.lifecycle.extend()
+	go func() {
+		defer m.lifecycle.release()
+		done <- m.execute(ctx, handler)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-invocation.Done():
+		if invocation.Err() == context.DeadlineExceeded {
+			m.handleError(ctx, ErrCommandTimeout)
+			return ErrCommandTimeout
+		}
+		return invocation.Err()
+	}
+}
+
+// execute calls the handler, recovering from panics and handling the error
+// returned by an ErrorCommand. It returns the error or the recovered panic.
+func (m *Mux) execute(ctx *Context, handler Command) (err error) {
+	defer m.recoverPanic(ctx, &err)
+
+	if ec, ok := handler.(ErrorCommand); ok {
+		err = ec.HandleErr(ctx)
+		if err != nil {
+			m.handleError(ctx, err)
+		}
+		return err
+	}
+
+	handler.Handle(ctx)
+	return nil
+}
+
+// handleError reports an error returned by a handler to the error reporter and
+// through the OnError hook, or to the user if there is no hook
+func (m *Mux) handleError(ctx *Context, err error) {
+	m.reportCtx(ctx, err)
+
+	if onError := m.errorHook(); onError != nil {
+		onError(ctx, err)
+		return
+	}
+
+	m.replyError(ctx, m.handlerErrorText(ctx), 0)
+}
+
+// admission holds what decides whether an invocation of a command may run
+type admission struct {
+	permissions *CommandPermissions
+	cooldown    *Cooldown
+	inhibitors  []Inhibitor
+	quota       *Quota
+	ownerOnly   bool
+
+	// arguments validates the arguments against the spec of the command
+	arguments bool
+}
+
+// admit runs the maintenance, permission, inhibitor, argument, cooldown and
+// quota checks for an invocation of the named command, replying with the
+// relevant error text if it is refused. The error it was refused with is
+// returned.
+func (m *Mux) admit(ctx *Context, name string, a admission) error {
+	if notice, on := m.maintenanceNotice(ctx); on && !a.ownerOnly {
+		m.logCtx(ctx, LogInfo, "Rejected during maintenance")
+		m.reject(ctx, ErrMaintenance, notice, 0)
+		return ErrMaintenance
+	}
+
+	permissions := a.permissions
+	check := ctx.perms
+	if check == nil {
+		check = newPermissionCheck(ctx.Session, ctx.API(), ctx.Message)
+	}
+
+	/* Owners may run everything, and they alone owner-only commands */
+	owner := m.isOwner(ctx.Message.Author.ID)
+	if owner {
+		permissions = nil
+	}
+
+	_, span := m.startSpan(ctx, spanPermissions)
+	allowed, err := check.allowed(permissions)
+	span.End(err)
+	if a.ownerOnly && !owner {
+		allowed = false
+	}
+	if err != nil {
+		m.logCtx(ctx, LogError, "Failed to check permissions")
+		m.reportCtx(ctx, err)
+		m.replyError(
+			ctx, "There was a weird issue. Maybe report it on Github?", 0,
+		)
+		return err
+	}
+
+	/* Clearly the user doesn't have the correct permissions */
+	if !allowed {
+		m.observeDenied(ctx, name)
+		m.logCtx(ctx, LogInfo, "Permission denied")
+		m.reject(
+			ctx, ErrNoPermission, m.errorText(ctx, MessageNoPermissions), 0,
+		)
+		return ErrNoPermission
+	}
+
+	if err := m.inhibit(ctx, a.inhibitors); err != nil {
+		m.logCtx(ctx, LogInfo, "Invocation inhibited: "+err.Error())
+		m.reject(ctx, err, err.Reason, 0)
+		return err
+	}
+
+	/*
+		Arguments are checked before a use is taken off the cooldown or
+		quota, so mistyped invocations can be retried right away
+	*/
+	if a.arguments {
+		if err := ctx.validateArguments(); err != nil {
+			m.logCtx(ctx, LogDebug, "Invalid arguments: "+err.Error())
+			m.reject(
+				ctx, err, m.errorText(ctx, MessageInvalidArguments), 0,
+			)
+			return err
+		}
+	}
+
+	if wait := m.onCooldown(name, a.cooldown, ctx.Message); wait > 0 {
+		m.logCtx(ctx, LogInfo, "Command on cooldown")
+		err := &CooldownError{Command: name, Remaining: wait}
+		m.reject(ctx, err, m.errorText(ctx, MessageCooldown), wait)
+		return err
+	}
+
+	if reset, over := m.overQuota(name, a.quota, ctx.Message); over {
+		m.logCtx(ctx, LogInfo, "Command quota exceeded")
+		err := &QuotaError{Command: name, Limit: a.quota.Limit, Reset: reset}
+		m.reject(
+			ctx, err, m.errorText(ctx, MessageQuotaExceeded),
+			reset.Sub(m.clock().Now()),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// reject hands a rejected invocation to the OnReject hook, or else replies
+// with the text
+func (m *Mux) reject(ctx *Context, err error, text string, wait time.Duration) {
+	if onReject := m.rejectHook(); onReject != nil {
+		onReject(ctx, err)
+		return
+	}
+	m.replyError(ctx, text, wait)
+}
@@ -0,0 +1,76 @@
+package disgomux
+
+import (
+	"io"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DMSend sends a direct message to the author of the invoking message
+func (ctx *Context) DMSend(content string) (*discordgo.Message, error) {
+	return ctx.dmSend(&discordgo.MessageSend{Content: content})
+}
+
+// DMSendEmbed sends an embed as a direct message to the author of the invoking
+// message
+func (ctx *Context) DMSendEmbed(
+	embed *discordgo.MessageEmbed,
+) (*discordgo.Message, error) {
+	return ctx.dmSend(&discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	})
+}
+
+// DMSendFile sends a file, with optional content, as a direct message to the
+// author of the invoking message
+func (ctx *Context) DMSendFile(
+	name string,
+	r io.Reader,
+	content string,
+) (*discordgo.Message, error) {
+	return ctx.dmSend(&discordgo.MessageSend{
+		Content: content,
+		Files:   []*discordgo.File{{Name: name, Reader: r}},
+	})
+}
+
+func (ctx *Context) dmSend(
+	ms *discordgo.MessageSend,
+) (*discordgo.Message, error) {
+	channelID, err := ctx.dmChannel()
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := ctx.send(channelID, ms)
+	if err != nil && dmsClosed(err) {
+		if hook := ctx.mux.dmFailureHook(); hook != nil {
+			hook(ctx, err)
+		}
+	}
+	return msg, err
+}
+
+// dmChannel returns the ID of the DM channel with the author, creating it if
+// there is none cached yet
+func (ctx *Context) dmChannel() (string, error) {
+	userID := ctx.Message.Author.ID
+	if id, ok := ctx.mux.dmChannels.Load(userID); ok {
+		return id.(string), nil
+	}
+
+	channel, err := ctx.API().UserChannelCreate(userID)
+	if err != nil {
+		return "", err
+	}
+
+	ctx.mux.dmChannels.Store(userID, channel.ID)
+	return channel.ID, nil
+}
+
+// dmsClosed reports whether err was caused by the user not accepting DMs
+func dmsClosed(err error) bool {
+	restErr, ok := err.(*discordgo.RESTError)
+	return ok && restErr.Message != nil &&
+		restErr.Message.Code == discordgo.ErrCodeCannotSendMessagesToThisUser
+}
@@ -0,0 +1,103 @@
+package disgomux
+
+import "strings"
+
+type (
+	// DMRouting configures how direct messages are routed, which differs
+	// from guild channels: there is no one else to address, so the prefix is
+	// often redundant, and only some commands make sense privately
+	DMRouting struct {
+		// NoPrefix lets commands be invoked in DMs without the prefix. Messages
+		// with the prefix still work. Unprefixed messages which don't match a
+		// command are ignored, so users can chat with the bot.
+		NoPrefix bool
+
+		// Commands limits the commands and simple commands usable in DMs to
+		// these names. Others are not found. Empty allows every command.
+		Commands []string
+
+		// ErrorTexts replace the error texts of the mux in DMs, field by
+		// field. Empty fields keep the text set with SetErrors().
+		ErrorTexts ErrorTexts
+	}
+
+	// dmRouting is a DMRouting prepared for lookups
+	dmRouting struct {
+		noPrefix   bool
+		commands   map[string]bool
+		errorTexts ErrorTexts
+	}
+)
+
+// SetDMRouting handles direct messages with the routing, regardless of the
+// IgnoreDMs option. Slash commands invoked in DMs are limited to the same
+// commands. nil restores the default handling, following IgnoreDMs.
+func (m *Mux) SetDMRouting(routing *DMRouting) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.dmRouting = newDMRouting(routing)
+}
+
+// WithDMRouting handles direct messages with the routing, as SetDMRouting()
+func WithDMRouting(routing DMRouting) Option {
+	return func(m *Mux) error {
+		m.dmRouting = newDMRouting(&routing)
+		return nil
+	}
+}
+
+// newDMRouting prepares the routing, nil for none
+func newDMRouting(routing *DMRouting) *dmRouting {
+	if routing == nil {
+		return nil
+	}
+
+	r := &dmRouting{
+		noPrefix:   routing.NoPrefix,
+		errorTexts: routing.ErrorTexts,
+	}
+	if len(routing.Commands) > 0 {
+		r.commands = make(map[string]bool, len(routing.Commands))
+		for _, name := range routing.Commands {
+			r.commands[strings.ToLower(name)] = true
+		}
+	}
+	return r
+}
+
+// dmRules returns the DM routing of the mux, nil if there is none
+func (m *Mux) dmRules() *dmRouting {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.dmRouting
+}
+
+// allows reports whether the command may be invoked in the channel of the
+// guild. Outside DMs, and without routing, every command is allowed.
+func (r *dmRouting) allows(guildID, command string) bool {
+	if r == nil || guildID != "" || r.commands == nil {
+		return true
+	}
+	return r.commands[command]
+}
+
+// texts returns the error texts for DMs, the overrides of the routing over the
+// texts of the mux
+func (r *dmRouting) texts(texts ErrorTexts) ErrorTexts {
+	for _, t := range []struct{ text, override *string }{
+		{&texts.CommandNotFound, &r.errorTexts.CommandNotFound},
+		{&texts.NoPermissions, &r.errorTexts.NoPermissions},
+		{&texts.Cooldown, &r.errorTexts.Cooldown},
+		{&texts.HandlerError, &r.errorTexts.HandlerError},
+		{&texts.Busy, &r.errorTexts.Busy},
+		{&texts.InvalidArguments, &r.errorTexts.InvalidArguments},
+		{&texts.QuotaExceeded, &r.errorTexts.QuotaExceeded},
+	} {
+		if *t.override != "" {
+			*t.text = *t.override
+		}
+	}
+	return texts
+}
@@ -0,0 +1,344 @@
+package disgomux
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// dryRunSession sends reads through the gateway session, and records and logs
+// every other request instead of sending it
+type dryRunSession struct {
+	Session
+	mux      *Mux
+	recorder *MockSession
+}
+
+// SetDryRun sets whether the mux runs dry: messages, edits, deletions,
+// reactions, role changes, kicks, interaction responses and slash command
+// syncs are recorded and logged instead of sent to Discord, while lookups
+// still reach it. Useful to shadow-deploy a bot against production traffic.
+// See DryRunCalls().
+func (m *Mux) SetDryRun(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !enabled {
+		m.dryRun = nil
+		return
+	}
+	if m.dryRun == nil {
+		m.dryRun = NewMockSession()
+	}
+}
+
+// WithDryRun makes the mux run dry, as SetDryRun()
+func WithDryRun() Option {
+	return func(m *Mux) error {
+		m.SetDryRun(true)
+		return nil
+	}
+}
+
+// DryRunCalls returns the requests recorded instead of sent while running dry
+func (m *Mux) DryRunCalls() []MockCall {
+	m.mu.RLock()
+	recorder := m.dryRun
+	m.mu.RUnlock()
+
+	if recorder == nil {
+		return nil
+	}
+	return recorder.Calls()
+}
+
+// dryRunning returns the recorder of a dry run, or nil
+func (m *Mux) dryRunning() *MockSession {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.dryRun
+}
+
+// skip logs a request left out in a dry run. The requests below are recorded
+// instead of sent, so later edits and deletions of sent messages still work.
+func (s *dryRunSession) skip(method string, fields map[string]string) {
+	s.mux.log(LogInfo, "Dry run: "+method, fields)
+}
+
+func (s *dryRunSession) ChannelMessageSend(
+	channelID, content string,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	s.skip("ChannelMessageSend", map[string]string{
+		"channel": channelID, "content": content,
+	})
+	return s.recorder.ChannelMessageSend(channelID, content)
+}
+
+func (s *dryRunSession) ChannelMessageSendComplex(
+	channelID string,
+	data *discordgo.MessageSend,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	s.skip("ChannelMessageSendComplex", map[string]string{
+		"channel": channelID, "content": data.Content,
+	})
+	return s.recorder.ChannelMessageSendComplex(channelID, data)
+}
+
+func (s *dryRunSession) ChannelMessageSendEmbed(
+	channelID string,
+	embed *discordgo.MessageEmbed,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	s.skip("ChannelMessageSendEmbed", map[string]string{
+		"channel": channelID, "title": embed.Title,
+	})
+	return s.recorder.ChannelMessageSendEmbed(channelID, embed)
+}
+
+func (s *dryRunSession) ChannelMessageEdit(
+	channelID, messageID, content string,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	s.skip("ChannelMessageEdit", map[string]string{
+		"channel": channelID, "message": messageID, "content": content,
+	})
+	return s.recorder.ChannelMessageEdit(channelID, messageID, content)
+}
+
+func (s *dryRunSession) ChannelMessageEditEmbed(
+	channelID, messageID string,
+	embed *discordgo.MessageEmbed,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	s.skip("ChannelMessageEditEmbed", map[string]string{
+		"channel": channelID, "message": messageID, "title": embed.Title,
+	})
+	return s.recorder.ChannelMessageEditEmbed(channelID, messageID, embed)
+}
+
+func (s *dryRunSession) ChannelMessageDelete(
+	channelID, messageID string,
+	options ...discordgo.RequestOption,
+) error {
+	s.skip("ChannelMessageDelete", map[string]string{
+		"channel": channelID, "message": messageID,
+	})
+	return s.recorder.ChannelMessageDelete(channelID, messageID)
+}
+
+func (s *dryRunSession) ChannelMessagesBulkDelete(
+	channelID string,
+	messages []string,
+	options ...discordgo.RequestOption,
+) error {
+	s.skip("ChannelMessagesBulkDelete", map[string]string{
+		"channel": channelID, "messages": strings.Join(messages, ","),
+	})
+	return s.recorder.ChannelMessagesBulkDelete(channelID, messages)
+}
+
+func (s *dryRunSession) ChannelMessageCrosspost(
+	channelID, messageID string,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	s.skip("ChannelMessageCrosspost", map[string]string{
+		"channel": channelID, "message": messageID,
+	})
+	return s.recorder.ChannelMessageCrosspost(channelID, messageID)
+}
+
+func (s *dryRunSession) MessageReactionAdd(
+	channelID, messageID, emojiID string,
+	options ...discordgo.RequestOption,
+) error {
+	s.skip("MessageReactionAdd", map[string]string{
+		"channel": channelID, "message": messageID, "emoji": emojiID,
+	})
+	return s.recorder.MessageReactionAdd(channelID, messageID, emojiID)
+}
+
+func (s *dryRunSession) MessageReactionRemove(
+	channelID, messageID, emojiID, userID string,
+	options ...discordgo.RequestOption,
+) error {
+	s.skip("MessageReactionRemove", map[string]string{
+		"channel": channelID, "message": messageID, "emoji": emojiID,
+		"user": userID,
+	})
+	return s.recorder.MessageReactionRemove(
+		channelID, messageID, emojiID, userID,
+	)
+}
+
+func (s *dryRunSession) MessageReactionsRemoveAll(
+	channelID, messageID string,
+	options ...discordgo.RequestOption,
+) error {
+	s.skip("MessageReactionsRemoveAll", map[string]string{
+		"channel": channelID, "message": messageID,
+	})
+	return s.recorder.MessageReactionsRemoveAll(channelID, messageID)
+}
+
+func (s *dryRunSession) ChannelTyping(
+	channelID string,
+	options ...discordgo.RequestOption,
+) error {
+	return s.recorder.ChannelTyping(channelID)
+}
+
+func (s *dryRunSession) MessageThreadStart(
+	channelID, messageID, name string,
+	archiveDuration int,
+	options ...discordgo.RequestOption,
+) (*discordgo.Channel, error) {
+	s.skip("MessageThreadStart", map[string]string{
+		"channel": channelID, "message": messageID, "name": name,
+	})
+	return s.recorder.MessageThreadStart(
+		channelID, messageID, name, archiveDuration,
+	)
+}
+
+func (s *dryRunSession) UserChannelCreate(
+	recipientID string,
+	options ...discordgo.RequestOption,
+) (*discordgo.Channel, error) {
+	return s.recorder.UserChannelCreate(recipientID)
+}
+
+func (s *dryRunSession) InteractionRespond(
+	interaction *discordgo.Interaction,
+	resp *discordgo.InteractionResponse,
+	options ...discordgo.RequestOption,
+) error {
+	fields := map[string]string{"interaction": interaction.ID}
+	if resp.Data != nil {
+		fields["content"] = resp.Data.Content
+	}
+	s.skip("InteractionRespond", fields)
+	return s.recorder.InteractionRespond(interaction, resp)
+}
+
+func (s *dryRunSession) InteractionResponse(
+	interaction *discordgo.Interaction,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	return s.recorder.InteractionResponse(interaction)
+}
+
+func (s *dryRunSession) InteractionResponseEdit(
+	interaction *discordgo.Interaction,
+	edit *discordgo.WebhookEdit,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	s.skip("InteractionResponseEdit", map[string]string{
+		"interaction": interaction.ID,
+	})
+	return s.recorder.InteractionResponseEdit(interaction, edit)
+}
+
+func (s *dryRunSession) InteractionResponseDelete(
+	interaction *discordgo.Interaction,
+	options ...discordgo.RequestOption,
+) error {
+	s.skip("InteractionResponseDelete", map[string]string{
+		"interaction": interaction.ID,
+	})
+	return s.recorder.InteractionResponseDelete(interaction)
+}
+
+func (s *dryRunSession) GuildMemberRoleAdd(
+	guildID, userID, roleID string,
+	options ...discordgo.RequestOption,
+) error {
+	s.skip("GuildMemberRoleAdd", map[string]string{
+		"guild": guildID, "user": userID, "role": roleID,
+	})
+	return s.recorder.GuildMemberRoleAdd(guildID, userID, roleID)
+}
+
+func (s *dryRunSession) GuildMemberDeleteWithReason(
+	guildID, userID, reason string,
+	options ...discordgo.RequestOption,
+) error {
+	s.skip("GuildMemberDeleteWithReason", map[string]string{
+		"guild": guildID, "user": userID, "reason": reason,
+	})
+	return s.recorder.GuildMemberDeleteWithReason(guildID, userID, reason)
+}
+
+func (s *dryRunSession) FollowupMessageCreate(
+	interaction *discordgo.Interaction,
+	wait bool,
+	data *discordgo.WebhookParams,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	s.skip("FollowupMessageCreate", map[string]string{
+		"interaction": interaction.ID, "content": data.Content,
+	})
+	return s.recorder.FollowupMessageCreate(interaction, wait, data)
+}
+
+func (s *dryRunSession) FollowupMessageEdit(
+	interaction *discordgo.Interaction,
+	messageID string,
+	data *discordgo.WebhookEdit,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	s.skip("FollowupMessageEdit", map[string]string{
+		"interaction": interaction.ID, "message": messageID,
+	})
+	return s.recorder.FollowupMessageEdit(interaction, messageID, data)
+}
+
+func (s *dryRunSession) FollowupMessageDelete(
+	interaction *discordgo.Interaction,
+	messageID string,
+	options ...discordgo.RequestOption,
+) error {
+	s.skip("FollowupMessageDelete", map[string]string{
+		"interaction": interaction.ID, "message": messageID,
+	})
+	return s.recorder.FollowupMessageDelete(interaction, messageID)
+}
+
+func (s *dryRunSession) WebhookCreate(
+	channelID, name, avatar string,
+	options ...discordgo.RequestOption,
+) (*discordgo.Webhook, error) {
+	s.skip("WebhookCreate", map[string]string{
+		"channel": channelID, "name": name,
+	})
+	return s.recorder.WebhookCreate(channelID, name, avatar)
+}
+
+func (s *dryRunSession) WebhookExecute(
+	webhookID, token string,
+	wait bool,
+	data *discordgo.WebhookParams,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	s.skip("WebhookExecute", map[string]string{
+		"webhook": webhookID, "content": data.Content,
+	})
+	return s.recorder.WebhookExecute(webhookID, token, wait, data)
+}
+
+// skipSync logs and records a slash command sync request left out in a dry
+// run, reporting whether it was left out
+func (m *Mux) skipSync(method, guildID, command string) bool {
+	recorder := m.dryRunning()
+	if recorder == nil {
+		return false
+	}
+
+	recorder.record(method, guildID, command)
+	m.log(LogInfo, "Dry run: "+method, map[string]string{
+		"guild": guildID, "command": command,
+	})
+	return true
+}
@@ -0,0 +1,94 @@
+package disgomux
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// EmbedBuilder is a fluent builder for message embeds. Initialized with
+// NewEmbed().
+type EmbedBuilder struct {
+	embed *discordgo.MessageEmbed
+}
+
+// NewEmbed creates an empty embed builder
+func NewEmbed() *EmbedBuilder {
+	return &EmbedBuilder{embed: &discordgo.MessageEmbed{}}
+}
+
+// Title sets the title of the embed
+func (e *EmbedBuilder) Title(title string) *EmbedBuilder {
+	e.embed.Title = title
+	return e
+}
+
+// Description sets the description of the embed
+func (e *EmbedBuilder) Description(description string) *EmbedBuilder {
+	e.embed.Description = description
+	return e
+}
+
+// URL sets the URL the title of the embed links to
+func (e *EmbedBuilder) URL(url string) *EmbedBuilder {
+	e.embed.URL = url
+	return e
+}
+
+// Color sets the color of the embed, e.g. 0x00ff00
+func (e *EmbedBuilder) Color(color int) *EmbedBuilder {
+	e.embed.Color = color
+	return e
+}
+
+// Field adds a field to the embed
+func (e *EmbedBuilder) Field(name, value string, inline bool) *EmbedBuilder {
+	e.embed.Fields = append(e.embed.Fields, &discordgo.MessageEmbedField{
+		Name:   name,
+		Value:  value,
+		Inline: inline,
+	})
+	return e
+}
+
+// Author sets the author of the embed. url and iconURL may be empty.
+func (e *EmbedBuilder) Author(name, url, iconURL string) *EmbedBuilder {
+	e.embed.Author = &discordgo.MessageEmbedAuthor{
+		Name:    name,
+		URL:     url,
+		IconURL: iconURL,
+	}
+	return e
+}
+
+// Footer sets the footer of the embed. iconURL may be empty.
+func (e *EmbedBuilder) Footer(text, iconURL string) *EmbedBuilder {
+	e.embed.Footer = &discordgo.MessageEmbedFooter{
+		Text:    text,
+		IconURL: iconURL,
+	}
+	return e
+}
+
+// Image sets the image of the embed
+func (e *EmbedBuilder) Image(url string) *EmbedBuilder {
+	e.embed.Image = &discordgo.MessageEmbedImage{URL: url}
+	return e
+}
+
+// Thumbnail sets the thumbnail of the embed
+func (e *EmbedBuilder) Thumbnail(url string) *EmbedBuilder {
+	e.embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: url}
+	return e
+}
+
+// Timestamp sets the timestamp of the embed
+func (e *EmbedBuilder) Timestamp(t time.Time) *EmbedBuilder {
+	e.embed.Timestamp = t.Format(time.RFC3339)
+	return e
+}
+
+// Build returns the built embed
+func (e *EmbedBuilder) Build() *discordgo.MessageEmbed {
+	return e.embed
+}
@@ -0,0 +1,153 @@
+package disgomux
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	// ErrInvalidPrefix is returned when creating a mux with a prefix it
+	// can't route on
+	ErrInvalidPrefix = errors.New("Invalid prefix")
+
+	// ErrDuplicateCommand is returned when a command name is already
+	// registered, and marks duplicates in a LintError
+	ErrDuplicateCommand = errors.New("Command already registered")
+)
+
+var (
+	// ErrNotFound is passed to the OnReject hook when no command matches an
+	// invocation
+	ErrNotFound = errors.New("Command not found")
+
+	// ErrNoPermission is passed to the OnReject hook when the invoking user
+	// lacks the permissions of the command
+	ErrNoPermission = errors.New("No permission to run the command")
+
+	// ErrCooldown is matched by a *CooldownError, passed to the OnReject hook
+	// when the command is cooling down
+	ErrCooldown = errors.New("Command is cooling down")
+
+	// ErrMaintenance is passed to the OnReject hook when an invocation is
+	// refused because the mux is in maintenance mode
+	ErrMaintenance = errors.New("Maintenance in progress")
+
+	// ErrQuotaExceeded is matched by a *QuotaError, passed to the OnReject
+	// hook when the quota of the command is used up
+	ErrQuotaExceeded = errors.New("Command quota exceeded")
+
+	// ErrInvalidArguments is matched by an *ArgumentError, passed to the
+	// OnReject hook when the arguments don't match the spec of the command
+	ErrInvalidArguments = errors.New("Invalid arguments")
+
+	// ErrChainTooLong is passed to the OnReject hook when a message chains more
+	// commands than the limit set with SetChaining()
+	ErrChainTooLong = errors.New("Too many chained commands")
+
+	// ErrInhibited is matched by an *InhibitError, passed to the OnReject
+	// hook when an Inhibitor refuses an invocation
+	ErrInhibited = errors.New("Invocation inhibited")
+
+	// ErrNotInVoice is returned by the voice state helpers of Context when
+	// the invoking user is not in a voice channel
+	ErrNotInVoice = errors.New("Not in a voice channel")
+)
+
+var (
+	// ErrTimeout is returned when waiting for a message or event times out
+	ErrTimeout = errors.New("Timed out waiting for a response")
+
+	// ErrCancelled is returned when the user cancels an interactive flow
+	ErrCancelled = errors.New("Cancelled by the user")
+
+	// ErrVerificationFailed is returned by Verification.Run() when the user
+	// gives wrong answers to every attempt
+	ErrVerificationFailed = errors.New("Verification failed")
+
+	// ErrCommandTimeout is reported when a handler exceeds its Timeout
+	ErrCommandTimeout = errors.New("Command timed out")
+)
+
+var (
+	// ErrUserNotFound is returned when a user argument can't be resolved
+	ErrUserNotFound = errors.New("User not found")
+
+	// ErrArgumentMissing is returned when a typed argument was not given
+	ErrArgumentMissing = errors.New("Argument missing")
+)
+
+var (
+	// ErrNotInteraction is returned by interaction helpers when the
+	// invocation is not an interaction
+	ErrNotInteraction = errors.New("Not an interaction")
+
+	// ErrAlreadyResponded is returned when responding to an interaction for
+	// the second time
+	ErrAlreadyResponded = errors.New("Interaction already responded to")
+)
+
+// CooldownError is passed to the OnReject hook when a command is cooling down.
+// It matches ErrCooldown.
+type CooldownError struct {
+	Command   string
+	Remaining time.Duration
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("Command %s is cooling down for %s", e.Command, e.Remaining)
+}
+
+// Is reports whether target is ErrCooldown
+func (e *CooldownError) Is(target error) bool {
+	return target == ErrCooldown
+}
+
+// QuotaError is passed to the OnReject hook when the quota of a command is used
+// up. It matches ErrQuotaExceeded.
+type QuotaError struct {
+	Command string
+	Limit   int
+	Reset   time.Time
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf(
+		"Command %s is limited to %d uses until %s",
+		e.Command, e.Limit, e.Reset.UTC().Format(time.RFC3339),
+	)
+}
+
+// Is reports whether target is ErrQuotaExceeded
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// ArgumentError is returned when an argument doesn't match its spec, or can't
+// be read as the type asked for. It matches ErrInvalidArguments.
+type ArgumentError struct {
+	Argument, Reason string
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("Argument %s %s", e.Argument, e.Reason)
+}
+
+// Is reports whether target is ErrInvalidArguments
+func (e *ArgumentError) Is(target error) bool {
+	return target == ErrInvalidArguments
+}
+
+// AmbiguousUserError is returned when a user argument matches several members
+type AmbiguousUserError struct {
+	Query      string
+	Candidates []*discordgo.Member
+}
+
+func (e *AmbiguousUserError) Error() string {
+	return fmt.Sprintf(
+		"%q matches %d members", e.Query, len(e.Candidates),
+	)
+}
@@ -0,0 +1,195 @@
+package disgomux
+
+import (
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ErrorStyle determines how mux-generated errors are delivered
+type ErrorStyle int
+
+// Error styles
+const (
+	// ErrorStylePlain sends a plain message (the default)
+	ErrorStylePlain ErrorStyle = iota
+	// ErrorStyleEmbed sends an embed
+	ErrorStyleEmbed
+	// ErrorStyleTemporary sends a plain message which is deleted after the
+	// error TTL
+	ErrorStyleTemporary
+	// ErrorStyleDM sends the error to the user in a direct message, reacting
+	// to the invocation instead if they have DMs disabled
+	ErrorStyleDM
+	// ErrorStyleReaction only reacts to the invocation with the failure emoji
+	ErrorStyleReaction
+)
+
+// defaultErrorTTL is how long temporary errors stay up without a TTL set
+const defaultErrorTTL = 10 * time.Second
+
+// errorColor is the color of error embeds
+const errorColor = 0xe74c3c
+
+// SetErrorStyle sets how mux-generated errors (not found, no permission,
+// cooldowns, handler errors) are delivered. ttl is how long temporary errors
+// stay up, defaulting to ten seconds.
+func (m *Mux) SetErrorStyle(style ErrorStyle, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.errorStyle = style
+	m.errorTTL = ttl
+}
+
+// errorDelivery returns the error style and the TTL of temporary errors
+func (m *Mux) errorDelivery() (ErrorStyle, time.Duration) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.errorStyle, m.errorTTL
+}
+
+// ErrorTemplateData is the data error texts, and their translations, are
+// rendered with, e.g. "`{{.Command}}` not found, try `{{.Prefix}}help`"
+type ErrorTemplateData struct {
+	Prefix, Command string
+	Arguments       []string
+
+	// Usage is how the command is invoked, as Context.Usage() returns
+	Usage string
+
+	User *discordgo.User
+
+	// RetryAfter is the time left on a cooldown or quota, rounded to the
+	// second
+	RetryAfter time.Duration
+
+	// ResetsAt is when the cooldown or quota resets, e.g. for a Discord
+	// timestamp: "<t:{{.ResetsAt.Unix}}:R>"
+	ResetsAt time.Time
+}
+
+// renderError renders an error text for the invocation. Texts which are not
+// templates, or fail to render, are returned as they are.
+func (m *Mux) renderError(
+	ctx *Context,
+	text string,
+	retryAfter time.Duration,
+) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+
+	var t *template.Template
+	if cached, ok := m.errorTemplates.Load(text); ok {
+		t = cached.(*template.Template)
+	} else {
+		parsed, err := template.New("error").Parse(text)
+		if err != nil {
+			return text
+		}
+		m.errorTemplates.Store(text, parsed)
+		t = parsed
+	}
+
+	data := ErrorTemplateData{
+		Prefix:     ctx.Prefix,
+		Command:    ctx.Command,
+		Arguments:  append([]string(nil), ctx.Arguments...),
+		Usage:      ctx.Usage(),
+		User:       ctx.Message.Author,
+		RetryAfter: retryAfter.Round(time.Second),
+	}
+	if retryAfter > 0 {
+		data.ResetsAt = m.clock().Now().Add(retryAfter)
+	}
+
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return text
+	}
+	return sb.String()
+}
+
+// replyError delivers a mux-generated error text in reply to an invocation,
+// in the configured error style. Empty texts are not delivered.
+func (m *Mux) replyError(ctx *Context, text string, retryAfter time.Duration) {
+	if text == "" {
+		return
+	}
+
+	channelID := ctx.Message.ChannelID
+	style, ttl := m.errorDelivery()
+
+	/*
+		Interactions must be responded to, whatever the error style. The
+		response is only shown to the invoker.
+	*/
+	if ctx.Interaction != nil {
+		ms := &discordgo.MessageSend{Content: m.renderError(ctx, text, retryAfter)}
+		if style == ErrorStyleEmbed {
+			ms = &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{{
+				Description: ms.Content,
+				Color:       errorColor,
+			}}}
+		}
+		ms.Flags = discordgo.MessageFlagsEphemeral
+		ctx.send(channelID, ms)
+		return
+	}
+
+	/* Texts quote the invocation, so they follow the mentions policy too */
+	ms := &discordgo.MessageSend{
+		Content:         m.renderError(ctx, text, retryAfter),
+		AllowedMentions: ctx.allowedMentions(),
+	}
+
+	switch style {
+	case ErrorStyleReaction:
+		ctx.ReactFailure()
+
+	case ErrorStyleEmbed:
+		ms.Embeds = []*discordgo.MessageEmbed{{
+			Description: ms.Content,
+			Color:       errorColor,
+		}}
+		ms.Content = ""
+		m.retry(channelID, func() error {
+			_, err := ctx.API().ChannelMessageSendComplex(channelID, ms)
+			return err
+		})
+
+	case ErrorStyleTemporary:
+		var msg *discordgo.Message
+		err := m.retry(channelID, func() (err error) {
+			msg, err = ctx.API().ChannelMessageSendComplex(channelID, ms)
+			return err
+		})
+		if err != nil {
+			return
+		}
+
+		if ttl <= 0 {
+			ttl = defaultErrorTTL
+		}
+		m.temps.deleteAfter(m.clock(), ctx.API(), msg, ttl)
+
+	case ErrorStyleDM:
+		channel, err := ctx.dmChannel()
+		if err == nil {
+			_, err = ctx.API().ChannelMessageSendComplex(channel, ms)
+		}
+		if err != nil {
+			ctx.ReactFailure()
+		}
+
+	default:
+		m.retry(channelID, func() error {
+			_, err := ctx.API().ChannelMessageSendComplex(channelID, ms)
+			return err
+		})
+	}
+}
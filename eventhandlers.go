@@ -0,0 +1,141 @@
+package disgomux
+
+import (
+	"reflect"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// AllEvents registers an event handler for every event
+const AllEvents = "*"
+
+// On registers a handler for a DiscordGo event type, named after its struct,
+// e.g. "GuildBanAdd" or "MessageReactionAdd", or AllEvents. Handlers go
+// through the middlewares, and are recovered, logged, traced and measured
+// like commands, under the event name. ctx.Event is the event; ctx.Message
+// carries its guild, channel and user when it has them. Pass Mux.HandleEvent
+// to DiscordGo.
+func (m *Mux) On(eventType string, handler func(ctx *Context)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.eventHandlers == nil {
+		m.eventHandlers = make(map[string][]func(ctx *Context))
+	}
+	m.eventHandlers[eventType] = append(m.eventHandlers[eventType], handler)
+}
+
+// HandleEvent is passed to DiscordGo as the single handler of all events.
+// Messages, interactions and member events are routed as by Handle(),
+// HandleInteraction(), HandleMemberAdd() and HandleMemberRemove(), guild joins
+// to the OnGuildJoin handler, then every event goes to the handlers
+// registered with On().
+func (m *Mux) HandleEvent(session *discordgo.Session, event interface{}) {
+	switch e := event.(type) {
+	case *discordgo.MessageCreate:
+		m.Handle(session, e)
+	case *discordgo.InteractionCreate:
+		m.HandleInteraction(session, e)
+	case *discordgo.GuildMemberAdd:
+		m.HandleMemberAdd(session, e)
+	case *discordgo.GuildMemberRemove:
+		m.HandleMemberRemove(session, e)
+	}
+
+	if m.trackGuilds(event) {
+		m.handleGuildJoin(session, event.(*discordgo.GuildCreate))
+	}
+
+	name := eventName(event)
+	if name == "" {
+		return
+	}
+
+	m.mu.RLock()
+	var handlers []func(ctx *Context)
+	handlers = append(handlers, m.eventHandlers[name]...)
+	handlers = append(handlers, m.eventHandlers[AllEvents]...)
+	m.mu.RUnlock()
+	if len(handlers) == 0 {
+		return
+	}
+
+	guildID, channelID, user, member := eventOrigin(event)
+	for _, h := range handlers {
+		ctx := m.eventContext(
+			session, event, name, guildID, channelID, user, member,
+		)
+		m.handleEvent(ctx, h)
+	}
+}
+
+// eventName returns the name of the struct type of an event
+func eventName(event interface{}) string {
+	t := reflect.TypeOf(event)
+	if t == nil {
+		return ""
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// eventOrigin finds the guild, channel and user an event comes from, where
+// it has them
+func eventOrigin(event interface{}) (
+	guildID, channelID string,
+	user *discordgo.User,
+	member *discordgo.Member,
+) {
+	v := reflect.ValueOf(event)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	guildID = stringField(v, "GuildID")
+	channelID = stringField(v, "ChannelID")
+
+	if f := field(v, "Member"); f.IsValid() {
+		member, _ = f.Interface().(*discordgo.Member)
+	}
+	if f := field(v, "User"); f.IsValid() {
+		user, _ = f.Interface().(*discordgo.User)
+	}
+	if f := field(v, "Author"); user == nil && f.IsValid() {
+		user, _ = f.Interface().(*discordgo.User)
+	}
+	if user == nil && member != nil {
+		user = member.User
+	}
+	if id := stringField(v, "UserID"); user == nil && id != "" {
+		user = &discordgo.User{ID: id}
+	}
+	return
+}
+
+// field returns the named field of a struct, including promoted fields of
+// embedded pointers, or the zero Value
+func field(v reflect.Value, name string) (f reflect.Value) {
+	defer func() {
+		/* FieldByName panics going through a nil embedded pointer */
+		if recover() != nil {
+			f = reflect.Value{}
+		}
+	}()
+	return v.FieldByName(name)
+}
+
+// stringField returns the named string field of a struct, or an empty string
+func stringField(v reflect.Value, name string) string {
+	if f := field(v, name); f.IsValid() && f.Kind() == reflect.String {
+		return f.String()
+	}
+	return ""
+}
@@ -0,0 +1,203 @@
+package disgomux
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// events is the registry of temporary listeners waiting for gateway
+	// events, used by prompts and other interactive helpers
+	events struct {
+		mu        sync.Mutex
+		waiters   []*waiter
+		listeners []*listener
+		attached  sync.Map
+	}
+
+	// waiter is a single pending wait. The first event match accepts is
+	// delivered on ch.
+	waiter struct {
+		match func(event interface{}) bool
+		ch    chan interface{}
+	}
+
+	// listener is a persistent subscription, called for every event match
+	// accepts until cancelled
+	listener struct {
+		match  func(event interface{}) bool
+		handle func(event interface{})
+	}
+)
+
+// attach installs the single handler through which the registry receives the
+// events of session. Message creates are delivered by Mux.Handle() instead, so
+// they are skipped. Safe to call repeatedly.
+func (e *events) attach(session *discordgo.Session) {
+	if _, loaded := e.attached.LoadOrStore(session, true); loaded {
+		return
+	}
+
+	session.AddHandler(func(_ *discordgo.Session, event interface{}) {
+		if _, ok := event.(*discordgo.MessageCreate); ok {
+			return
+		}
+		e.deliver(event)
+	})
+}
+
+// eventContext builds the Context of a gateway event handled like a command.
+// The synthetic message carries the guild, channel and user of the event, so
+// middleware and logging work as they do for messages.
+func (m *Mux) eventContext(
+	session *discordgo.Session,
+	event interface{},
+	name, guildID, channelID string,
+	user *discordgo.User,
+	member *discordgo.Member,
+) *Context {
+	if user == nil {
+		user = &discordgo.User{}
+	}
+
+	message := &discordgo.MessageCreate{Message: &discordgo.Message{
+		ChannelID: channelID,
+		GuildID:   guildID,
+		Author:    user,
+		Member:    member,
+	}}
+
+	return &Context{
+		Command:    name,
+		Arguments:  []string{},
+		Session:    session,
+		Message:    message,
+		Event:      event,
+		mux:        m,
+		id:         newInvocationID(),
+		invocation: m.lifecycle.context(),
+	}
+}
+
+// handleEvent routes a gateway event to a handler through the middleware
+// pipeline of the mux, like a command named name
+func (m *Mux) handleEvent(ctx *Context, handler func(ctx *Context)) {
+	if m.lifecycle.isClosed() {
+		return
+	}
+
+	invocationCtx, span := m.startSpan(ctx, spanInvocation)
+	ctx.invocation = invocationCtx
+
+	m.mu.RLock()
+	middleware := m.middleware()
+	m.mu.RUnlock()
+
+	m.route(ctx, &funcCommand{
+		settings: CommandSettings{Command: ctx.Command},
+		handle:   handler,
+	}, middleware, m.opts(), span)
+}
+
+// wait blocks until an event accepted by match is delivered, or the timeout
+// passes
+func (e *events) wait(
+	clock Clock,
+	timeout time.Duration,
+	match func(event interface{}) bool,
+) (interface{}, error) {
+	w := &waiter{match: match, ch: make(chan interface{}, 1)}
+
+	e.mu.Lock()
+	e.waiters = append(e.waiters, w)
+	e.mu.Unlock()
+
+	timer := clock.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case event := <-w.ch:
+		return event, nil
+	case <-timer.C():
+		e.remove(w)
+
+		/* The event may have been delivered while timing out */
+		select {
+		case event := <-w.ch:
+			return event, nil
+		default:
+			return nil, ErrTimeout
+		}
+	}
+}
+
+// listen calls handle for every event accepted by match until the returned
+// function is called
+func (e *events) listen(
+	match func(event interface{}) bool,
+	handle func(event interface{}),
+) (cancel func()) {
+	l := &listener{match: match, handle: handle}
+
+	e.mu.Lock()
+	e.listeners = append(e.listeners, l)
+	e.mu.Unlock()
+
+	return func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		for i, o := range e.listeners {
+			if o == l {
+				e.listeners = append(e.listeners[:i], e.listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// deliver passes event to all listeners accepting it, then hands it to the
+// oldest waiter which accepts it. It reports whether a waiter consumed the
+// event.
+func (e *events) deliver(event interface{}) bool {
+	e.mu.Lock()
+
+	var handlers []func(interface{})
+	for _, l := range e.listeners {
+		if l.match(event) {
+			handlers = append(handlers, l.handle)
+		}
+	}
+
+	consumed := false
+	for i, w := range e.waiters {
+		if w.match(event) {
+			e.waiters = append(e.waiters[:i], e.waiters[i+1:]...)
+			w.ch <- event
+			consumed = true
+			break
+		}
+	}
+
+	e.mu.Unlock()
+
+	/* Listeners are called without the lock so they may wait or listen */
+	for _, h := range handlers {
+		h(event)
+	}
+	return consumed
+}
+
+func (e *events) remove(w *waiter) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, o := range e.waiters {
+		if o == w {
+			e.waiters = append(e.waiters[:i], e.waiters[i+1:]...)
+			return
+		}
+	}
+}
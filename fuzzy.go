@@ -0,0 +1,118 @@
+package disgomux
+
+import (
+	"sort"
+
+	"github.com/sahilm/fuzzy"
+)
+
+// Matcher returns the subset of targets which are close enough to source to be
+// suggested to the user, best match first.
+type Matcher func(source string, targets []string) []string
+
+// SubsequenceMatcher matches targets which contain the characters of source in
+// order. This is the default matcher used by InitializeFuzzy().
+func SubsequenceMatcher(source string, targets []string) []string {
+	var matches []string
+	for _, fzy := range fuzzy.Find(source, targets) {
+		matches = append(matches, fzy.Str)
+	}
+	return matches
+}
+
+// EditDistanceMatcher returns a matcher which suggests targets within
+// maxDistance edits of source. Edits are insertions, deletions, substitutions
+// and transpositions of adjacent characters (Damerau-Levenshtein), so common
+// typos such as "paly" still match "play".
+func EditDistanceMatcher(maxDistance int) Matcher {
+	return func(source string, targets []string) []string {
+		type match struct {
+			str  string
+			dist int
+		}
+
+		var found []match
+		for _, t := range targets {
+			if d := editDistance(source, t); d <= maxDistance {
+				found = append(found, match{t, d})
+			}
+		}
+
+		sort.SliceStable(found, func(i, j int) bool {
+			return found[i].dist < found[j].dist
+		})
+
+		matches := make([]string, len(found))
+		for i, f := range found {
+			matches[i] = f.str
+		}
+		return matches
+	}
+}
+
+// editDistance computes the optimal string alignment distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	/* Three rows are needed to account for transpositions */
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				if t := prev2[j-2] + 1; t < curr[j] {
+					curr[j] = t
+				}
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// Suggestions returns the commands similar to the invoked one which the user
+// may run in the current guild, best match first. Empty unless fuzzy matching
+// has been enabled with Mux.InitializeFuzzy().
+func (ctx *Context) Suggestions() []string {
+	ctx.mux.mu.RLock()
+	enabled := ctx.mux.fuzzyMatch
+	ctx.mux.mu.RUnlock()
+	if !enabled {
+		return nil
+	}
+
+	check := ctx.perms
+	if check == nil {
+		check = newPermissionCheck(ctx.Session, ctx.API(), ctx.Message)
+	}
+	suggestions := ctx.mux.suggest(ctx.Command, ctx.Message.GuildID, check)
+	if len(suggestions) == 0 {
+		ctx.mux.logCtx(ctx, LogDebug, "No suggestions for unknown command")
+	}
+	return suggestions
+}
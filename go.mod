@@ -3,9 +3,9 @@ module github.com/CS-5/disgomux
 go 1.13
 
 require (
-	github.com/bwmarrin/discordgo v0.20.2
+	github.com/bwmarrin/discordgo v0.29.0
 	github.com/kylelemons/godebug v1.1.0 // indirect
+	github.com/prometheus/client_golang v1.11.1
 	github.com/sahilm/fuzzy v0.1.0
-	golang.org/x/crypto v0.0.0-20200214034016-1d94cc7ab1c6 // indirect
-	golang.org/x/sys v0.0.0-20200212091648-12a6c2dcc1e4 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
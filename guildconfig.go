@@ -0,0 +1,272 @@
+package disgomux
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// GuildConfig is the configuration of the mux for one guild, consulted
+	// when routing its messages and interactions
+	GuildConfig struct {
+		GuildID string `json:"guild_id"`
+
+		// Prefix replaces the prefix of the mux in the guild if set
+		Prefix string `json:"prefix,omitempty"`
+
+		// Locale is the language of the guild, e.g. "en-US"
+		Locale string `json:"locale,omitempty"`
+
+		// DisabledCommands are disabled in the guild, as with DisableCommand()
+		DisabledCommands []string `json:"disabled_commands,omitempty"`
+
+		// ModLogChannel replaces the audit channel of the mux in the guild
+		ModLogChannel string `json:"mod_log_channel,omitempty"`
+
+		// ModRoles are the roles of the moderators of the guild. See
+		// Context.IsModerator().
+		ModRoles []string `json:"mod_roles,omitempty"`
+
+		// Invocations in the ignored channels, or by the ignored users or
+		// members with the ignored roles, are not handled
+		IgnoredChannels []string `json:"ignored_channels,omitempty"`
+		IgnoredUsers    []string `json:"ignored_users,omitempty"`
+		IgnoredRoles    []string `json:"ignored_roles,omitempty"`
+
+		// MinAccountAge and MinMembership refuse invocations by accounts
+		// newer than, or members who joined less than, the thresholds, if
+		// the GuildAgeGate() inhibitor is in use
+		MinAccountAge time.Duration `json:"min_account_age,omitempty"`
+		MinMembership time.Duration `json:"min_membership,omitempty"`
+
+		// Macros expand to other commands, keyed by name. See
+		// Mux.SetMacro().
+		Macros map[string]string `json:"macros,omitempty"`
+
+		// OutputChannels are the channels the responses of commands are
+		// redirected to, keyed by command name, overriding the
+		// OutputChannel of their settings
+		OutputChannels map[string]string `json:"output_channels,omitempty"`
+	}
+
+	// ConfigStore persists the configuration of guilds. Implementations must
+	// be safe for concurrent use.
+	ConfigStore interface {
+		Load(guildID string) (GuildConfig, bool, error)
+		Save(c GuildConfig) error
+		Delete(guildID string) error
+		List() ([]GuildConfig, error)
+	}
+
+	// MemoryConfigStore is a ConfigStore which keeps guild configurations in
+	// memory only
+	MemoryConfigStore struct {
+		mu     sync.RWMutex
+		guilds map[string]GuildConfig
+		users  map[string]string
+	}
+)
+
+// NewMemoryConfigStore creates an empty in-memory store
+func NewMemoryConfigStore() *MemoryConfigStore {
+	return &MemoryConfigStore{
+		guilds: make(map[string]GuildConfig),
+		users:  make(map[string]string),
+	}
+}
+
+// Load returns the configuration of the guild
+func (s *MemoryConfigStore) Load(guildID string) (GuildConfig, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.guilds[guildID]
+	return c, ok, nil
+}
+
+// Save stores c, replacing any configuration of the same guild
+func (s *MemoryConfigStore) Save(c GuildConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.guilds[c.GuildID] = c.clone()
+	return nil
+}
+
+// Delete removes the configuration of the guild
+func (s *MemoryConfigStore) Delete(guildID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.guilds, guildID)
+	return nil
+}
+
+// List returns all stored configurations sorted by guild ID
+func (s *MemoryConfigStore) List() ([]GuildConfig, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]GuildConfig, 0, len(s.guilds))
+	for _, c := range s.guilds {
+		list = append(list, c)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].GuildID < list[j].GuildID
+	})
+	return list, nil
+}
+
+// clone copies the configuration, so it can't be changed through its lists
+func (c GuildConfig) clone() GuildConfig {
+	c.DisabledCommands = append([]string(nil), c.DisabledCommands...)
+	c.ModRoles = append([]string(nil), c.ModRoles...)
+	c.IgnoredChannels = append([]string(nil), c.IgnoredChannels...)
+	c.IgnoredUsers = append([]string(nil), c.IgnoredUsers...)
+	c.IgnoredRoles = append([]string(nil), c.IgnoredRoles...)
+	if c.Macros != nil {
+		macros := make(map[string]string, len(c.Macros))
+		for name, expansion := range c.Macros {
+			macros[name] = expansion
+		}
+		c.Macros = macros
+	}
+	if c.OutputChannels != nil {
+		channels := make(map[string]string, len(c.OutputChannels))
+		for name, channelID := range c.OutputChannels {
+			channels[name] = channelID
+		}
+		c.OutputChannels = channels
+	}
+	return c
+}
+
+// ignores reports whether invocations in the channel by the member are ignored
+func (c GuildConfig) ignores(
+	channelID, userID string,
+	member *discordgo.Member,
+) bool {
+	if arrayContains(c.IgnoredChannels, channelID) ||
+		arrayContains(c.IgnoredUsers, userID) {
+		return true
+	}
+
+	if member != nil {
+		for _, r := range member.Roles {
+			if arrayContains(c.IgnoredRoles, r) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// disables reports whether the command is disabled in the guild
+func (c GuildConfig) disables(command string) bool {
+	return arrayContains(c.DisabledCommands, command)
+}
+
+// GuildConfig returns the configuration of the guild of the invocation. It is
+// empty outside guilds, or for guilds without one.
+func (ctx *Context) GuildConfig() GuildConfig {
+	return ctx.config.clone()
+}
+
+// IsModerator reports whether the author of the invocation owns the bot or has
+// one of the ModRoles of the guild configuration
+func (ctx *Context) IsModerator() bool {
+	if ctx.IsOwner() {
+		return true
+	}
+	if len(ctx.config.ModRoles) == 0 || ctx.Message.GuildID == "" {
+		return false
+	}
+
+	member, err := ctx.Member()
+	if err != nil {
+		return false
+	}
+	for _, r := range member.Roles {
+		if arrayContains(ctx.config.ModRoles, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// guildConfig loads the configuration of the guild. Failures are logged and
+// reported, and an empty configuration is returned.
+func (m *Mux) guildConfig(guildID string) GuildConfig {
+	if guildID == "" || m.configs == nil {
+		return GuildConfig{GuildID: guildID}
+	}
+
+	c, ok, err := m.configs.Load(guildID)
+	if err != nil {
+		fields := map[string]string{"guild": guildID}
+		m.log(LogError, "Failed to load guild config", fields)
+		m.report(err, fields)
+	}
+	if err != nil || !ok {
+		return GuildConfig{GuildID: guildID}
+	}
+	return c
+}
+
+// SetConfigStore sets the store guild configurations are kept in. Defaults to
+// a MemoryConfigStore.
+func (m *Mux) SetConfigStore(store ConfigStore) {
+	m.configs = store
+}
+
+// InitGuildConfig stores defaults as the configuration of the guild unless it
+// already has one, and applies its disabled commands. The configuration of the
+// guild is returned.
+func (m *Mux) InitGuildConfig(
+	guildID string,
+	defaults GuildConfig,
+) (GuildConfig, error) {
+	c, ok, err := m.configs.Load(guildID)
+	if err != nil {
+		return GuildConfig{}, err
+	}
+
+	if !ok {
+		c = defaults
+		c.GuildID = guildID
+		if err := m.configs.Save(c); err != nil {
+			return GuildConfig{}, err
+		}
+	}
+
+	if len(c.DisabledCommands) > 0 {
+		m.DisableCommand(guildID, c.DisabledCommands...)
+	}
+	return c, nil
+}
+
+// UpdateGuildConfig loads the configuration of the guild, applies update to it
+// and saves it to the store
+func (m *Mux) UpdateGuildConfig(
+	guildID string,
+	update func(c *GuildConfig),
+) (GuildConfig, error) {
+	c, ok, err := m.configs.Load(guildID)
+	if err != nil {
+		return GuildConfig{}, err
+	}
+	if !ok {
+		c = GuildConfig{GuildID: guildID}
+	}
+
+	update(&c)
+	c.GuildID = guildID
+	if err := m.configs.Save(c); err != nil {
+		return GuildConfig{}, err
+	}
+	return c, nil
+}
@@ -0,0 +1,67 @@
+package disgomux
+
+import "github.com/bwmarrin/discordgo"
+
+// guildJoinEvent is the name guild joins are handled under
+const guildJoinEvent = "guild_join"
+
+// OnGuildJoin sets a handler called when the bot is added to a guild, e.g. to
+// set up the guild with Mux.InitGuildConfig() and post a welcome message. It
+// goes through the middlewares like commands, under "guild_join". ctx.Event is
+// the *discordgo.GuildCreate, and the channel of ctx is the system channel of
+// the guild (if it has one), so ctx.ChannelSend() posts there. Guilds loaded
+// when connecting, or becoming available again after an outage, are not
+// joins. Requires Mux.HandleEvent to be passed to DiscordGo.
+func (m *Mux) OnGuildJoin(handler func(ctx *Context)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.guildJoin = handler
+}
+
+// trackGuilds keeps the guilds which the gateway announces without the bot
+// joining them: those in the ready event, and those going unavailable. It
+// reports whether a guild create event is a join.
+func (m *Mux) trackGuilds(event interface{}) bool {
+	switch e := event.(type) {
+	case *discordgo.Ready:
+		for _, g := range e.Guilds {
+			m.knownGuilds.Store(g.ID, true)
+		}
+
+	case *discordgo.GuildDelete:
+		if e.Unavailable {
+			m.knownGuilds.Store(e.ID, true)
+		}
+
+	case *discordgo.GuildCreate:
+		_, known := m.knownGuilds.Load(e.ID)
+		m.knownGuilds.Delete(e.ID)
+		return !known
+	}
+	return false
+}
+
+// handleGuildJoin routes a guild join to the OnGuildJoin handler
+func (m *Mux) handleGuildJoin(
+	session *discordgo.Session,
+	event *discordgo.GuildCreate,
+) {
+	m.mu.RLock()
+	handler := m.guildJoin
+	m.mu.RUnlock()
+	if handler == nil {
+		return
+	}
+
+	var owner *discordgo.User
+	if event.OwnerID != "" {
+		owner = &discordgo.User{ID: event.OwnerID}
+	}
+
+	ctx := m.eventContext(
+		session, event, guildJoinEvent, event.ID, event.SystemChannelID,
+		owner, nil,
+	)
+	m.handleEvent(ctx, handler)
+}
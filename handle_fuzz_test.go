@@ -0,0 +1,59 @@
+//go:build go1.18
+// +build go1.18
+
+package disgomux_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/CS-5/disgomux"
+	"github.com/CS-5/disgomux/muxtest"
+)
+
+// recorder is a command remembering the invocation it handled
+type recorder struct {
+	noop
+	ctx *disgomux.Context
+}
+
+func (c *recorder) Handle(ctx *disgomux.Context) { c.ctx = ctx }
+
+func FuzzHandlePrefix(f *testing.F) {
+	f.Add("!", "hello world")
+	f.Add("é", "")
+	f.Add("👋🏽", "🇳🇱 flags\nand lines")
+	f.Add("🇳🇱", "  spaced  out ")
+	f.Add("1️⃣", "\xff\xfe")
+
+	f.Fuzz(func(t *testing.T, prefix, content string) {
+		m, err := disgomux.New(prefix)
+		if err != nil || prefix == "" {
+			return
+		}
+
+		c := &recorder{noop: noop{name: "echo"}}
+		m.Register(c)
+		h := muxtest.New(m)
+
+		/* Any content after the prefix is parsed without panicking */
+		h.Send(prefix + content)
+		h.Send(content)
+
+		/* The prefix is stripped whole, whatever its size */
+		c.ctx = nil
+		h.Send(prefix + "echo " + content)
+		if c.ctx == nil {
+			t.Fatalf("%q with prefix %q not routed", content, prefix)
+		}
+		if c.ctx.Prefix != prefix || c.ctx.Command != "echo" {
+			t.Fatalf(
+				"prefix %q, command %q, want %q and echo",
+				c.ctx.Prefix, c.ctx.Command, prefix,
+			)
+		}
+		if args := strings.Join(c.ctx.Arguments, " "); args != content {
+			t.Fatalf("arguments %q, want %q", args, content)
+		}
+	})
+}
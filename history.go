@@ -0,0 +1,192 @@
+package disgomux
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHistorySize is the number of invocations a MemoryHistoryStore keeps
+// per user unless told otherwise
+const defaultHistorySize = 50
+
+type (
+	// HistoryEntry is an invocation in the history of a user
+	HistoryEntry struct {
+		Command, Subcommand string
+		Arguments           []string
+		GuildID, ChannelID  string
+		Time                time.Time
+
+		// Error is the error the handler returned, empty if it succeeded
+		Error string
+	}
+
+	// HistoryStore keeps the recent invocations of each user. Implementations
+	// must be safe for concurrent use, and may forget old entries.
+	HistoryStore interface {
+		// Add appends an invocation to the history of the user
+		Add(userID string, entry HistoryEntry) error
+
+		// History returns up to limit of the most recent invocations of the
+		// user, newest first. A limit of zero returns all of them.
+		History(userID string, limit int) ([]HistoryEntry, error)
+	}
+
+	// MemoryHistoryStore is a HistoryStore which keeps a bounded number of
+	// invocations per user in memory
+	MemoryHistoryStore struct {
+		mu    sync.Mutex
+		size  int
+		users map[string][]HistoryEntry
+	}
+)
+
+// ErrNoHistory is returned by Context.RepeatLast() when the user has no
+// invocation to repeat
+var ErrNoHistory = errors.New("No command to repeat")
+
+// NewMemoryHistoryStore creates an empty in-memory history store keeping the
+// size most recent invocations of each user (50 if size is zero)
+func NewMemoryHistoryStore(size int) *MemoryHistoryStore {
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+	return &MemoryHistoryStore{
+		size:  size,
+		users: make(map[string][]HistoryEntry),
+	}
+}
+
+// Add appends an invocation to the history of the user, forgetting the oldest
+// once the history is full
+func (s *MemoryHistoryStore) Add(userID string, entry HistoryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := append(s.users[userID], entry)
+	if len(entries) > s.size {
+		entries = append([]HistoryEntry(nil), entries[len(entries)-s.size:]...)
+	}
+	s.users[userID] = entries
+	return nil
+}
+
+// History returns up to limit of the most recent invocations of the user,
+// newest first
+func (s *MemoryHistoryStore) History(
+	userID string,
+	limit int,
+) ([]HistoryEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := s.users[userID]
+	if limit <= 0 || limit > len(entries) {
+		limit = len(entries)
+	}
+
+	history := make([]HistoryEntry, limit)
+	for i := range history {
+		history[i] = entries[len(entries)-1-i]
+	}
+	return history, nil
+}
+
+// SetHistoryStore sets the store the invocations of each user are kept in,
+// e.g. NewMemoryHistoryStore(50). Defaults to nil, which keeps no history.
+func (m *Mux) SetHistoryStore(store HistoryStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.history = store
+}
+
+// WithHistoryStore keeps the invocations of each user in the store, as
+// SetHistoryStore()
+func WithHistoryStore(store HistoryStore) Option {
+	return func(m *Mux) error {
+		if store == nil {
+			return &OptionError{Option: "WithHistoryStore", Reason: "store is nil"}
+		}
+		m.history = store
+		return nil
+	}
+}
+
+// History returns up to limit of the most recent invocations of the user,
+// newest first. Empty if no history store is set.
+func (m *Mux) History(userID string, limit int) ([]HistoryEntry, error) {
+	store := m.historyStore()
+	if store == nil {
+		return []HistoryEntry{}, nil
+	}
+	return store.History(userID, limit)
+}
+
+// RepeatLast invokes the most recent command of the user again, as if they
+// had sent it in the current channel, skipping invocations of the current
+// command (e.g. the command calling RepeatLast). It goes through the usual
+// routing, so permissions and cooldowns apply. Fails with ErrNoHistory if
+// there is nothing to repeat.
+func (ctx *Context) RepeatLast() error {
+	history, err := ctx.mux.History(ctx.Message.Author.ID, 0)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range history {
+		if entry.Command == ctx.Command {
+			continue
+		}
+
+		content := entry.Command
+		if entry.Subcommand != "" {
+			content += " " + entry.Subcommand
+		}
+		if len(entry.Arguments) != 0 {
+			content += " " + strings.Join(entry.Arguments, " ")
+		}
+
+		m := ctx.mux
+		m.handleSegment(
+			ctx.Session, ctx.Message, ctx.config, m.opts(), ctx.Prefix,
+			content, false, nil, func(error) {},
+		)
+		return nil
+	}
+	return ErrNoHistory
+}
+
+// historyStore returns the history store of the mux, nil if there is none
+func (m *Mux) historyStore() HistoryStore {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.history
+}
+
+// recordHistory adds a finished invocation to the history of its author
+func (m *Mux) recordHistory(ctx *Context, err error) {
+	store := m.historyStore()
+	if store == nil {
+		return
+	}
+
+	entry := HistoryEntry{
+		Command:    ctx.Command,
+		Subcommand: ctx.Subcommand,
+		Arguments:  append([]string(nil), ctx.Arguments...),
+		GuildID:    ctx.Message.GuildID,
+		ChannelID:  ctx.Message.ChannelID,
+		Time:       m.clock().Now(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	if addErr := store.Add(ctx.Message.Author.ID, entry); addErr != nil {
+		m.reportCtx(ctx, addErr)
+	}
+}
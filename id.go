@@ -0,0 +1,40 @@
+package disgomux
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// invocationIDBytes is the number of random bytes in an invocation ID
+const invocationIDBytes = 6
+
+// newInvocationID generates a random invocation ID
+func newInvocationID() string {
+	b := make([]byte, invocationIDBytes)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// ID returns the unique ID of the invocation, which is included in logs and
+// error reports so failures reported by users can be found
+func (ctx *Context) ID() string {
+	return ctx.id
+}
+
+// SetErrorRefs makes the HandlerError reply end with the invocation ID
+// ("Error ref: ab12cd34ef56"), so users can quote it when reporting a failure
+func (m *Mux) SetErrorRefs(enabled bool) {
+	m.errorRefs = enabled
+}
+
+// handlerErrorText returns the HandlerError text for the invocation, with its
+// ID if error refs are enabled
+func (m *Mux) handlerErrorText(ctx *Context) string {
+	text := m.errorText(ctx, MessageHandlerError)
+	if !m.errorRefs || ctx.id == "" {
+		return text
+	}
+	return text + "\n" + m.localize(ctx, MessageErrorRef, "Error ref: "+ctx.id)
+}
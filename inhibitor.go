@@ -0,0 +1,69 @@
+package disgomux
+
+type (
+	// Inhibitor decides whether an invocation may go ahead, once the
+	// permissions of the command are checked and before its cooldown is
+	// taken. A non-nil error refuses the invocation: it is passed to the
+	// OnReject hook (as an *InhibitError), or else its text is sent to the
+	// user, so it should read as an explanation. Return an *InhibitError to
+	// send a text other than the error's.
+	Inhibitor func(ctx *Context) error
+
+	// InhibitError is the error an invocation refused by an Inhibitor is
+	// rejected with
+	InhibitError struct {
+		// Reason is the text sent to the user
+		Reason string
+
+		// Err is the error the inhibitor refused the invocation with, if any
+		Err error
+	}
+)
+
+func (e *InhibitError) Error() string {
+	return e.Reason
+}
+
+// Unwrap returns the error the inhibitor refused the invocation with
+func (e *InhibitError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrInhibited
+func (e *InhibitError) Is(target error) bool {
+	return target == ErrInhibited
+}
+
+// UseInhibitor adds an inhibitor consulted for every command, before those of
+// the command itself (see CommandSettings.Inhibitors)
+func (m *Mux) UseInhibitor(inhibitor Inhibitor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	/* Copy on write, so a running Handle() keeps its own slice */
+	inhibitors := make([]Inhibitor, len(m.inhibitors), len(m.inhibitors)+1)
+	copy(inhibitors, m.inhibitors)
+	m.inhibitors = append(inhibitors, inhibitor)
+}
+
+// inhibit runs the inhibitors of the mux, then those given, returning the
+// error the first to refuse the invocation failed with
+func (m *Mux) inhibit(ctx *Context, inhibitors []Inhibitor) *InhibitError {
+	m.mu.RLock()
+	global := m.inhibitors
+	m.mu.RUnlock()
+
+	for _, list := range [][]Inhibitor{global, inhibitors} {
+		for _, inhibitor := range list {
+			err := inhibitor(ctx)
+			if err == nil {
+				continue
+			}
+			if e, ok := err.(*InhibitError); ok {
+				return e
+			}
+			return &InhibitError{Reason: err.Error(), Err: err}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package disgomux
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandleInteraction is passed to DiscordGo to handle interactions. Slash
+// commands are routed to the registered commands like messages are, through
+// the middlewares and the permission and cooldown checks. Their options become
+// the Arguments of the Context, in order, with users, channels and roles as
+// mentions. Sync the commands with Mux.SyncApplicationCommands() first.
+func (m *Mux) HandleInteraction(
+	session *discordgo.Session,
+	interaction *discordgo.InteractionCreate,
+) {
+	/* Ignore if the mux is shutting down */
+	if m.lifecycle.isClosed() {
+		return
+	}
+
+	switch interaction.Type {
+	case discordgo.InteractionApplicationCommand:
+		m.handleApplicationCommand(session, interaction)
+	case discordgo.InteractionMessageComponent:
+		m.handleComponent(session, interaction)
+	case discordgo.InteractionModalSubmit:
+		m.handleModal(session, interaction)
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		m.handleAutocomplete(session, interaction)
+	}
+}
+
+// handleApplicationCommand routes a slash command to the registered command
+func (m *Mux) handleApplicationCommand(
+	session *discordgo.Session,
+	interaction *discordgo.InteractionCreate,
+) {
+	data := interaction.ApplicationCommandData()
+	if data.CommandType == discordgo.UserApplicationCommand ||
+		data.CommandType == discordgo.MessageApplicationCommand {
+		m.handleContextMenu(session, interaction, data)
+		return
+	}
+
+	m.countMessage(session)
+	ctx := m.interactionContext(session, interaction, data.Name)
+
+	invocationCtx, span := m.startSpan(ctx, spanInvocation)
+	ctx.invocation = invocationCtx
+
+	m.mu.RLock()
+	handler, name, ok := m.lookup(ctx.Command)
+	ok = ok && m.commandEnabled(interaction.GuildID, name) &&
+		!ctx.config.disables(name) &&
+		m.dmRouting.allows(interaction.GuildID, name)
+	middleware := m.middleware()
+	m.mu.RUnlock()
+
+	/* Ignored invocations must still be answered, so they are not found */
+	ok = ok && !ctx.config.ignores(
+		interaction.ChannelID, ctx.Message.Author.ID, interaction.Member,
+	)
+
+	if !ok {
+		defer span.End(ErrNotFound)
+		m.countNotFound(session)
+		m.logCtx(ctx, LogDebug, "Command not found")
+		m.reject(ctx, ErrNotFound, m.errorText(ctx, MessageCommandNotFound), 0)
+		return
+	}
+
+	ctx.Command = name
+	handler, options := slashSubcommand(ctx, handler, data.Options)
+	ctx.named = namedOptions(options)
+	ctx.Arguments = specArguments(handler.Settings().Arguments, options)
+	m.route(ctx, handler, middleware, m.opts(), span)
+}
+
+// interactionContext builds the Context of an interaction
+func (m *Mux) interactionContext(
+	session *discordgo.Session,
+	interaction *discordgo.InteractionCreate,
+	command string,
+) *Context {
+	author := interaction.User
+	if interaction.Member != nil {
+		author = interaction.Member.User
+	}
+
+	message := &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        interaction.ID,
+		ChannelID: interaction.ChannelID,
+		GuildID:   interaction.GuildID,
+		Author:    author,
+		Member:    interaction.Member,
+	}}
+
+	/* The member comes with the interaction, so it needn't be fetched */
+	check := newPermissionCheck(session, m.api(session), message)
+	check.member = interaction.Member
+
+	return &Context{
+		Prefix:      "/",
+		Command:     command,
+		Arguments:   []string{},
+		Session:     session,
+		Message:     message,
+		Interaction: interaction,
+		Invocation: &interactionInvocation{
+			session:     m.api(session),
+			interaction: interaction,
+			author:      author,
+		},
+		mux:        m,
+		id:         newInvocationID(),
+		perms:      check,
+		config:     m.guildConfig(interaction.GuildID),
+		invocation: m.lifecycle.context(),
+	}
+}
+
+// namedOptions returns the values of the options of a slash command as they
+// would be typed, keyed by name
+func namedOptions(
+	options []*discordgo.ApplicationCommandInteractionDataOption,
+) map[string]string {
+	named := make(map[string]string, len(options))
+	for _, o := range options {
+		named[o.Name] = optionString(o)
+	}
+	return named
+}
+
+// specArguments flattens the options of a slash command into arguments the
+// way they would be typed in a message: in the order of the argument spec,
+// followed by any option the spec doesn't name
+func specArguments(
+	spec []Argument,
+	options []*discordgo.ApplicationCommandInteractionDataOption,
+) []string {
+	args := make([]string, 0, len(options))
+	used := make(map[string]bool, len(spec))
+
+	for _, a := range spec {
+		name := strings.ToLower(a.Name)
+		for _, o := range options {
+			if o.Name == name {
+				args = append(args, optionString(o))
+				used[name] = true
+				break
+			}
+		}
+	}
+
+	for _, o := range options {
+		if !used[o.Name] {
+			args = append(args, optionString(o))
+		}
+	}
+	return args
+}
+
+// optionString formats the value of an option as it would be typed
+func optionString(o *discordgo.ApplicationCommandInteractionDataOption) string {
+	switch o.Type {
+	case discordgo.ApplicationCommandOptionUser:
+		return "<@" + fmt.Sprint(o.Value) + ">"
+	case discordgo.ApplicationCommandOptionChannel:
+		return "<#" + fmt.Sprint(o.Value) + ">"
+	case discordgo.ApplicationCommandOptionRole:
+		return "<@&" + fmt.Sprint(o.Value) + ">"
+	default:
+		return fmt.Sprint(o.Value)
+	}
+}
+
+// ReplyEphemeral replies with a message only the invoker can see, if the
+// invocation is an interaction. Message invocations get an ordinary reply, as
+// messages can't be hidden from the rest of the channel.
+func (ctx *Context) ReplyEphemeral(content string) (*discordgo.Message, error) {
+	if ctx.Interaction == nil {
+		return ctx.ReplySilent(content)
+	}
+
+	return ctx.send(ctx.Message.ChannelID, &discordgo.MessageSend{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+}
+
+// Defer acknowledges an interaction, showing that the bot is thinking, so a
+// slow handler doesn't run into the three second deadline for a response. The
+// next message sent replaces the thinking indicator. For message invocations,
+// Defer shows the typing indicator instead.
+func (ctx *Context) Defer() error {
+	if ctx.Interaction == nil {
+		ctx.Typing()
+		return nil
+	}
+
+	resp := &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	}
+	if ctx.Interaction.Type == discordgo.InteractionMessageComponent {
+		resp.Type = discordgo.InteractionResponseDeferredMessageUpdate
+	}
+
+	ii, ok := ctx.Invocation.(*interactionInvocation)
+	if !ok {
+		return ErrNotInteraction
+	}
+	return ii.deferResponse(resp)
+}
+
+// Followup sends a message after the response to an interaction, or to the
+// current channel for message invocations. The first message sent after
+// Defer() takes the place of the response.
+func (ctx *Context) Followup(content string) (*discordgo.Message, error) {
+	return ctx.ChannelSend(content)
+}
+
+// respondWith responds to the interaction with a response of the specified
+// type, failing if it has already been responded to
+func (ctx *Context) respondWith(
+	responseType discordgo.InteractionResponseType,
+	ms *discordgo.MessageSend,
+) error {
+	resp := &discordgo.InteractionResponse{Type: responseType}
+	if ms != nil {
+		if ms.AllowedMentions == nil {
+			ms.AllowedMentions = ctx.allowedMentions()
+		}
+		resp.Data = &discordgo.InteractionResponseData{
+			Content:         ms.Content,
+			Embeds:          ms.Embeds,
+			Files:           ms.Files,
+			Components:      ms.Components,
+			AllowedMentions: ms.AllowedMentions,
+		}
+	}
+	return ctx.respondOnce(resp)
+}
+
+// respondOnce sends resp as the response to the interaction, failing if it has
+// already been responded to
+func (ctx *Context) respondOnce(resp *discordgo.InteractionResponse) error {
+	ii, ok := ctx.Invocation.(*interactionInvocation)
+	if !ok {
+		return ErrNotInteraction
+	}
+	return ii.respondOnce(resp)
+}
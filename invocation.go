@@ -0,0 +1,235 @@
+package disgomux
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// Invocation is what triggered a Context: a message or an interaction.
+	// Helpers, middleware, permissions and cooldowns go through it, so they
+	// work the same for both.
+	Invocation interface {
+		// ID returns the ID of the message or interaction
+		ID() string
+		Author() *discordgo.User
+
+		// Member returns the guild member of the author, which may be nil
+		// for messages or outside guilds
+		Member() *discordgo.Member
+		GuildID() string
+		ChannelID() string
+
+		// Respond sends a message in response: to the channel for messages,
+		// as the response or a followup for interactions
+		Respond(ms *discordgo.MessageSend) (*discordgo.Message, error)
+
+		// Edit replaces the content of a message sent with Respond
+		Edit(messageID, content string) (*discordgo.Message, error)
+
+		// Delete deletes a message sent with Respond
+		Delete(messageID string) error
+	}
+
+	// messageInvocation is an Invocation triggered by a message
+	messageInvocation struct {
+		session Session
+		message *discordgo.MessageCreate
+	}
+
+	// interactionInvocation is an Invocation triggered by an interaction. The
+	// first message sent is the response, later ones are followups. A
+	// deferred response is replaced by the first message sent, unless it is
+	// ephemeral.
+	interactionInvocation struct {
+		session     Session
+		interaction *discordgo.InteractionCreate
+		author      *discordgo.User
+
+		mu        sync.Mutex
+		responded bool
+		deferred  bool
+		original  string
+	}
+)
+
+func (i *messageInvocation) ID() string                { return i.message.ID }
+func (i *messageInvocation) Author() *discordgo.User   { return i.message.Author }
+func (i *messageInvocation) Member() *discordgo.Member { return i.message.Member }
+func (i *messageInvocation) GuildID() string           { return i.message.GuildID }
+func (i *messageInvocation) ChannelID() string         { return i.message.ChannelID }
+
+func (i *messageInvocation) Respond(
+	ms *discordgo.MessageSend,
+) (*discordgo.Message, error) {
+	return i.session.ChannelMessageSendComplex(i.message.ChannelID, ms)
+}
+
+func (i *messageInvocation) Edit(
+	messageID, content string,
+) (*discordgo.Message, error) {
+	return i.session.ChannelMessageEdit(i.message.ChannelID, messageID, content)
+}
+
+func (i *messageInvocation) Delete(messageID string) error {
+	return i.session.ChannelMessageDelete(i.message.ChannelID, messageID)
+}
+
+func (i *interactionInvocation) ID() string              { return i.interaction.ID }
+func (i *interactionInvocation) Author() *discordgo.User { return i.author }
+func (i *interactionInvocation) GuildID() string         { return i.interaction.GuildID }
+func (i *interactionInvocation) ChannelID() string       { return i.interaction.ChannelID }
+
+func (i *interactionInvocation) Member() *discordgo.Member {
+	return i.interaction.Member
+}
+
+func (i *interactionInvocation) Respond(
+	ms *discordgo.MessageSend,
+) (*discordgo.Message, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	interaction := i.interaction.Interaction
+
+	/*
+		Edits of a deferred response keep the visibility of the deferral,
+		so ephemeral messages are sent as a followup and the thinking
+		indicator is removed
+	*/
+	if i.deferred && ms.Flags&discordgo.MessageFlagsEphemeral != 0 {
+		msg, err := i.session.FollowupMessageCreate(
+			interaction, true, &discordgo.WebhookParams{
+				Content:         ms.Content,
+				Embeds:          ms.Embeds,
+				Files:           ms.Files,
+				Components:      ms.Components,
+				AllowedMentions: ms.AllowedMentions,
+				Flags:           ms.Flags,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		i.deferred = false
+		i.session.InteractionResponseDelete(interaction)
+		return msg, nil
+	}
+
+	if i.deferred {
+		msg, err := i.session.InteractionResponseEdit(
+			interaction, &discordgo.WebhookEdit{
+				Content:         &ms.Content,
+				Embeds:          &ms.Embeds,
+				Files:           ms.Files,
+				Components:      &ms.Components,
+				AllowedMentions: ms.AllowedMentions,
+			},
+		)
+		if err == nil {
+			i.deferred = false
+			i.original = msg.ID
+		}
+		return msg, err
+	}
+
+	if i.responded {
+		return i.session.FollowupMessageCreate(
+			interaction, true, &discordgo.WebhookParams{
+				Content:         ms.Content,
+				Embeds:          ms.Embeds,
+				Files:           ms.Files,
+				Components:      ms.Components,
+				AllowedMentions: ms.AllowedMentions,
+				Flags:           ms.Flags,
+			},
+		)
+	}
+
+	err := i.session.InteractionRespond(
+		interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content:         ms.Content,
+				Embeds:          ms.Embeds,
+				Files:           ms.Files,
+				Components:      ms.Components,
+				AllowedMentions: ms.AllowedMentions,
+				Flags:           ms.Flags,
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	i.responded = true
+
+	msg, err := i.session.InteractionResponse(interaction)
+	if err != nil {
+		return nil, err
+	}
+	i.original = msg.ID
+	return msg, nil
+}
+
+func (i *interactionInvocation) Edit(
+	messageID, content string,
+) (*discordgo.Message, error) {
+	edit := &discordgo.WebhookEdit{Content: &content}
+	if i.isOriginal(messageID) {
+		return i.session.InteractionResponseEdit(i.interaction.Interaction, edit)
+	}
+	return i.session.FollowupMessageEdit(i.interaction.Interaction, messageID, edit)
+}
+
+func (i *interactionInvocation) Delete(messageID string) error {
+	if i.isOriginal(messageID) {
+		return i.session.InteractionResponseDelete(i.interaction.Interaction)
+	}
+	return i.session.FollowupMessageDelete(i.interaction.Interaction, messageID)
+}
+
+// isOriginal reports whether the message is the response to the interaction
+func (i *interactionInvocation) isOriginal(messageID string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return messageID == i.original
+}
+
+// respondOnce sends resp as the response to the interaction, failing if it has
+// already been responded to
+func (i *interactionInvocation) respondOnce(
+	resp *discordgo.InteractionResponse,
+) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.responded {
+		return ErrAlreadyResponded
+	}
+
+	if err := i.session.InteractionRespond(i.interaction.Interaction, resp); err != nil {
+		return err
+	}
+
+	i.responded = true
+	return nil
+}
+
+// deferResponse acknowledges the interaction with a deferred response, which
+// the next message sent replaces
+func (i *interactionInvocation) deferResponse(
+	resp *discordgo.InteractionResponse,
+) error {
+	if err := i.respondOnce(resp); err != nil {
+		return err
+	}
+
+	i.mu.Lock()
+	i.deferred = resp.Type ==
+		discordgo.InteractionResponseDeferredChannelMessageWithSource
+	i.mu.Unlock()
+	return nil
+}
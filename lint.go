@@ -0,0 +1,236 @@
+package disgomux
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type (
+	// LintError is returned by Initialize() when the registered commands have
+	// problems which would otherwise only show at runtime, e.g. names which
+	// can never be typed. It lists every problem found.
+	LintError struct {
+		Problems []LintProblem
+	}
+
+	// LintProblem is a problem with a registered command
+	LintProblem struct {
+		Command, Problem string
+
+		// Err is the sentinel error of the problem, if any, e.g.
+		// ErrDuplicateCommand
+		Err error
+	}
+)
+
+func (e *LintError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = p.String()
+	}
+	return fmt.Sprintf(
+		"%d problems with registered commands:\n%s",
+		len(e.Problems), strings.Join(lines, "\n"),
+	)
+}
+
+// Is reports whether one of the problems has the target as its sentinel error
+func (e *LintError) Is(target error) bool {
+	for _, p := range e.Problems {
+		if p.Err != nil && p.Err == target {
+			return true
+		}
+	}
+	return false
+}
+
+func (p LintProblem) String() string {
+	if p.Command == "" {
+		return p.Problem
+	}
+	return fmt.Sprintf("%s: %s", p.Command, p.Problem)
+}
+
+// Lint checks the registered commands for empty, duplicate and untypable names,
+// aliases colliding with commands, simple commands or each other, missing help
+// texts and inconsistent argument specs. It returns a *LintError listing the
+// problems, or nil.
+func (m *Mux) Lint() error {
+	m.mu.RLock()
+	problems := append([]LintProblem(nil), m.registration...)
+	commands := make(map[string]Command, len(m.Commands))
+	for name, c := range m.Commands {
+		commands[name] = c
+	}
+	m.mu.RUnlock()
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	aliases := make(map[string]string)
+	for _, name := range names {
+		c := commands[name]
+		settings := c.Settings()
+		problem := func(format string, args ...interface{}) {
+			problems = append(problems, LintProblem{
+				Command: name,
+				Problem: fmt.Sprintf(format, args...),
+			})
+		}
+
+		if p := namingProblem(name); p != "" {
+			problem("name %s", p)
+		}
+		if strings.TrimSpace(settings.HelpText) == "" {
+			problem("no help text")
+		}
+		if _, ok := m.Simple(name); ok {
+			problem("shadows a simple command")
+		}
+
+		for _, a := range settings.Aliases {
+			switch {
+			case a == "":
+				problem("empty alias")
+			case namingProblem(a) != "":
+				problem("alias %s %s", a, namingProblem(a))
+			case commands[a] != nil:
+				problem("alias %s collides with a command", a)
+			case aliases[a] != "":
+				problem("alias %s is also an alias of %s", a, aliases[a])
+			default:
+				aliases[a] = name
+			}
+		}
+
+		for _, p := range argumentProblems(settings.Arguments) {
+			problem("%s", p)
+		}
+
+		if parent, ok := c.(ParentCommand); ok {
+			for _, p := range subcommandProblems(parent.Subcommands(), "") {
+				problem("%s", p)
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &LintError{Problems: problems}
+}
+
+// namingProblem describes why a command name can't be typed, or returns ""
+func namingProblem(name string) string {
+	switch {
+	case strings.IndexFunc(name, unicode.IsSpace) >= 0:
+		return "contains spaces, so it can never be typed"
+	case strings.ToLower(name) != name:
+		return "has uppercase letters, so it can never match a typed command"
+	}
+	return ""
+}
+
+// argumentProblems describes inconsistencies of an argument spec
+func argumentProblems(arguments []Argument) []string {
+	var problems []string
+	seen := make(map[string]bool, len(arguments))
+	optional := ""
+	for i, a := range arguments {
+		name := a.Name
+		if name == "" {
+			problems = append(problems,
+				fmt.Sprintf("argument %d has no name", i+1))
+			name = fmt.Sprint(i + 1)
+		}
+
+		if seen[strings.ToLower(name)] {
+			problems = append(problems,
+				fmt.Sprintf("argument %s specified more than once", name))
+		}
+		seen[strings.ToLower(name)] = true
+
+		if p := namingProblem(a.Name); a.Name != "" && p != "" {
+			problems = append(problems,
+				fmt.Sprintf("argument %s %s", name, p))
+		}
+
+		switch {
+		case a.Required && optional != "":
+			problems = append(problems, fmt.Sprintf(
+				"required argument %s follows optional argument %s",
+				name, optional,
+			))
+		case !a.Required && optional == "":
+			optional = name
+		}
+
+		switch a.Type {
+		case ArgumentBoolean, ArgumentUser, ArgumentChannel, ArgumentRole:
+			if len(a.Choices) > 0 {
+				problems = append(problems,
+					fmt.Sprintf("argument %s can't have choices", name))
+			}
+			if a.Min != nil || a.Max != nil {
+				problems = append(problems,
+					fmt.Sprintf("argument %s can't have bounds", name))
+			}
+		}
+		if a.Min != nil && a.Max != nil && *a.Min > *a.Max {
+			problems = append(problems,
+				fmt.Sprintf("argument %s has a minimum above its maximum", name))
+		}
+		if a.Autocomplete && len(a.Choices) > 0 {
+			problems = append(problems, fmt.Sprintf(
+				"argument %s has both choices and autocompletion", name,
+			))
+		}
+	}
+	return problems
+}
+
+// subcommandProblems describes problems with subcommands below the path
+func subcommandProblems(subs []*Subcommand, path string) []string {
+	var problems []string
+	seen := make(map[string]bool, len(subs))
+	for _, sub := range subs {
+		name := strings.TrimSpace(path + " " + sub.Name)
+		switch {
+		case sub.Name == "":
+			problems = append(problems,
+				fmt.Sprintf("subcommand of %q has no name", path))
+		case seen[strings.ToLower(sub.Name)]:
+			problems = append(problems,
+				fmt.Sprintf("subcommand %s specified more than once", name))
+		case namingProblem(sub.Name) != "":
+			problems = append(problems,
+				fmt.Sprintf("subcommand %s %s", name, namingProblem(sub.Name)))
+		}
+		seen[strings.ToLower(sub.Name)] = true
+
+		if strings.TrimSpace(sub.HelpText) == "" {
+			problems = append(problems,
+				fmt.Sprintf("subcommand %s has no help text", name))
+		}
+
+		if len(sub.Subcommands) > 0 {
+			problems = append(problems,
+				subcommandProblems(sub.Subcommands, name)...)
+			continue
+		}
+		if sub.Handler == nil {
+			problems = append(problems,
+				fmt.Sprintf("subcommand %s has no handler", name))
+		}
+		for _, p := range argumentProblems(sub.Arguments) {
+			problems = append(problems,
+				fmt.Sprintf("subcommand %s: %s", name, p))
+		}
+	}
+	return problems
+}
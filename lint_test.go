@@ -0,0 +1,163 @@
+package disgomux
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// lintCommand is a command with settings to lint
+type lintCommand struct {
+	settings CommandSettings
+	subs     []*Subcommand
+}
+
+func (c *lintCommand) Init(m *Mux)                      {}
+func (c *lintCommand) Handle(ctx *Context)              {}
+func (c *lintCommand) HandleHelp(ctx *Context) bool     { return false }
+func (c *lintCommand) Settings() *CommandSettings       { return &c.settings }
+func (c *lintCommand) Permissions() *CommandPermissions { return nil }
+func (c *lintCommand) Subcommands() []*Subcommand       { return c.subs }
+
+func TestNamingProblem(t *testing.T) {
+	for name, bad := range map[string]bool{
+		"ping":      false,
+		"set-role":  false,
+		"été":       false,
+		"two words": true,
+		"tab\tname": true,
+		"Ping":      true,
+		"ÉTÉ":       true,
+	} {
+		if p := namingProblem(name); (p != "") != bad {
+			t.Errorf("namingProblem(%q) = %q", name, p)
+		}
+	}
+}
+
+func TestArgumentProblems(t *testing.T) {
+	one, two := 1.0, 2.0
+	tests := []struct {
+		arguments []Argument
+		want      string
+	}{
+		{[]Argument{{Name: "user", Type: ArgumentUser, Required: true}}, ""},
+		{[]Argument{{Name: "a", Required: true}, {Name: "b"}, {Name: "c"}}, ""},
+		{[]Argument{{Type: ArgumentString}}, "argument 1 has no name"},
+		{[]Argument{{Name: "a"}, {Name: "a"}},
+			"argument a specified more than once"},
+		{[]Argument{{Name: "Big"}}, "argument Big has uppercase letters"},
+		{[]Argument{{Name: "a"}, {Name: "b", Required: true}},
+			"required argument b follows optional argument a"},
+		{[]Argument{{Name: "on", Type: ArgumentBoolean,
+			Choices: []Choice{{Name: "yes", Value: "yes"}}}},
+			"argument on can't have choices"},
+		{[]Argument{{Name: "who", Type: ArgumentUser, Min: &one}},
+			"argument who can't have bounds"},
+		{[]Argument{{Name: "n", Type: ArgumentInteger, Min: &two, Max: &one}},
+			"argument n has a minimum above its maximum"},
+		{[]Argument{{Name: "q", Autocomplete: true,
+			Choices: []Choice{{Name: "x", Value: "x"}}}},
+			"argument q has both choices and autocompletion"},
+	}
+
+	for _, tt := range tests {
+		problems := argumentProblems(tt.arguments)
+		if tt.want == "" {
+			if len(problems) != 0 {
+				t.Errorf("%+v: unexpected problems %q", tt.arguments, problems)
+			}
+			continue
+		}
+		if len(problems) != 1 || !strings.HasPrefix(problems[0], tt.want) {
+			t.Errorf("%+v: problems %q, want %q", tt.arguments, problems, tt.want)
+		}
+	}
+}
+
+func TestSubcommandProblems(t *testing.T) {
+	handler := func(ctx *Context) {}
+	subs := []*Subcommand{
+		{Name: "get", HelpText: "Get", Handler: handler},
+		{Name: "GET", HelpText: "Get again", Handler: handler},
+		{Name: "", HelpText: "Nameless", Handler: handler},
+		{Name: "set", Handler: handler},
+		{Name: "noop", HelpText: "No handler"},
+		{Name: "role", HelpText: "Roles", Subcommands: []*Subcommand{
+			{Name: "add", HelpText: "Add", Handler: handler,
+				Arguments: []Argument{{Name: "a"}, {Name: "b", Required: true}}},
+		}},
+	}
+
+	want := []string{
+		"subcommand GET specified more than once",
+		`subcommand of "" has no name`,
+		"subcommand set has no help text",
+		"subcommand noop has no handler",
+		"subcommand role add: required argument b follows optional argument a",
+	}
+	got := subcommandProblems(subs, "")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems\n%s\nwant\n%s",
+			strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLint(t *testing.T) {
+	m := newMux()
+	m.Register(
+		&lintCommand{settings: CommandSettings{
+			Command: "ping", HelpText: "Ping", Aliases: []string{"p", "echo"},
+		}},
+		&lintCommand{settings: CommandSettings{
+			Command: "echo", Aliases: []string{"p", "Say", ""},
+		}},
+		&lintCommand{
+			settings: CommandSettings{Command: "config", HelpText: "Config"},
+			subs:     []*Subcommand{{Name: "get", HelpText: "Get"}},
+		},
+	)
+
+	err := m.Lint()
+	var lint *LintError
+	if !errors.As(err, &lint) {
+		t.Fatalf("Lint() = %v, want a *LintError", err)
+	}
+
+	want := []string{
+		"config: subcommand get has no handler",
+		"echo: no help text",
+		"echo: alias Say has uppercase letters, so it can never match a " +
+			"typed command",
+		"echo: empty alias",
+		"ping: alias p is also an alias of echo",
+		"ping: alias echo collides with a command",
+	}
+	got := make([]string, len(lint.Problems))
+	for i, p := range lint.Problems {
+		got[i] = p.String()
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems\n%s\nwant\n%s",
+			strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if !strings.HasPrefix(err.Error(), "6 problems with registered commands") {
+		t.Errorf("error %q", err)
+	}
+}
+
+func TestLintErrorIs(t *testing.T) {
+	err := &LintError{Problems: []LintProblem{
+		{Command: "ping", Problem: "no help text"},
+		{Command: "pong", Problem: "registered twice", Err: ErrDuplicateCommand},
+	}}
+	if !errors.Is(err, ErrDuplicateCommand) {
+		t.Error("LintError is not ErrDuplicateCommand")
+	}
+	if errors.Is(err, ErrNotFound) {
+		t.Error("LintError is ErrNotFound")
+	}
+	if (&LintError{Problems: []LintProblem{{Problem: "x"}}}).Is(nil) {
+		t.Error("LintError without sentinels is nil")
+	}
+}
@@ -0,0 +1,244 @@
+package disgomux
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type (
+	// muxConfig is the representation of a mux within a configuration file
+	muxConfig struct {
+		Prefix         string                `json:"prefix" yaml:"prefix"`
+		Owners         []string              `json:"owners" yaml:"owners"`
+		Options        optionsConfig         `json:"options" yaml:"options"`
+		ErrorTexts     errorTextsConfig      `json:"error_texts" yaml:"error_texts"`
+		SimpleCommands []simpleCommandConfig `json:"simple_commands" yaml:"simple_commands"`
+	}
+
+	// optionsConfig holds the options set in a configuration file. Options
+	// left out keep their defaults.
+	optionsConfig struct {
+		IgnoreBots        *bool `json:"ignore_bots" yaml:"ignore_bots"`
+		IgnoreDMs         *bool `json:"ignore_dms" yaml:"ignore_dms"`
+		IgnoreEmpty       *bool `json:"ignore_empty" yaml:"ignore_empty"`
+		IgnoreNonDefault  *bool `json:"ignore_non_default" yaml:"ignore_non_default"`
+		IgnoreUnknown     *bool `json:"ignore_unknown" yaml:"ignore_unknown"`
+		IgnoreThreads     *bool `json:"ignore_threads" yaml:"ignore_threads"`
+		Synchronous       *bool `json:"synchronous" yaml:"synchronous"`
+		SerializeChannels *bool `json:"serialize_channels" yaml:"serialize_channels"`
+		Fuzzy             bool  `json:"fuzzy" yaml:"fuzzy"`
+	}
+
+	// errorTextsConfig holds the error texts set in a configuration file
+	errorTextsConfig struct {
+		CommandNotFound  string `json:"command_not_found" yaml:"command_not_found"`
+		NoPermissions    string `json:"no_permissions" yaml:"no_permissions"`
+		Cooldown         string `json:"cooldown" yaml:"cooldown"`
+		HandlerError     string `json:"handler_error" yaml:"handler_error"`
+		Busy             string `json:"busy" yaml:"busy"`
+		InvalidArguments string `json:"invalid_arguments" yaml:"invalid_arguments"`
+		QuotaExceeded    string `json:"quota_exceeded" yaml:"quota_exceeded"`
+	}
+)
+
+// envPrefix prefixes the environment variables read by LoadMux
+const envPrefix = "DISGOMUX"
+
+// LoadMux creates a mux configured by the JSON or YAML file at path (by its
+// extension), then by the DISGOMUX_ environment variables (see EnvOptions),
+// which take precedence. Further options are applied last. The file holds the
+// prefix, owners, options, error texts and simple commands:
+//
+//	prefix: "!"
+//	owners: ["1234"]
+//	options:
+//	  ignore_dms: false
+//	  fuzzy: true
+//	error_texts:
+//	  command_not_found: "No such command, try !help"
+//	simple_commands:
+//	  - name: ping
+//	    content: pong
+func LoadMux(path string, options ...Option) (*Mux, error) {
+	format := FormatJSON
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		format = FormatYAML
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fileOptions, err := ConfigOptions(f, format)
+	if err != nil {
+		return nil, err
+	}
+
+	envOptions, err := EnvOptions(envPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	all := append(fileOptions, envOptions...)
+	return NewMux(append(all, options...)...)
+}
+
+// ConfigOptions reads a configuration file in the format described by
+// LoadMux, returning the options it sets
+func ConfigOptions(r io.Reader, format Format) ([]Option, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var c muxConfig
+	if err := decode(data, format, &c); err != nil {
+		return nil, err
+	}
+
+	var options []Option
+	if c.Prefix != "" {
+		options = append(options, WithPrefix(c.Prefix))
+	}
+	if len(c.Owners) > 0 {
+		options = append(options, WithOwners(c.Owners...))
+	}
+	if c.Options.Fuzzy {
+		options = append(options, WithFuzzy())
+	}
+
+	for _, o := range []struct {
+		value  *bool
+		option func(bool) Option
+	}{
+		{c.Options.IgnoreBots, IgnoreBots},
+		{c.Options.IgnoreDMs, IgnoreDMs},
+		{c.Options.IgnoreEmpty, IgnoreEmpty},
+		{c.Options.IgnoreNonDefault, IgnoreNonDefault},
+		{c.Options.IgnoreUnknown, IgnoreUnknown},
+		{c.Options.IgnoreThreads, IgnoreThreads},
+		{c.Options.Synchronous, Synchronous},
+		{c.Options.SerializeChannels, SerializeChannels},
+	} {
+		if o.value != nil {
+			options = append(options, o.option(*o.value))
+		}
+	}
+
+	options = append(options, WithErrorTexts(ErrorTexts{
+		CommandNotFound:  c.ErrorTexts.CommandNotFound,
+		NoPermissions:    c.ErrorTexts.NoPermissions,
+		Cooldown:         c.ErrorTexts.Cooldown,
+		HandlerError:     c.ErrorTexts.HandlerError,
+		Busy:             c.ErrorTexts.Busy,
+		InvalidArguments: c.ErrorTexts.InvalidArguments,
+		QuotaExceeded:    c.ErrorTexts.QuotaExceeded,
+	}))
+
+	simple, err := simpleCommands(c.SimpleCommands)
+	if err != nil {
+		return nil, err
+	}
+	if len(simple) > 0 {
+		options = append(options, WithSimpleCommands(simple...))
+	}
+	return options, nil
+}
+
+// EnvOptions returns the options set by environment variables named with the
+// prefix, e.g. for "BOT": BOT_PREFIX, BOT_OWNERS (comma-separated IDs),
+// BOT_FUZZY, BOT_IGNORE_BOTS, BOT_IGNORE_DMS, BOT_IGNORE_EMPTY,
+// BOT_IGNORE_NON_DEFAULT, BOT_IGNORE_UNKNOWN, BOT_IGNORE_THREADS,
+// BOT_SYNCHRONOUS and BOT_SERIALIZE_CHANNELS (booleans), and the error texts
+// BOT_ERROR_COMMAND_NOT_FOUND, BOT_ERROR_NO_PERMISSIONS, BOT_ERROR_COOLDOWN,
+// BOT_ERROR_HANDLER_ERROR, BOT_ERROR_BUSY and BOT_ERROR_INVALID_ARGUMENTS.
+// Unset variables are left out.
+func EnvOptions(prefix string) ([]Option, error) {
+	env := func(name string) (string, bool) {
+		return os.LookupEnv(prefix + "_" + name)
+	}
+
+	var options []Option
+	if v, ok := env("PREFIX"); ok {
+		options = append(options, WithPrefix(v))
+	}
+	if v, ok := env("OWNERS"); ok {
+		var owners []string
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				owners = append(owners, id)
+			}
+		}
+		options = append(options, WithOwners(owners...))
+	}
+
+	for _, o := range []struct {
+		name   string
+		option func(bool) Option
+	}{
+		{"FUZZY", func(on bool) Option {
+			if !on {
+				return func(*Mux) error { return nil }
+			}
+			return WithFuzzy()
+		}},
+		{"IGNORE_BOTS", IgnoreBots},
+		{"IGNORE_DMS", IgnoreDMs},
+		{"IGNORE_EMPTY", IgnoreEmpty},
+		{"IGNORE_NON_DEFAULT", IgnoreNonDefault},
+		{"IGNORE_UNKNOWN", IgnoreUnknown},
+		{"IGNORE_THREADS", IgnoreThreads},
+		{"SYNCHRONOUS", Synchronous},
+		{"SERIALIZE_CHANNELS", SerializeChannels},
+	} {
+		v, ok := env(o.name)
+		if !ok {
+			continue
+		}
+
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, &OptionError{
+				Option: prefix + "_" + o.name,
+				Reason: "not a boolean",
+			}
+		}
+		options = append(options, o.option(b))
+	}
+
+	var texts ErrorTexts
+	for _, t := range []struct {
+		name string
+		text *string
+	}{
+		{"ERROR_COMMAND_NOT_FOUND", &texts.CommandNotFound},
+		{"ERROR_NO_PERMISSIONS", &texts.NoPermissions},
+		{"ERROR_COOLDOWN", &texts.Cooldown},
+		{"ERROR_HANDLER_ERROR", &texts.HandlerError},
+		{"ERROR_BUSY", &texts.Busy},
+		{"ERROR_INVALID_ARGUMENTS", &texts.InvalidArguments},
+		{"ERROR_QUOTA_EXCEEDED", &texts.QuotaExceeded},
+	} {
+		if v, ok := env(t.name); ok {
+			*t.text = v
+		}
+	}
+	if texts != (ErrorTexts{}) {
+		options = append(options, WithErrorTexts(texts))
+	}
+	return options, nil
+}
+
+// WithSimpleCommands registers simple commands, as RegisterSimple()
+func WithSimpleCommands(commands ...SimpleCommand) Option {
+	return func(m *Mux) error {
+		return m.RegisterSimple(commands...)
+	}
+}
@@ -0,0 +1,82 @@
+package disgomux
+
+// defaultLocale is the locale of invocations without one
+const defaultLocale = "en-US"
+
+// UserLocaleStore is a ConfigStore which also keeps a locale per user. The
+// MemoryConfigStore is one.
+type UserLocaleStore interface {
+	ConfigStore
+	UserLocale(userID string) (string, bool, error)
+	SetUserLocale(userID, locale string) error
+}
+
+// UserLocale returns the locale of the user
+func (s *MemoryConfigStore) UserLocale(userID string) (string, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	l, ok := s.users[userID]
+	return l, ok, nil
+}
+
+// SetUserLocale sets the locale of the user. An empty locale removes it.
+func (s *MemoryConfigStore) SetUserLocale(userID, locale string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if locale == "" {
+		delete(s.users, userID)
+		return nil
+	}
+	s.users[userID] = locale
+	return nil
+}
+
+// SetDefaultLocale sets the locale of invocations without one. Defaults to
+// "en-US".
+func (m *Mux) SetDefaultLocale(locale string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.locale = locale
+}
+
+// Locale returns the locale of the invocation, e.g. "en-US", to localize its
+// responses. It is the first set of: the locale of the user in the config
+// store (if it is a UserLocaleStore), the locale of the Discord client for
+// interactions, the locale of the guild configuration, the locale of the guild
+// for interactions, and the default locale of the mux.
+func (ctx *Context) Locale() string {
+	if ctx.mux == nil {
+		return defaultLocale
+	}
+
+	if store, ok := ctx.mux.configs.(UserLocaleStore); ok {
+		l, ok, err := store.UserLocale(ctx.Message.Author.ID)
+		if err != nil {
+			ctx.mux.reportCtx(ctx, err)
+		}
+		if ok && l != "" {
+			return l
+		}
+	}
+
+	if i := ctx.Interaction; i != nil && i.Locale != "" {
+		return string(i.Locale)
+	}
+	if ctx.config.Locale != "" {
+		return ctx.config.Locale
+	}
+	if i := ctx.Interaction; i != nil && i.GuildLocale != nil {
+		return string(*i.GuildLocale)
+	}
+
+	ctx.mux.mu.RLock()
+	defer ctx.mux.mu.RUnlock()
+
+	if ctx.mux.locale != "" {
+		return ctx.mux.locale
+	}
+	return defaultLocale
+}
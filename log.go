@@ -0,0 +1,73 @@
+package disgomux
+
+// LogLevel is the severity of a log entry
+type LogLevel int
+
+// Log levels, from least to most severe
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "debug"
+	case LogInfo:
+		return "info"
+	case LogWarn:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+type (
+	// Logger receives the structured log entries of the mux: routing
+	// decisions, refused invocations, failed sends and so on. fields describe
+	// the invocation (guild, channel, user, command) where there is one.
+	// Implementations must be safe for concurrent use.
+	Logger interface {
+		Log(level LogLevel, msg string, fields map[string]string)
+	}
+
+	// LoggerFunc adapts a function to a Logger, e.g. to forward entries to
+	// logrus:
+	//
+	//	mux.SetLogger(disgomux.LoggerFunc(
+	//		func(level disgomux.LogLevel, msg string, fields map[string]string) {
+	//			entry := logrus.WithField("level", level.String())
+	//			for k, v := range fields {
+	//				entry = entry.WithField(k, v)
+	//			}
+	//			entry.Info(msg)
+	//		},
+	//	))
+	LoggerFunc func(level LogLevel, msg string, fields map[string]string)
+)
+
+// Log calls f(level, msg, fields)
+func (f LoggerFunc) Log(level LogLevel, msg string, fields map[string]string) {
+	f(level, msg, fields)
+}
+
+// SetLogger sets the logger the mux writes to. The mux is silent without one.
+func (m *Mux) SetLogger(logger Logger) {
+	m.logger = logger
+}
+
+// log writes an entry to the logger, if one is set
+func (m *Mux) log(level LogLevel, msg string, fields map[string]string) {
+	if m.logger != nil {
+		m.logger.Log(level, msg, fields)
+	}
+}
+
+// logCtx writes an entry with the fields of the invocation
+func (m *Mux) logCtx(ctx *Context, level LogLevel, msg string) {
+	if m.logger != nil {
+		m.logger.Log(level, msg, ctx.fields())
+	}
+}
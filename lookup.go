@@ -0,0 +1,94 @@
+package disgomux
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// lookups caches the guild, channel and member an invocation refers to
+type lookups struct {
+	mu      sync.Mutex
+	guild   *discordgo.Guild
+	channel *discordgo.Channel
+	member  *discordgo.Member
+}
+
+// Guild returns the guild the invoking message was sent in, resolved from the
+// session state or else fetched from Discord. The result is cached for the
+// lifetime of the Context.
+func (ctx *Context) Guild() (*discordgo.Guild, error) {
+	ctx.lookups.mu.Lock()
+	defer ctx.lookups.mu.Unlock()
+
+	if ctx.lookups.guild != nil {
+		return ctx.lookups.guild, nil
+	}
+
+	guild, err := ctx.Session.State.Guild(ctx.Message.GuildID)
+	if err != nil {
+		if guild, err = ctx.API().Guild(ctx.Message.GuildID); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx.lookups.guild = guild
+	return guild, nil
+}
+
+// Channel returns the channel the invoking message was sent in, resolved from
+// the session state or else fetched from Discord. The result is cached for the
+// lifetime of the Context.
+func (ctx *Context) Channel() (*discordgo.Channel, error) {
+	ctx.lookups.mu.Lock()
+	defer ctx.lookups.mu.Unlock()
+
+	if ctx.lookups.channel != nil {
+		return ctx.lookups.channel, nil
+	}
+
+	channel, err := lookupChannel(
+		ctx.Session.State, ctx.API(), ctx.Message.ChannelID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.lookups.channel = channel
+	return channel, nil
+}
+
+// lookupChannel resolves a channel from the state, or else fetches it
+func lookupChannel(
+	state *discordgo.State,
+	api Session,
+	channelID string,
+) (*discordgo.Channel, error) {
+	if channel, err := state.Channel(channelID); err == nil {
+		return channel, nil
+	}
+	return api.Channel(channelID)
+}
+
+// Member returns the guild member who sent the invoking message, resolved from
+// the session state or else fetched from Discord. The result is cached for the
+// lifetime of the Context.
+func (ctx *Context) Member() (*discordgo.Member, error) {
+	ctx.lookups.mu.Lock()
+	defer ctx.lookups.mu.Unlock()
+
+	if ctx.lookups.member != nil {
+		return ctx.lookups.member, nil
+	}
+
+	guildID, userID := ctx.Message.GuildID, ctx.Message.Author.ID
+	member, err := ctx.Session.State.Member(guildID, userID)
+	if err != nil {
+		if member, err = ctx.API().GuildMember(guildID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx.lookups.member = member
+	return member, nil
+}
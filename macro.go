@@ -0,0 +1,131 @@
+package disgomux
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// macroPlaceholder matches the placeholders of a macro: {1}, {2}... for single
+// arguments and {*} for all of them
+var macroPlaceholder = regexp.MustCompile(`\{([1-9][0-9]*|\*)\}`)
+
+// SetMacro defines a macro in the guild, kept in its configuration: invoking
+// name runs expansion, a command without the prefix whose placeholders are
+// replaced with the arguments of the invocation. {1} is the first argument,
+// {2} the second and so on, and {*} all of them, e.g.
+//
+//	mux.SetMacro(guildID, "mute7d", "mute {1} 7d spam")
+//
+// makes "!mute7d @user" run "!mute @user 7d spam". The expanded command goes
+// through the usual routing, so the invoker needs its permissions. A macro
+// can't take the name of a command, nor expand to another macro.
+func (m *Mux) SetMacro(guildID, name, expansion string) error {
+	name = strings.ToLower(name)
+	if name == "" || strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("Invalid macro name %q", name)
+	}
+	if m.isCommandName(guildID, name) {
+		return fmt.Errorf("%w: %s is already a command", ErrDuplicateCommand, name)
+	}
+
+	target, _ := splitCommand(strings.TrimSpace(expansion))
+	if !m.isCommandName(guildID, strings.ToLower(target)) {
+		return fmt.Errorf("%w: macro %s expands to %q", ErrNotFound, name, target)
+	}
+
+	_, err := m.UpdateGuildConfig(guildID, func(c *GuildConfig) {
+		if c.Macros == nil {
+			c.Macros = make(map[string]string)
+		}
+		c.Macros[name] = strings.TrimSpace(expansion)
+	})
+	return err
+}
+
+// RemoveMacro deletes a macro of the guild
+func (m *Mux) RemoveMacro(guildID, name string) error {
+	_, err := m.UpdateGuildConfig(guildID, func(c *GuildConfig) {
+		delete(c.Macros, strings.ToLower(name))
+	})
+	return err
+}
+
+// Macros returns the names of the macros of the guild, sorted
+func (m *Mux) Macros(guildID string) []string {
+	macros := m.guildConfig(guildID).Macros
+	names := make([]string, 0, len(macros))
+	for name := range macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// macro returns the expansion of the macro of the guild, unless a command has
+// taken its name since it was defined
+func (m *Mux) macro(config GuildConfig, name string) (string, bool) {
+	expansion, ok := config.Macros[name]
+	if !ok || m.isCommandName(config.GuildID, name) {
+		return "", false
+	}
+	return expansion, true
+}
+
+// isCommandName reports whether a command or simple command of the guild goes
+// by the name
+func (m *Mux) isCommandName(guildID, name string) bool {
+	if _, ok := m.command(name); ok {
+		return true
+	}
+	_, ok := m.resolveSimple(guildID, name)
+	return ok
+}
+
+// expandMacro replaces the placeholders of the expansion with the arguments.
+// A placeholder without an argument fails with an *ArgumentError.
+func expandMacro(expansion string, args []string) (string, error) {
+	var missing string
+	expanded := macroPlaceholder.ReplaceAllStringFunc(
+		expansion,
+		func(placeholder string) string {
+			index := placeholder[1 : len(placeholder)-1]
+			if index == "*" {
+				return strings.Join(args, " ")
+			}
+
+			i, _ := strconv.Atoi(index)
+			if i > len(args) {
+				if missing == "" {
+					missing = index
+				}
+				return ""
+			}
+			return args[i-1]
+		},
+	)
+	if missing != "" {
+		return "", &ArgumentError{missing, "is required"}
+	}
+	return expanded, nil
+}
+
+// macroSpec returns the arguments a macro takes, named after the numbers of
+// its placeholders, for its usage
+func macroSpec(expansion string) []Argument {
+	var count int
+	for _, match := range macroPlaceholder.FindAllStringSubmatch(expansion, -1) {
+		if i, err := strconv.Atoi(match[1]); err == nil && i > count {
+			count = i
+		}
+	}
+
+	spec := make([]Argument, count)
+	for i := range spec {
+		spec[i] = Argument{Name: strconv.Itoa(i + 1), Required: true}
+	}
+	return spec
+}
@@ -0,0 +1,64 @@
+package disgomux
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExpandMacro(t *testing.T) {
+	tests := []struct {
+		expansion string
+		args      []string
+		want      string
+	}{
+		{"ping", nil, "ping"},
+		{"mute {1} 7d spam", []string{"@user"}, "mute @user 7d spam"},
+		{"say {2} {1}", []string{"world", "hello"}, "say hello world"},
+		{"say {*}", []string{"a", "b", "c"}, "say a b c"},
+		{"say {*}", nil, "say "},
+		{"say {1} {1}", []string{"twice"}, "say twice twice"},
+		{"say {1}", []string{"one", "extra"}, "say one"},
+		{"say {10}", []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"},
+			"say 10"},
+
+		/* Placeholders in arguments are not expanded again */
+		{"say {1} {2}", []string{"{2}", "two"}, "say {2} two"},
+		{"say {*}", []string{"{1}"}, "say {1}"},
+
+		/* Only numbers from 1 and * are placeholders */
+		{"say {0} {x} {}", nil, "say {0} {x} {}"},
+	}
+
+	for _, tt := range tests {
+		got, err := expandMacro(tt.expansion, tt.args)
+		if err != nil {
+			t.Errorf("expandMacro(%q, %q): %v", tt.expansion, tt.args, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("expandMacro(%q, %q) = %q, want %q",
+				tt.expansion, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestExpandMacroMissingArgument(t *testing.T) {
+	_, err := expandMacro("ban {1} {3}", []string{"@user"})
+
+	var argErr *ArgumentError
+	if !errors.As(err, &argErr) || argErr.Argument != "3" {
+		t.Errorf("returned %v, want the third argument missing", err)
+	}
+}
+
+func TestMacroSpec(t *testing.T) {
+	spec := macroSpec("ban {2} {*} {1} {2}")
+	if len(spec) != 2 || spec[0].Name != "1" || spec[1].Name != "2" {
+		t.Fatalf("spec %+v, want arguments 1 and 2", spec)
+	}
+	for _, a := range spec {
+		if !a.Required {
+			t.Errorf("argument %s is optional", a.Name)
+		}
+	}
+}
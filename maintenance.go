@@ -0,0 +1,43 @@
+package disgomux
+
+// maintenance is the maintenance mode of a mux
+type maintenance struct {
+	on      bool
+	message string
+}
+
+// SetMaintenance turns maintenance mode on or off. In maintenance mode every
+// command but the owner-only ones (see CommandSettings.OwnerOnly) is answered
+// with the message instead of running, or with a default notice if it is
+// empty, so the bot can be deployed or debugged without half-working.
+func (m *Mux) SetMaintenance(on bool, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.maintenance = maintenance{on: on, message: message}
+}
+
+// Maintenance reports whether the mux is in maintenance mode, and the message
+// it was set with
+func (m *Mux) Maintenance() (bool, string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.maintenance.on, m.maintenance.message
+}
+
+// maintenanceNotice returns the text invocations are answered with, and
+// whether the mux is in maintenance mode
+func (m *Mux) maintenanceNotice(ctx *Context) (string, bool) {
+	on, message := m.Maintenance()
+	if !on {
+		return "", false
+	}
+	if message != "" {
+		return message, true
+	}
+	return m.localize(
+		ctx, MessageMaintenance,
+		"I'm down for maintenance, try again in a little while.",
+	), true
+}
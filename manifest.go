@@ -0,0 +1,335 @@
+package disgomux
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type (
+	// Manifest is the command catalog of a mux, as exported by
+	// ExportManifest() for documentation
+	Manifest struct {
+		Prefix         string            `json:"prefix" yaml:"prefix"`
+		Commands       []ManifestCommand `json:"commands" yaml:"commands"`
+		SimpleCommands []ManifestCommand `json:"simple_commands,omitempty" yaml:"simple_commands,omitempty"`
+	}
+
+	// ManifestCommand documents a command, subcommand or simple command
+	ManifestCommand struct {
+		Name        string               `json:"name" yaml:"name"`
+		Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+		Category    string               `json:"category,omitempty" yaml:"category,omitempty"`
+		Aliases     []string             `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+		Usage       string               `json:"usage,omitempty" yaml:"usage,omitempty"`
+		Examples    []string             `json:"examples,omitempty" yaml:"examples,omitempty"`
+		Permissions *ManifestPermissions `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+		Cooldown    *ManifestCooldown    `json:"cooldown,omitempty" yaml:"cooldown,omitempty"`
+		Subcommands []ManifestCommand    `json:"subcommands,omitempty" yaml:"subcommands,omitempty"`
+	}
+
+	// ManifestPermissions documents who may run a command
+	ManifestPermissions struct {
+		Users       []string `json:"users,omitempty" yaml:"users,omitempty"`
+		Roles       []string `json:"roles,omitempty" yaml:"roles,omitempty"`
+		Channels    []string `json:"channels,omitempty" yaml:"channels,omitempty"`
+		Permissions int64    `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+	}
+
+	// ManifestCooldown documents the cooldown of a command
+	ManifestCooldown struct {
+		Duration string `json:"duration" yaml:"duration"`
+		Scope    string `json:"scope" yaml:"scope"`
+	}
+)
+
+// uncategorized is the heading of commands without a category in Markdown
+const uncategorized = "Other"
+
+// cooldownScopes names the cooldown scopes in manifests
+var cooldownScopes = map[CooldownScope]string{
+	CooldownUser:    "user",
+	CooldownChannel: "channel",
+	CooldownGuild:   "guild",
+	CooldownGlobal:  "global",
+}
+
+// Manifest returns the command catalog of the mux: its commands with their
+// subcommands, and its global simple commands, sorted by name
+func (m *Mux) Manifest() Manifest {
+	d := m.Describe()
+	manifest := Manifest{Prefix: d.Prefix}
+
+	for _, c := range d.Commands {
+		entry := ManifestCommand{
+			Name:        c.Name,
+			Description: c.HelpText,
+			Category:    c.Category,
+			Aliases:     c.Aliases,
+			Usage:       c.Usage,
+			Examples:    prefixed(d.Prefix, c.Examples),
+			Permissions: manifestPermissions(c.Permissions),
+			Cooldown:    manifestCooldown(c.Cooldown),
+		}
+
+		if parent, ok := m.parent(c.Name); ok {
+			entry.Subcommands = manifestSubcommands(
+				d.Prefix, c.Name, parent.Subcommands(),
+			)
+		}
+		manifest.Commands = append(manifest.Commands, entry)
+	}
+
+	for _, c := range d.SimpleCommands {
+		if c.GuildID != "" {
+			continue
+		}
+
+		manifest.SimpleCommands = append(manifest.SimpleCommands, ManifestCommand{
+			Name:        c.Name,
+			Description: c.HelpText,
+			Aliases:     c.Aliases,
+			Usage:       d.Prefix + c.Name,
+			Permissions: manifestPermissions(c.Permissions),
+			Cooldown:    manifestCooldown(c.Cooldown),
+		})
+	}
+	return manifest
+}
+
+// ExportManifest writes the command catalog of the mux as JSON, YAML or
+// Markdown, e.g. to generate the command documentation of a bot. Markdown
+// groups the commands by category.
+func (m *Mux) ExportManifest(w io.Writer, format Format) error {
+	manifest := m.Manifest()
+
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(manifest)
+	case FormatYAML:
+		data, err := yaml.Marshal(manifest)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case FormatMarkdown:
+		_, err := io.WriteString(w, manifest.markdown())
+		return err
+	default:
+		return fmt.Errorf("Unknown manifest format %d", format)
+	}
+}
+
+// parent looks up a registered command with subcommands
+func (m *Mux) parent(name string) (ParentCommand, bool) {
+	c, ok := m.command(name)
+	if !ok {
+		return nil, false
+	}
+
+	parent, ok := c.(ParentCommand)
+	return parent, ok
+}
+
+// manifestSubcommands documents the subcommands below the path
+func manifestSubcommands(
+	prefix, path string,
+	subs []*Subcommand,
+) []ManifestCommand {
+	entries := make([]ManifestCommand, 0, len(subs))
+	for _, sub := range subs {
+		name := path + " " + sub.Name
+		entry := ManifestCommand{
+			Name:        sub.Name,
+			Description: sub.HelpText,
+			Permissions: manifestPermissions(sub.Permissions),
+		}
+
+		if len(sub.Subcommands) > 0 {
+			entry.Subcommands = manifestSubcommands(prefix, name, sub.Subcommands)
+		} else {
+			entry.Usage = usage(prefix, name, sub.Arguments)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// manifestPermissions documents command permissions, or returns nil for none
+func manifestPermissions(p *CommandPermissions) *ManifestPermissions {
+	if p == nil || (len(p.UserIDs) == 0 && len(p.RoleIDs) == 0 &&
+		len(p.ChanIDs) == 0 && p.Permissions == 0) {
+		return nil
+	}
+
+	return &ManifestPermissions{
+		Users:       p.UserIDs,
+		Roles:       p.RoleIDs,
+		Channels:    p.ChanIDs,
+		Permissions: p.Permissions,
+	}
+}
+
+// manifestCooldown documents a cooldown, or returns nil for none
+func manifestCooldown(c *Cooldown) *ManifestCooldown {
+	if c == nil || c.Duration <= 0 {
+		return nil
+	}
+
+	return &ManifestCooldown{
+		Duration: c.Duration.String(),
+		Scope:    cooldownScopes[c.Scope],
+	}
+}
+
+// prefixed prepends the prefix to each invocation
+func prefixed(prefix string, invocations []string) []string {
+	if len(invocations) == 0 {
+		return nil
+	}
+
+	out := make([]string, len(invocations))
+	for i, inv := range invocations {
+		out[i] = prefix + inv
+	}
+	return out
+}
+
+// markdown renders the manifest as a Markdown document
+func (manifest Manifest) markdown() string {
+	var sb strings.Builder
+	sb.WriteString("# Commands\n\n")
+	if manifest.Prefix != "" {
+		fmt.Fprintf(&sb, "Prefix: `%s`\n\n", manifest.Prefix)
+	}
+
+	categories := make(map[string][]ManifestCommand)
+	for _, c := range manifest.Commands {
+		category := c.Category
+		if category == "" {
+			category = uncategorized
+		}
+		categories[category] = append(categories[category], c)
+	}
+
+	names := make([]string, 0, len(categories))
+	for name := range categories {
+		if name != uncategorized {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := categories[uncategorized]; ok {
+		names = append(names, uncategorized)
+	}
+
+	for _, name := range names {
+		if len(names) > 1 {
+			fmt.Fprintf(&sb, "## %s\n\n", name)
+		}
+		for _, c := range categories[name] {
+			c.markdown(&sb, manifest.Prefix)
+		}
+	}
+
+	if len(manifest.SimpleCommands) > 0 {
+		sb.WriteString("## Simple commands\n\n")
+		for _, c := range manifest.SimpleCommands {
+			fmt.Fprintf(&sb, "- `%s`", manifest.Prefix+c.Name)
+			if c.Description != "" {
+				sb.WriteString(": " + c.Description)
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// markdown renders the documentation of a command
+func (c ManifestCommand) markdown(sb *strings.Builder, prefix string) {
+	fmt.Fprintf(sb, "### `%s%s`\n\n", prefix, c.Name)
+	if c.Description != "" {
+		sb.WriteString(c.Description + "\n\n")
+	}
+
+	if c.Usage != "" {
+		fmt.Fprintf(sb, "- Usage: `%s`\n", c.Usage)
+	}
+	if len(c.Aliases) > 0 {
+		fmt.Fprintf(sb, "- Aliases: %s\n", codeList(c.Aliases))
+	}
+	if c.Cooldown != nil {
+		fmt.Fprintf(sb, "- Cooldown: %s per %s\n",
+			c.Cooldown.Duration, c.Cooldown.Scope)
+	}
+	if c.Permissions != nil {
+		fmt.Fprintf(sb, "- Permissions: %s\n", c.Permissions.markdown())
+	}
+	if len(c.Examples) > 0 {
+		fmt.Fprintf(sb, "- Examples: %s\n", codeList(c.Examples))
+	}
+	for _, sub := range c.Subcommands {
+		sub.subcommandMarkdown(sb, "")
+	}
+	sb.WriteString("\n")
+}
+
+// subcommandMarkdown renders a subcommand as a nested list item
+func (c ManifestCommand) subcommandMarkdown(sb *strings.Builder, indent string) {
+	fmt.Fprintf(sb, "%s- `%s`", indent, c.Name)
+	if c.Description != "" {
+		sb.WriteString(": " + c.Description)
+	}
+	if c.Usage != "" {
+		fmt.Fprintf(sb, " (`%s`)", c.Usage)
+	}
+	sb.WriteString("\n")
+
+	for _, sub := range c.Subcommands {
+		sub.subcommandMarkdown(sb, indent+"  ")
+	}
+}
+
+// markdown describes the permissions in words
+func (p *ManifestPermissions) markdown() string {
+	var parts []string
+	if len(p.Users) > 0 {
+		parts = append(parts, "users "+mentionList("@", p.Users))
+	}
+	if len(p.Roles) > 0 {
+		parts = append(parts, "roles "+mentionList("@&", p.Roles))
+	}
+	if len(p.Channels) > 0 {
+		parts = append(parts, "in channels "+mentionList("#", p.Channels))
+	}
+	if p.Permissions != 0 {
+		parts = append(parts, fmt.Sprintf("permission bits `%d`", p.Permissions))
+	}
+	return strings.Join(parts, ", or ")
+}
+
+// codeList renders values as a comma-separated list of code spans
+func codeList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "`" + v + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// mentionList renders IDs as a comma-separated list of mentions
+func mentionList(kind string, ids []string) string {
+	mentions := make([]string, len(ids))
+	for i, id := range ids {
+		mentions[i] = "<" + kind + id + ">"
+	}
+	return strings.Join(mentions, ", ")
+}
@@ -0,0 +1,68 @@
+package disgomux
+
+import "github.com/bwmarrin/discordgo"
+
+// Names member events are handled under, in logs, metrics and stats
+const (
+	memberJoinEvent  = "member_join"
+	memberLeaveEvent = "member_leave"
+)
+
+// OnMemberJoin registers a handler called when a member joins a guild, e.g.
+// to welcome them or give them a role. Handlers go through the middlewares
+// and are logged, traced and measured like commands, under "member_join".
+// ctx.Event is the *discordgo.GuildMemberAdd and ctx.Message.Member the member;
+// ctx.Message has no channel, so use ChannelSendTo() to send messages. Pass
+// Mux.HandleMemberAdd to DiscordGo.
+func (m *Mux) OnMemberJoin(handler func(ctx *Context)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.memberJoin = append(m.memberJoin, handler)
+}
+
+// OnMemberLeave registers a handler called when a member leaves a guild,
+// like OnMemberJoin does, under "member_leave". ctx.Event is the
+// *discordgo.GuildMemberRemove. Pass Mux.HandleMemberRemove to DiscordGo.
+func (m *Mux) OnMemberLeave(handler func(ctx *Context)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.memberLeave = append(m.memberLeave, handler)
+}
+
+// HandleMemberAdd is passed to DiscordGo to handle members joining guilds
+func (m *Mux) HandleMemberAdd(
+	session *discordgo.Session,
+	event *discordgo.GuildMemberAdd,
+) {
+	m.mu.RLock()
+	handlers := m.memberJoin
+	m.mu.RUnlock()
+
+	for _, h := range handlers {
+		ctx := m.eventContext(
+			session, event, memberJoinEvent, event.GuildID, "",
+			event.User, event.Member,
+		)
+		m.handleEvent(ctx, h)
+	}
+}
+
+// HandleMemberRemove is passed to DiscordGo to handle members leaving guilds
+func (m *Mux) HandleMemberRemove(
+	session *discordgo.Session,
+	event *discordgo.GuildMemberRemove,
+) {
+	m.mu.RLock()
+	handlers := m.memberLeave
+	m.mu.RUnlock()
+
+	for _, h := range handlers {
+		ctx := m.eventContext(
+			session, event, memberLeaveEvent, event.GuildID, "",
+			event.User, event.Member,
+		)
+		m.handleEvent(ctx, h)
+	}
+}
@@ -0,0 +1,96 @@
+package disgomux
+
+import "time"
+
+// Metrics receives measurements of the mux, e.g. to export them to a
+// monitoring system. Implementations must be safe for concurrent use. See the
+// prommetrics package for a Prometheus implementation.
+type Metrics interface {
+	// CommandDispatched is called when an invocation is dispatched
+	CommandDispatched(command string)
+
+	// CommandCompleted is called when a handler returns, with the error it
+	// returned, the panic it recovered from or ErrCommandTimeout
+	CommandCompleted(command string, latency time.Duration, err error)
+
+	// PermissionDenied is called when an invocation is refused because the
+	// user lacks permission
+	PermissionDenied(command string)
+
+	// QueueDepth is called with the number of invocations waiting in the
+	// worker pool queue whenever it changes
+	QueueDepth(depth int)
+}
+
+// ShardMetrics is Metrics partitioned by shard, for bots running several
+// sessions with one mux. If the receiver set with SetMetrics() implements it,
+// the mux calls these methods, with the shard ID of the session behind the
+// invocation, instead of their Metrics counterparts.
+type ShardMetrics interface {
+	Metrics
+
+	// ShardCommandDispatched is CommandDispatched for the shard
+	ShardCommandDispatched(shard int, command string)
+
+	// ShardCommandCompleted is CommandCompleted for the shard
+	ShardCommandCompleted(
+		shard int,
+		command string,
+		latency time.Duration,
+		err error,
+	)
+
+	// ShardPermissionDenied is PermissionDenied for the shard
+	ShardPermissionDenied(shard int, command string)
+}
+
+// SetMetrics sets the receiver of the measurements of the mux. Must be called
+// before Mux.Handle().
+func (m *Mux) SetMetrics(metrics Metrics) {
+	m.metrics = metrics
+}
+
+// observeQueue reports the depth of the worker pool queue
+func (m *Mux) observeQueue() {
+	if p := m.workers(); m.metrics != nil && p != nil {
+		m.metrics.QueueDepth(len(p.jobs))
+	}
+}
+
+// observeDispatched reports the invocation being dispatched
+func (m *Mux) observeDispatched(ctx *Context) {
+	switch metrics := m.metrics.(type) {
+	case nil:
+	case ShardMetrics:
+		metrics.ShardCommandDispatched(ctx.ShardID(), ctx.Command)
+	default:
+		metrics.CommandDispatched(ctx.Command)
+	}
+}
+
+// observeCompleted reports the handler of the invocation returning
+func (m *Mux) observeCompleted(
+	ctx *Context,
+	latency time.Duration,
+	err error,
+) {
+	switch metrics := m.metrics.(type) {
+	case nil:
+	case ShardMetrics:
+		metrics.ShardCommandCompleted(ctx.ShardID(), ctx.Command, latency, err)
+	default:
+		metrics.CommandCompleted(ctx.Command, latency, err)
+	}
+}
+
+// observeDenied reports the invocation of the command being refused for lack
+// of permission
+func (m *Mux) observeDenied(ctx *Context, command string) {
+	switch metrics := m.metrics.(type) {
+	case nil:
+	case ShardMetrics:
+		metrics.ShardPermissionDenied(ctx.ShardID(), command)
+	default:
+		metrics.PermissionDenied(command)
+	}
+}
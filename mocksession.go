@@ -0,0 +1,716 @@
+package disgomux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// MockSession is a Session which records requests instead of sending them
+	// to Discord, for tests. Sent messages are kept with sequential IDs, so
+	// they can be edited, fetched and deleted again. Channels, guilds, members
+	// and roles are looked up in the maps, which may be filled before use.
+	MockSession struct {
+		// Err, if set, is returned by every request
+		Err error
+
+		Channels map[string]*discordgo.Channel
+		Guilds   map[string]*discordgo.Guild
+		Roles    map[string][]*discordgo.Role
+
+		// Members are keyed by guild ID, then user ID
+		Members map[string]map[string]*discordgo.Member
+
+		mu        sync.Mutex
+		calls     []MockCall
+		messages  map[string]*discordgo.Message
+		history   []*discordgo.Message
+		sent      []*discordgo.Message
+		responses map[string]string
+		nextID    uint64
+	}
+
+	// MockCall is a request made through a MockSession
+	MockCall struct {
+		Method string
+		Args   []interface{}
+	}
+)
+
+var _ Session = (*MockSession)(nil)
+
+// NewMockSession creates an empty mock session
+func NewMockSession() *MockSession {
+	return &MockSession{
+		Channels: make(map[string]*discordgo.Channel),
+		Guilds:   make(map[string]*discordgo.Guild),
+		Roles:    make(map[string][]*discordgo.Role),
+		Members:  make(map[string]map[string]*discordgo.Member),
+	}
+}
+
+// Calls returns the requests made, in order
+func (s *MockSession) Calls() []MockCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]MockCall(nil), s.calls...)
+}
+
+// Sent returns the messages sent, in order, including interaction responses
+// and followups. Edits are reflected; deleted messages are left out.
+func (s *MockSession) Sent() []*discordgo.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sent []*discordgo.Message
+	for _, msg := range s.sent {
+		if _, ok := s.messages[msg.ID]; ok {
+			sent = append(sent, msg)
+		}
+	}
+	return sent
+}
+
+// Reset forgets the requests made and the messages sent
+func (s *MockSession) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = nil
+	s.messages = nil
+	s.history = nil
+	s.sent = nil
+	s.responses = nil
+}
+
+// record records a request, returning Err
+func (s *MockSession) record(method string, args ...interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, MockCall{Method: method, Args: args})
+	return s.Err
+}
+
+// send stores a message sent to the channel
+func (s *MockSession) send(
+	channelID string,
+	content string,
+	embeds []*discordgo.MessageEmbed,
+) *discordgo.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	msg := &discordgo.Message{
+		ID:        strconv.FormatUint(1000+s.nextID, 10),
+		ChannelID: channelID,
+		Content:   content,
+		Embeds:    embeds,
+		Timestamp: time.Now(),
+	}
+	s.store(msg)
+	s.sent = append(s.sent, msg)
+	return msg
+}
+
+// AddMessage adds a message the bot didn't send, e.g. one by a user, to the
+// history of its channel, so it can be fetched and deleted
+func (s *MockSession) AddMessage(msg *discordgo.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store(msg)
+}
+
+// store adds a message to the history of its channel. Must be called with mu
+// held.
+func (s *MockSession) store(msg *discordgo.Message) {
+	if s.messages == nil {
+		s.messages = make(map[string]*discordgo.Message)
+	}
+	s.messages[msg.ID] = msg
+	s.history = append(s.history, msg)
+}
+
+// message looks up a message sent in the channel
+func (s *MockSession) message(
+	channelID, messageID string,
+) (*discordgo.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg, ok := s.messages[messageID]
+	if !ok || (channelID != "" && msg.ChannelID != channelID) {
+		return nil, fmt.Errorf("Unknown message %s", messageID)
+	}
+	return msg, nil
+}
+
+// edit changes the content and embeds of a sent message
+func (s *MockSession) edit(
+	channelID, messageID string,
+	content *string,
+	embeds *[]*discordgo.MessageEmbed,
+) (*discordgo.Message, error) {
+	msg, err := s.message(channelID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if content != nil {
+		msg.Content = *content
+	}
+	if embeds != nil {
+		msg.Embeds = *embeds
+	}
+	return msg, nil
+}
+
+// delete removes a sent message
+func (s *MockSession) delete(channelID, messageID string) error {
+	if _, err := s.message(channelID, messageID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.messages, messageID)
+	return nil
+}
+
+// ChannelMessageSend records and stores a message
+func (s *MockSession) ChannelMessageSend(
+	channelID, content string,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	if err := s.record("ChannelMessageSend", channelID, content); err != nil {
+		return nil, err
+	}
+	return s.send(channelID, content, nil), nil
+}
+
+// ChannelMessageSendComplex records and stores a message
+func (s *MockSession) ChannelMessageSendComplex(
+	channelID string,
+	data *discordgo.MessageSend,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	err := s.record("ChannelMessageSendComplex", channelID, data)
+	if err != nil {
+		return nil, err
+	}
+
+	embeds := data.Embeds
+	if data.Embed != nil {
+		embeds = append([]*discordgo.MessageEmbed{data.Embed}, embeds...)
+	}
+	return s.send(channelID, data.Content, embeds), nil
+}
+
+// ChannelMessageSendEmbed records and stores a message
+func (s *MockSession) ChannelMessageSendEmbed(
+	channelID string,
+	embed *discordgo.MessageEmbed,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	err := s.record("ChannelMessageSendEmbed", channelID, embed)
+	if err != nil {
+		return nil, err
+	}
+	return s.send(channelID, "", []*discordgo.MessageEmbed{embed}), nil
+}
+
+// ChannelMessageEdit records the edit and applies it to the stored message
+func (s *MockSession) ChannelMessageEdit(
+	channelID, messageID, content string,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	err := s.record("ChannelMessageEdit", channelID, messageID, content)
+	if err != nil {
+		return nil, err
+	}
+	return s.edit(channelID, messageID, &content, nil)
+}
+
+// ChannelMessageEditEmbed records the edit and applies it to the stored
+// message
+func (s *MockSession) ChannelMessageEditEmbed(
+	channelID, messageID string,
+	embed *discordgo.MessageEmbed,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	err := s.record("ChannelMessageEditEmbed", channelID, messageID, embed)
+	if err != nil {
+		return nil, err
+	}
+
+	embeds := []*discordgo.MessageEmbed{embed}
+	return s.edit(channelID, messageID, nil, &embeds)
+}
+
+// ChannelMessageDelete records the deletion and forgets the stored message.
+// Deleting a message not sent through the mock succeeds.
+func (s *MockSession) ChannelMessageDelete(
+	channelID, messageID string,
+	options ...discordgo.RequestOption,
+) error {
+	err := s.record("ChannelMessageDelete", channelID, messageID)
+	if err != nil {
+		return err
+	}
+
+	s.delete(channelID, messageID)
+	return nil
+}
+
+// ChannelMessages records the request and returns the messages of the channel,
+// newest first, before beforeID if set. afterID and aroundID are ignored.
+func (s *MockSession) ChannelMessages(
+	channelID string,
+	limit int,
+	beforeID, afterID, aroundID string,
+	options ...discordgo.RequestOption,
+) ([]*discordgo.Message, error) {
+	err := s.record("ChannelMessages", channelID, limit, beforeID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var messages []*discordgo.Message
+	before := beforeID == ""
+	for i := len(s.history) - 1; i >= 0 && len(messages) < limit; i-- {
+		msg := s.history[i]
+		if msg.ChannelID != channelID {
+			continue
+		}
+		if !before {
+			before = msg.ID == beforeID
+			continue
+		}
+		if _, ok := s.messages[msg.ID]; ok {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// ChannelMessagesBulkDelete records the deletion and forgets the stored
+// messages
+func (s *MockSession) ChannelMessagesBulkDelete(
+	channelID string,
+	messages []string,
+	options ...discordgo.RequestOption,
+) error {
+	err := s.record("ChannelMessagesBulkDelete", channelID, messages)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range messages {
+		s.delete(channelID, id)
+	}
+	return nil
+}
+
+// ChannelMessageCrosspost records the crosspost and returns the stored
+// message, flagged as crossposted
+func (s *MockSession) ChannelMessageCrosspost(
+	channelID, messageID string,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	err := s.record("ChannelMessageCrosspost", channelID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := s.message(channelID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg.Flags |= discordgo.MessageFlagsCrossPosted
+	return msg, nil
+}
+
+// MessageReactionAdd records the reaction
+func (s *MockSession) MessageReactionAdd(
+	channelID, messageID, emojiID string,
+	options ...discordgo.RequestOption,
+) error {
+	return s.record("MessageReactionAdd", channelID, messageID, emojiID)
+}
+
+// MessageReactionRemove records the removal
+func (s *MockSession) MessageReactionRemove(
+	channelID, messageID, emojiID, userID string,
+	options ...discordgo.RequestOption,
+) error {
+	return s.record(
+		"MessageReactionRemove", channelID, messageID, emojiID, userID,
+	)
+}
+
+// MessageReactionsRemoveAll records the removal
+func (s *MockSession) MessageReactionsRemoveAll(
+	channelID, messageID string,
+	options ...discordgo.RequestOption,
+) error {
+	return s.record("MessageReactionsRemoveAll", channelID, messageID)
+}
+
+// ChannelTyping records the typing indicator
+func (s *MockSession) ChannelTyping(
+	channelID string,
+	options ...discordgo.RequestOption,
+) error {
+	return s.record("ChannelTyping", channelID)
+}
+
+// MessageThreadStart records the thread and stores it as a channel
+func (s *MockSession) MessageThreadStart(
+	channelID, messageID, name string,
+	archiveDuration int,
+	options ...discordgo.RequestOption,
+) (*discordgo.Channel, error) {
+	err := s.record("MessageThreadStart", channelID, messageID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	thread := &discordgo.Channel{
+		ID:       messageID,
+		ParentID: channelID,
+		Name:     name,
+		Type:     discordgo.ChannelTypeGuildPublicThread,
+	}
+	s.Channels[thread.ID] = thread
+	return thread, nil
+}
+
+// Channel looks up a channel in Channels
+func (s *MockSession) Channel(
+	channelID string,
+	options ...discordgo.RequestOption,
+) (*discordgo.Channel, error) {
+	if err := s.record("Channel", channelID); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.Channels[channelID]; ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("Unknown channel %s", channelID)
+}
+
+// Guild looks up a guild in Guilds
+func (s *MockSession) Guild(
+	guildID string,
+	options ...discordgo.RequestOption,
+) (*discordgo.Guild, error) {
+	if err := s.record("Guild", guildID); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if g, ok := s.Guilds[guildID]; ok {
+		return g, nil
+	}
+	return nil, fmt.Errorf("Unknown guild %s", guildID)
+}
+
+// GuildMember looks up a member in Members
+func (s *MockSession) GuildMember(
+	guildID, userID string,
+	options ...discordgo.RequestOption,
+) (*discordgo.Member, error) {
+	if err := s.record("GuildMember", guildID, userID); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if m, ok := s.Members[guildID][userID]; ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("Unknown member %s", userID)
+}
+
+// GuildRoles looks up the roles of a guild in Roles
+func (s *MockSession) GuildRoles(
+	guildID string,
+	options ...discordgo.RequestOption,
+) ([]*discordgo.Role, error) {
+	if err := s.record("GuildRoles", guildID); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.Roles[guildID], nil
+}
+
+// GuildMemberRoleAdd records the role change and adds the role to the member
+// in Members, if it is there
+func (s *MockSession) GuildMemberRoleAdd(
+	guildID, userID, roleID string,
+	options ...discordgo.RequestOption,
+) error {
+	if err := s.record("GuildMemberRoleAdd", guildID, userID, roleID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.Members[guildID][userID]
+	if ok && !arrayContains(m.Roles, roleID) {
+		m.Roles = append(m.Roles, roleID)
+	}
+	return nil
+}
+
+// GuildMemberDeleteWithReason records the kick and removes the member from
+// Members
+func (s *MockSession) GuildMemberDeleteWithReason(
+	guildID, userID, reason string,
+	options ...discordgo.RequestOption,
+) error {
+	err := s.record("GuildMemberDeleteWithReason", guildID, userID, reason)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.Members[guildID], userID)
+	return nil
+}
+
+// UserChannelCreate returns the DM channel of the user, creating it in
+// Channels with the ID "dm-<userID>" if needed
+func (s *MockSession) UserChannelCreate(
+	recipientID string,
+	options ...discordgo.RequestOption,
+) (*discordgo.Channel, error) {
+	if err := s.record("UserChannelCreate", recipientID); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := "dm-" + recipientID
+	c, ok := s.Channels[id]
+	if !ok {
+		c = &discordgo.Channel{
+			ID:         id,
+			Type:       discordgo.ChannelTypeDM,
+			Recipients: []*discordgo.User{{ID: recipientID}},
+		}
+		s.Channels[id] = c
+	}
+	return c, nil
+}
+
+// InteractionRespond records the response and stores any message it carries
+// as the original response
+func (s *MockSession) InteractionRespond(
+	interaction *discordgo.Interaction,
+	resp *discordgo.InteractionResponse,
+	options ...discordgo.RequestOption,
+) error {
+	if err := s.record("InteractionRespond", interaction, resp); err != nil {
+		return err
+	}
+
+	var content string
+	var embeds []*discordgo.MessageEmbed
+	if resp.Data != nil {
+		content, embeds = resp.Data.Content, resp.Data.Embeds
+	}
+
+	switch resp.Type {
+	case discordgo.InteractionResponseChannelMessageWithSource,
+		discordgo.InteractionResponseDeferredChannelMessageWithSource:
+		msg := s.send(interaction.ChannelID, content, embeds)
+
+		s.mu.Lock()
+		if s.responses == nil {
+			s.responses = make(map[string]string)
+		}
+		s.responses[interaction.ID] = msg.ID
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// InteractionResponse returns the stored original response
+func (s *MockSession) InteractionResponse(
+	interaction *discordgo.Interaction,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	if err := s.record("InteractionResponse", interaction); err != nil {
+		return nil, err
+	}
+	return s.original(interaction)
+}
+
+// InteractionResponseEdit records the edit and applies it to the stored
+// original response
+func (s *MockSession) InteractionResponseEdit(
+	interaction *discordgo.Interaction,
+	edit *discordgo.WebhookEdit,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	err := s.record("InteractionResponseEdit", interaction, edit)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := s.original(interaction)
+	if err != nil {
+		return nil, err
+	}
+	return s.edit("", msg.ID, edit.Content, edit.Embeds)
+}
+
+// InteractionResponseDelete records the deletion and forgets the stored
+// original response
+func (s *MockSession) InteractionResponseDelete(
+	interaction *discordgo.Interaction,
+	options ...discordgo.RequestOption,
+) error {
+	if err := s.record("InteractionResponseDelete", interaction); err != nil {
+		return err
+	}
+
+	msg, err := s.original(interaction)
+	if err != nil {
+		return err
+	}
+	return s.delete("", msg.ID)
+}
+
+// original returns the stored original response to the interaction
+func (s *MockSession) original(
+	interaction *discordgo.Interaction,
+) (*discordgo.Message, error) {
+	s.mu.Lock()
+	id, ok := s.responses[interaction.ID]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("No response to interaction %s", interaction.ID)
+	}
+	return s.message("", id)
+}
+
+// FollowupMessageCreate records and stores a followup message
+func (s *MockSession) FollowupMessageCreate(
+	interaction *discordgo.Interaction,
+	wait bool,
+	data *discordgo.WebhookParams,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	err := s.record("FollowupMessageCreate", interaction, data)
+	if err != nil {
+		return nil, err
+	}
+	return s.send(interaction.ChannelID, data.Content, data.Embeds), nil
+}
+
+// FollowupMessageEdit records the edit and applies it to the stored followup
+func (s *MockSession) FollowupMessageEdit(
+	interaction *discordgo.Interaction,
+	messageID string,
+	data *discordgo.WebhookEdit,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	err := s.record("FollowupMessageEdit", interaction, messageID, data)
+	if err != nil {
+		return nil, err
+	}
+	return s.edit("", messageID, data.Content, data.Embeds)
+}
+
+// FollowupMessageDelete records the deletion and forgets the stored followup
+func (s *MockSession) FollowupMessageDelete(
+	interaction *discordgo.Interaction,
+	messageID string,
+	options ...discordgo.RequestOption,
+) error {
+	err := s.record("FollowupMessageDelete", interaction, messageID)
+	if err != nil {
+		return err
+	}
+	return s.delete("", messageID)
+}
+
+// ChannelWebhooks returns no webhooks
+func (s *MockSession) ChannelWebhooks(
+	channelID string,
+	options ...discordgo.RequestOption,
+) ([]*discordgo.Webhook, error) {
+	return nil, s.record("ChannelWebhooks", channelID)
+}
+
+// WebhookCreate records and returns a webhook in the channel
+func (s *MockSession) WebhookCreate(
+	channelID, name, avatar string,
+	options ...discordgo.RequestOption,
+) (*discordgo.Webhook, error) {
+	if err := s.record("WebhookCreate", channelID, name); err != nil {
+		return nil, err
+	}
+
+	return &discordgo.Webhook{
+		ID:        "webhook-" + channelID,
+		ChannelID: channelID,
+		Name:      name,
+		Token:     "token",
+	}, nil
+}
+
+// WebhookExecute records and stores a message sent in the channel of the
+// webhook, which is taken from its ID "webhook-<channelID>"
+func (s *MockSession) WebhookExecute(
+	webhookID, token string,
+	wait bool,
+	data *discordgo.WebhookParams,
+	options ...discordgo.RequestOption,
+) (*discordgo.Message, error) {
+	if err := s.record("WebhookExecute", webhookID, data); err != nil {
+		return nil, err
+	}
+
+	channelID := strings.TrimPrefix(webhookID, "webhook-")
+	return s.send(channelID, data.Content, data.Embeds), nil
+}
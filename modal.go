@@ -0,0 +1,85 @@
+package disgomux
+
+import "github.com/bwmarrin/discordgo"
+
+// HandleModal registers the handler of the modal submissions whose custom ID
+// matches pattern, which is either a custom ID or a prefix followed by *, as
+// for HandleComponent(). Use Context.ModalValue() to read the submitted fields.
+// Safe to call while interactions are being handled.
+func (m *Mux) HandleModal(pattern string, handler ComponentHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.modals.add(pattern, handler)
+}
+
+// handleModal routes a modal submission to its handler
+func (m *Mux) handleModal(
+	session *discordgo.Session,
+	interaction *discordgo.InteractionCreate,
+) {
+	customID := interaction.ModalSubmitData().CustomID
+
+	m.mu.RLock()
+	handler, pattern, ok := m.modals.match(customID)
+	m.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	m.dispatchInteraction(session, interaction, pattern, handler)
+}
+
+// ShowModal responds to a slash command or component interaction by opening a
+// modal with the text inputs, one per row. Its submission is routed to the
+// handler registered with HandleModal() for the custom ID.
+func (ctx *Context) ShowModal(
+	customID, title string,
+	inputs ...discordgo.TextInput,
+) error {
+	rows := make([]discordgo.MessageComponent, 0, len(inputs))
+	for _, input := range inputs {
+		rows = append(rows, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{input},
+		})
+	}
+
+	return ctx.respondOnce(&discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID:   customID,
+			Title:      title,
+			Components: rows,
+		},
+	})
+}
+
+// ModalValue returns the value submitted in the text input of the modal with
+// the custom ID, or an empty string if there is none
+func (ctx *Context) ModalValue(customID string) string {
+	return ctx.ModalValues()[customID]
+}
+
+// ModalValues returns the values submitted in the text inputs of the modal,
+// keyed by their custom ID
+func (ctx *Context) ModalValues() map[string]string {
+	values := make(map[string]string)
+	if ctx.Interaction == nil ||
+		ctx.Interaction.Type != discordgo.InteractionModalSubmit {
+		return values
+	}
+
+	for _, c := range ctx.Interaction.ModalSubmitData().Components {
+		row, ok := c.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+
+		for _, rc := range row.Components {
+			if input, ok := rc.(*discordgo.TextInput); ok {
+				values[input.CustomID] = input.Value
+			}
+		}
+	}
+	return values
+}
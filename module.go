@@ -0,0 +1,232 @@
+package disgomux
+
+import (
+	"fmt"
+	"sort"
+)
+
+type (
+	// Module is a cohesive set of commands and middleware, loaded into and
+	// unloaded from the mux as one feature
+	Module interface {
+		Name() string
+		Commands() []Command
+		Middleware() []Middleware
+
+		// OnLoad is called when the module is loaded, after its commands are
+		// registered and initialized. An error unloads the module again.
+		OnLoad(m *Mux) error
+
+		// OnUnload is called when the module is unloaded, after its commands
+		// are removed
+		OnUnload(m *Mux) error
+	}
+
+	// ModuleInfo describes a loaded module
+	ModuleInfo struct {
+		Name     string
+		Enabled  bool
+		Commands []string
+	}
+
+	// loadedModule is a module loaded into the mux
+	loadedModule struct {
+		module     Module
+		enabled    bool
+		commands   []Command
+		middleware []Middleware
+	}
+)
+
+// LoadModule registers the commands and middleware of the modules, calls the
+// init functions of the commands, then calls OnLoad. A module is rejected if
+// its name is already loaded or one of its commands is already registered.
+func (m *Mux) LoadModule(modules ...Module) error {
+	for _, module := range modules {
+		if err := m.loadModule(module); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadModule loads a single module
+func (m *Mux) loadModule(module Module) error {
+	name := module.Name()
+	loaded := &loadedModule{
+		module:     module,
+		enabled:    true,
+		commands:   module.Commands(),
+		middleware: module.Middleware(),
+	}
+
+	m.mu.Lock()
+	if _, ok := m.modules[name]; ok {
+		m.mu.Unlock()
+		return fmt.Errorf("Module %s already loaded", name)
+	}
+	for _, c := range loaded.commands {
+		if _, _, ok := m.lookup(c.Settings().Command); ok {
+			m.mu.Unlock()
+			return fmt.Errorf(
+				"Module %s: %w: %s",
+				name, ErrDuplicateCommand, c.Settings().Command,
+			)
+		}
+	}
+	if m.modules == nil {
+		m.modules = make(map[string]*loadedModule)
+	}
+	m.modules[name] = loaded
+	m.mu.Unlock()
+
+	m.Register(loaded.commands...)
+	m.Initialize(loaded.commands...)
+	m.rebuildModuleMiddleware()
+
+	if err := module.OnLoad(m); err != nil {
+		m.removeModule(name)
+		return fmt.Errorf("Module %s failed to load: %v", name, err)
+	}
+	return nil
+}
+
+// UnloadModule removes the commands and middleware of the named module, then
+// calls its OnUnload
+func (m *Mux) UnloadModule(name string) error {
+	loaded, ok := m.removeModule(name)
+	if !ok {
+		return fmt.Errorf("Module %s not loaded", name)
+	}
+	return loaded.module.OnUnload(m)
+}
+
+// removeModule removes a loaded module from the mux
+func (m *Mux) removeModule(name string) (*loadedModule, bool) {
+	m.mu.Lock()
+	loaded, ok := m.modules[name]
+	if ok {
+		delete(m.modules, name)
+		if loaded.enabled {
+			m.unregister(loaded.commands)
+		}
+	}
+	m.mu.Unlock()
+
+	if ok {
+		m.rebuildModuleMiddleware()
+	}
+	return loaded, ok
+}
+
+// EnableModule re-enables the commands and middleware of a disabled module
+func (m *Mux) EnableModule(name string) error {
+	return m.setModuleEnabled(name, true)
+}
+
+// DisableModule disables the commands and middleware of a loaded module,
+// without unloading it. Its commands are then treated as unknown.
+func (m *Mux) DisableModule(name string) error {
+	return m.setModuleEnabled(name, false)
+}
+
+// setModuleEnabled enables or disables a loaded module
+func (m *Mux) setModuleEnabled(name string, enabled bool) error {
+	m.mu.Lock()
+	loaded, ok := m.modules[name]
+	if !ok {
+		m.mu.Unlock()
+		return fmt.Errorf("Module %s not loaded", name)
+	}
+	changed := loaded.enabled != enabled
+	loaded.enabled = enabled
+	if changed && !enabled {
+		m.unregister(loaded.commands)
+	}
+	m.mu.Unlock()
+
+	if !changed {
+		return nil
+	}
+	if enabled {
+		m.Register(loaded.commands...)
+	}
+	m.rebuildModuleMiddleware()
+	return nil
+}
+
+// Modules lists the loaded modules, sorted by name
+func (m *Mux) Modules() []ModuleInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	infos := make([]ModuleInfo, 0, len(m.modules))
+	for name, loaded := range m.modules {
+		info := ModuleInfo{Name: name, Enabled: loaded.enabled}
+		for _, c := range loaded.commands {
+			info.Commands = append(info.Commands, c.Settings().Command)
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// unregister removes commands along with their aliases. Must be called with mu
+// held.
+func (m *Mux) unregister(commands []Command) {
+	for _, c := range commands {
+		name := c.Settings().Command
+		delete(m.Commands, name)
+
+		for alias, canonical := range m.aliases {
+			if canonical == name {
+				delete(m.aliases, alias)
+			}
+		}
+
+		/* Copy on write, as suggest() reads the names without the lock */
+		names := make([]string, 0, len(m.commandNames))
+		for _, n := range m.commandNames {
+			if n != name {
+				names = append(names, n)
+			}
+		}
+		m.commandNames = names
+	}
+}
+
+// rebuildModuleMiddleware collects the middleware of the enabled modules, in
+// the order of their names
+func (m *Mux) rebuildModuleMiddleware() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.modules))
+	for name := range m.modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var middleware []Middleware
+	for _, name := range names {
+		if loaded := m.modules[name]; loaded.enabled {
+			middleware = append(middleware, loaded.middleware...)
+		}
+	}
+	m.moduleMiddleware = middleware
+}
+
+// middleware returns the middleware of the mux followed by that of the enabled
+// modules. Must be called with mu held.
+func (m *Mux) middleware() []Middleware {
+	if len(m.moduleMiddleware) == 0 {
+		return m.Middleware
+	}
+
+	n := len(m.Middleware)
+	return append(m.Middleware[:n:n], m.moduleMiddleware...)
+}
@@ -0,0 +1,415 @@
+// Package muxtest feeds synthetic events into a disgomux.Mux and captures what
+// the bot sends, so command handlers can be tested without a live Discord
+// connection.
+//
+//	h := muxtest.New(mux)
+//	h.Send("!ping")
+//	h.AssertSent(t, "pong")
+package muxtest
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CS-5/disgomux"
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// Harness drives a mux with synthetic events. Requests the mux makes go to
+	// Mock; State holds the guild, channel, bot and members events come from.
+	Harness struct {
+		Mux     *disgomux.Mux
+		Session *discordgo.Session
+		Mock    *disgomux.MockSession
+
+		// Guild and Channel are where events originate by default, and User
+		// sends them
+		Guild   *discordgo.Guild
+		Channel *discordgo.Channel
+		User    *discordgo.User
+
+		nextID uint64
+	}
+
+	// Reaction is a reaction added by the bot
+	Reaction struct {
+		ChannelID, MessageID, Emoji string
+	}
+)
+
+// Default IDs of the synthetic guild, channel and users
+const (
+	BotID     = "100"
+	GuildID   = "200"
+	ChannelID = "300"
+	UserID    = "400"
+)
+
+// New creates a harness around the mux, which is made synchronous and sends
+// its requests to a new MockSession. The state holds a guild with a text
+// channel, the bot and a user, both members of the guild.
+func New(m *disgomux.Mux) *Harness {
+	h := &Harness{
+		Mux:     m,
+		Mock:    disgomux.NewMockSession(),
+		Guild:   &discordgo.Guild{ID: GuildID, Name: "Guild", OwnerID: UserID},
+		Channel: &discordgo.Channel{ID: ChannelID, GuildID: GuildID, Name: "general"},
+		User:    &discordgo.User{ID: UserID, Username: "user"},
+		nextID:  1 << 20,
+	}
+
+	state := discordgo.NewState()
+	state.User = &discordgo.User{ID: BotID, Username: "bot", Bot: true}
+	h.Session = &discordgo.Session{State: state}
+
+	state.GuildAdd(h.Guild)
+	state.ChannelAdd(h.Channel)
+	h.AddMember(state.User)
+	h.AddMember(h.User)
+
+	h.Mock.Guilds[GuildID] = h.Guild
+	h.Mock.Channels[ChannelID] = h.Channel
+
+	m.SetSynchronous(true)
+	m.SetSession(h.Mock)
+	return h
+}
+
+// AddMember adds a member of the guild with the roles, to the state and mock
+func (h *Harness) AddMember(user *discordgo.User, roleIDs ...string) {
+	member := &discordgo.Member{GuildID: GuildID, User: user, Roles: roleIDs}
+	h.Session.State.MemberAdd(member)
+
+	if h.Mock.Members[GuildID] == nil {
+		h.Mock.Members[GuildID] = make(map[string]*discordgo.Member)
+	}
+	h.Mock.Members[GuildID][user.ID] = member
+}
+
+// id returns a new snowflake-like ID for a synthetic event
+func (h *Harness) id() string {
+	return strconv.FormatUint(atomic.AddUint64(&h.nextID, 1), 10)
+}
+
+// Send handles a message with the content, sent by User in Channel
+func (h *Harness) Send(content string) *discordgo.MessageCreate {
+	return h.SendAs(h.User, content)
+}
+
+// SendAs handles a message with the content, sent by the user in Channel
+func (h *Harness) SendAs(
+	user *discordgo.User,
+	content string,
+) *discordgo.MessageCreate {
+	return h.SendMessage(&discordgo.Message{
+		ChannelID: h.Channel.ID,
+		GuildID:   h.Guild.ID,
+		Author:    user,
+		Content:   content,
+	})
+}
+
+// SendDM handles a message with the content, sent by User in a DM
+func (h *Harness) SendDM(content string) *discordgo.MessageCreate {
+	return h.SendMessage(&discordgo.Message{
+		ChannelID: "dm-" + h.User.ID,
+		Author:    h.User,
+		Content:   content,
+	})
+}
+
+// SendMessage handles the message, filling in its ID, author and timestamp if
+// unset
+func (h *Harness) SendMessage(msg *discordgo.Message) *discordgo.MessageCreate {
+	if msg.ID == "" {
+		msg.ID = h.id()
+	}
+	if msg.Author == nil {
+		msg.Author = h.User
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+
+	/* Keep the message in the channel history, e.g. for purges */
+	h.Mock.AddMessage(msg)
+
+	event := &discordgo.MessageCreate{Message: msg}
+	h.Mux.Handle(h.Session, event)
+	return event
+}
+
+// Slash handles a slash command invoked by User in Channel, with the options
+func (h *Harness) Slash(
+	name string,
+	options ...*discordgo.ApplicationCommandInteractionDataOption,
+) *discordgo.InteractionCreate {
+	return h.Interaction(&discordgo.Interaction{
+		Type: discordgo.InteractionApplicationCommand,
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name:        name,
+			CommandType: discordgo.ChatApplicationCommand,
+			Options:     options,
+		},
+	})
+}
+
+// Component handles a press of the component with the custom ID by User, on a
+// message in Channel
+func (h *Harness) Component(
+	customID string,
+	values ...string,
+) *discordgo.InteractionCreate {
+	componentType := discordgo.ButtonComponent
+	if len(values) > 0 {
+		componentType = discordgo.SelectMenuComponent
+	}
+
+	return h.Interaction(&discordgo.Interaction{
+		Type: discordgo.InteractionMessageComponent,
+		Data: discordgo.MessageComponentInteractionData{
+			CustomID:      customID,
+			ComponentType: componentType,
+			Values:        values,
+		},
+		Message: &discordgo.Message{ID: h.id(), ChannelID: h.Channel.ID},
+	})
+}
+
+// Interaction handles the interaction, filling in its ID, token, guild,
+// channel and member if unset
+func (h *Harness) Interaction(
+	interaction *discordgo.Interaction,
+) *discordgo.InteractionCreate {
+	if interaction.ID == "" {
+		interaction.ID = h.id()
+	}
+	if interaction.Token == "" {
+		interaction.Token = "token-" + interaction.ID
+	}
+	if interaction.ChannelID == "" {
+		interaction.ChannelID = h.Channel.ID
+		interaction.GuildID = h.Guild.ID
+	}
+	if interaction.Member == nil && interaction.User == nil {
+		if interaction.GuildID != "" {
+			interaction.Member = &discordgo.Member{
+				GuildID: interaction.GuildID,
+				User:    h.User,
+			}
+		} else {
+			interaction.User = h.User
+		}
+	}
+
+	event := &discordgo.InteractionCreate{Interaction: interaction}
+	h.Mux.HandleInteraction(h.Session, event)
+	return event
+}
+
+// Option builds a slash command option with the value
+func Option(
+	name string,
+	value interface{},
+) *discordgo.ApplicationCommandInteractionDataOption {
+	option := &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  name,
+		Value: value,
+	}
+
+	/* Discord sends numbers as JSON, so they arrive as float64 */
+	switch v := value.(type) {
+	case bool:
+		option.Type = discordgo.ApplicationCommandOptionBoolean
+	case int:
+		option.Type = discordgo.ApplicationCommandOptionInteger
+		option.Value = float64(v)
+	case int64:
+		option.Type = discordgo.ApplicationCommandOptionInteger
+		option.Value = float64(v)
+	case float64:
+		option.Type = discordgo.ApplicationCommandOptionNumber
+	default:
+		option.Type = discordgo.ApplicationCommandOptionString
+	}
+	return option
+}
+
+// Sent returns the messages the bot sent outside of DMs, in order
+func (h *Harness) Sent() []*discordgo.Message {
+	var sent []*discordgo.Message
+	for _, msg := range h.Mock.Sent() {
+		if !isDM(msg.ChannelID) {
+			sent = append(sent, msg)
+		}
+	}
+	return sent
+}
+
+// DMs returns the messages the bot sent to the user in DMs, in order
+func (h *Harness) DMs(userID string) []*discordgo.Message {
+	var sent []*discordgo.Message
+	for _, msg := range h.Mock.Sent() {
+		if msg.ChannelID == "dm-"+userID {
+			sent = append(sent, msg)
+		}
+	}
+	return sent
+}
+
+// Embeds returns the embeds the bot sent outside of DMs, in order
+func (h *Harness) Embeds() []*discordgo.MessageEmbed {
+	var embeds []*discordgo.MessageEmbed
+	for _, msg := range h.Sent() {
+		embeds = append(embeds, msg.Embeds...)
+	}
+	return embeds
+}
+
+// Reactions returns the reactions the bot added, in order
+func (h *Harness) Reactions() []Reaction {
+	var reactions []Reaction
+	for _, call := range h.Mock.Calls() {
+		if call.Method != "MessageReactionAdd" {
+			continue
+		}
+
+		reactions = append(reactions, Reaction{
+			ChannelID: call.Args[0].(string),
+			MessageID: call.Args[1].(string),
+			Emoji:     call.Args[2].(string),
+		})
+	}
+	return reactions
+}
+
+// Reset forgets everything the bot sent
+func (h *Harness) Reset() {
+	h.Mock.Reset()
+}
+
+// isDM reports whether the channel is a DM channel of the mock session
+func isDM(channelID string) bool {
+	return strings.HasPrefix(channelID, "dm-")
+}
+
+// AssertSent fails the test unless the bot sent a message with exactly the
+// content outside of DMs
+func (h *Harness) AssertSent(t testing.TB, content string) {
+	t.Helper()
+
+	for _, msg := range h.Sent() {
+		if msg.Content == content {
+			return
+		}
+	}
+	t.Errorf("No message %q sent; sent %s", content, contents(h.Sent()))
+}
+
+// AssertSentContains fails the test unless the bot sent a message containing
+// the text outside of DMs
+func (h *Harness) AssertSentContains(t testing.TB, text string) {
+	t.Helper()
+
+	for _, msg := range h.Sent() {
+		if strings.Contains(msg.Content, text) {
+			return
+		}
+	}
+	t.Errorf("No message containing %q sent; sent %s", text, contents(h.Sent()))
+}
+
+// AssertNothingSent fails the test if the bot sent any message
+func (h *Harness) AssertNothingSent(t testing.TB) {
+	t.Helper()
+
+	if sent := h.Mock.Sent(); len(sent) > 0 {
+		t.Errorf("Expected no messages; sent %s", contents(sent))
+	}
+}
+
+// AssertEmbed fails the test unless the bot sent an embed with the title
+// outside of DMs
+func (h *Harness) AssertEmbed(t testing.TB, title string) {
+	t.Helper()
+
+	for _, embed := range h.Embeds() {
+		if embed.Title == title {
+			return
+		}
+	}
+	t.Errorf("No embed titled %q sent", title)
+}
+
+// AssertReacted fails the test unless the bot reacted with the emoji
+func (h *Harness) AssertReacted(t testing.TB, emoji string) {
+	t.Helper()
+
+	for _, r := range h.Reactions() {
+		if r.Emoji == emoji {
+			return
+		}
+	}
+	t.Errorf("No reaction %q added", emoji)
+}
+
+// AssertDM fails the test unless the bot sent the user a DM with exactly the
+// content
+func (h *Harness) AssertDM(t testing.TB, userID, content string) {
+	t.Helper()
+
+	for _, msg := range h.DMs(userID) {
+		if msg.Content == content {
+			return
+		}
+	}
+	t.Errorf("No DM %q sent to %s; sent %s",
+		content, userID, contents(h.DMs(userID)))
+}
+
+// contents lists the contents of messages for failure messages
+func contents(messages []*discordgo.Message) string {
+	quoted := make([]string, len(messages))
+	for i, msg := range messages {
+		quoted[i] = strconv.Quote(msg.Content)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// Replay feeds the recorded invocations back through the mux one by one,
+// returning the messages the bot sent for each
+func (h *Harness) Replay(
+	recorded []disgomux.RecordedInvocation,
+) [][]*discordgo.Message {
+	sent := make([][]*discordgo.Message, len(recorded))
+	for i := range recorded {
+		before := len(h.Mock.Sent())
+		h.Mux.Replay(h.Session, recorded[i:i+1])
+
+		/* Responses deleted by the bot in the meantime are left out */
+		if after := h.Mock.Sent(); len(after) > before {
+			sent[i] = after[before:]
+		}
+	}
+	return sent
+}
+
+// AssertReplay replays the recorded invocations and fails the test for each
+// whose responses differ in content from the recorded ones
+func (h *Harness) AssertReplay(
+	t testing.TB,
+	recorded []disgomux.RecordedInvocation,
+) {
+	t.Helper()
+
+	for i, sent := range h.Replay(recorded) {
+		want, got := contents(recorded[i].Responses), contents(sent)
+		if want != got {
+			t.Errorf("Invocation %d responded %s, recorded %s", i+1, got, want)
+		}
+	}
+}
@@ -0,0 +1,848 @@
+package muxtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/CS-5/disgomux"
+	"github.com/CS-5/disgomux/muxtest"
+	"github.com/bwmarrin/discordgo"
+)
+
+// command is a test command running handle
+type command struct {
+	name     string
+	handle   func(ctx *disgomux.Context)
+	settings disgomux.CommandSettings
+	calls    int
+}
+
+func (c *command) Init(m *disgomux.Mux) {}
+func (c *command) Handle(ctx *disgomux.Context) {
+	c.calls++
+	if c.handle != nil {
+		c.handle(ctx)
+	}
+}
+func (c *command) HandleHelp(ctx *disgomux.Context) bool     { return false }
+func (c *command) Permissions() *disgomux.CommandPermissions { return nil }
+func (c *command) Settings() *disgomux.CommandSettings {
+	s := c.settings
+	s.Command, s.HelpText = c.name, "Test command"
+	return &s
+}
+
+// harness creates a harness around a mux with the "!" prefix and the commands
+func harness(t *testing.T, commands ...disgomux.Command) *muxtest.Harness {
+	t.Helper()
+
+	m, err := disgomux.New("!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Register(commands...)
+	if err := m.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	return muxtest.New(m)
+}
+
+func TestHarnessSent(t *testing.T) {
+	h := harness(t, &command{name: "ping", handle: func(ctx *disgomux.Context) {
+		ctx.ChannelSend("pong")
+	}})
+
+	h.AssertNothingSent(t)
+	h.Send("!ping")
+	h.AssertSent(t, "pong")
+	h.AssertSentContains(t, "on")
+
+	sent := h.Sent()
+	if len(sent) != 1 || sent[0].ChannelID != muxtest.ChannelID {
+		t.Fatalf("sent %v, want one message in %s", sent, muxtest.ChannelID)
+	}
+
+	h.Reset()
+	h.AssertNothingSent(t)
+}
+
+func TestHarnessDMs(t *testing.T) {
+	h := harness(t, &command{name: "whisper", handle: func(ctx *disgomux.Context) {
+		ctx.DMSend("psst")
+	}})
+
+	h.Send("!whisper")
+	h.AssertDM(t, muxtest.UserID, "psst")
+	if sent := h.Sent(); len(sent) != 0 {
+		t.Errorf("DMs listed with the channel messages: %v", sent)
+	}
+}
+
+func TestHarnessEmbedsAndReactions(t *testing.T) {
+	h := harness(t, &command{name: "card", handle: func(ctx *disgomux.Context) {
+		ctx.ChannelSendEmbed(&discordgo.MessageEmbed{Title: "Card"})
+		ctx.React("👍")
+	}})
+
+	msg := h.Send("!card")
+	h.AssertEmbed(t, "Card")
+	h.AssertReacted(t, "👍")
+
+	reactions := h.Reactions()
+	if len(reactions) != 1 || reactions[0].MessageID != msg.ID {
+		t.Errorf("reactions %v, want one on %s", reactions, msg.ID)
+	}
+}
+
+func TestHarnessMembers(t *testing.T) {
+	var roles []string
+	h := harness(t, &command{name: "roles", handle: func(ctx *disgomux.Context) {
+		if member, err := ctx.Member(); err == nil {
+			roles = member.Roles
+		}
+	}})
+
+	other := &discordgo.User{ID: "401", Username: "other"}
+	h.AddMember(other, "900")
+	h.SendAs(other, "!roles")
+	if len(roles) != 1 || roles[0] != "900" {
+		t.Errorf("roles %v, want [900]", roles)
+	}
+}
+
+func TestRouting(t *testing.T) {
+	var args []string
+	echo := &command{
+		name: "echo",
+		handle: func(ctx *disgomux.Context) {
+			args = ctx.Arguments
+			ctx.ChannelSend(strings.Join(ctx.Arguments, " "))
+		},
+		settings: disgomux.CommandSettings{Aliases: []string{"say"}},
+	}
+	h := harness(t, echo)
+
+	h.Send("!echo hello world")
+	h.AssertSent(t, "hello world")
+	if len(args) != 2 || args[0] != "hello" || args[1] != "world" {
+		t.Errorf("arguments %q", args)
+	}
+
+	h.Reset()
+	h.Send("!say hi")
+	h.AssertSent(t, "hi")
+
+	h.Reset()
+	h.Send("echo without prefix")
+	h.AssertNothingSent(t)
+
+	bot := h.Session.State.User
+	h.SendAs(bot, "!echo from the bot")
+	h.AssertNothingSent(t)
+
+	calls := echo.calls
+	h.Send("!unknown")
+	h.AssertSent(t, "Command not found.")
+	if echo.calls != calls {
+		t.Error("unknown command handled by echo")
+	}
+}
+
+func TestChainStopsAtRejectedSimpleCommand(t *testing.T) {
+	lock := &command{name: "lock"}
+	h := harness(t, lock)
+	h.Mux.SetChaining("&&", 0)
+	h.Mux.RegisterSimple(disgomux.SimpleCommand{
+		Command:     "secret",
+		Content:     "the secret",
+		Permissions: &disgomux.CommandPermissions{UserIDs: []string{"999"}},
+	})
+
+	h.Send("!secret && !lock")
+	if lock.calls != 0 {
+		t.Error("chain went on after the simple command was rejected")
+	}
+	for _, msg := range h.Sent() {
+		if msg.Content == "the secret" {
+			t.Error("rejected simple command responded")
+		}
+	}
+
+	h.Send("!lock && !lock")
+	if lock.calls != 2 {
+		t.Errorf("lock ran %d times in a chain of two, want 2", lock.calls)
+	}
+}
+
+func TestChainPropagatesArguments(t *testing.T) {
+	var runs []string
+	clean := &command{name: "clean", handle: func(ctx *disgomux.Context) {
+		runs = append(runs, "clean "+strings.Join(ctx.Arguments, ","))
+		ctx.Chain.Set("cleaned", ctx.Arguments[0])
+	}}
+	say := &command{name: "say", handle: func(ctx *disgomux.Context) {
+		cleaned, _ := ctx.Chain.Get("cleaned")
+		runs = append(runs, fmt.Sprintf("say %s after %v at %d of %d",
+			strings.Join(ctx.Arguments, ","), cleaned, ctx.Chain.Index,
+			len(ctx.Chain.Commands)))
+	}}
+	h := harness(t, clean, say)
+	h.Mux.SetChaining("&&", 3)
+
+	h.Send("!clean 10 && say done here && !say twice")
+	want := []string{
+		"clean 10",
+		"say done,here after 10 at 1 of 3",
+		"say twice after 10 at 2 of 3",
+	}
+	if strings.Join(runs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ran %q, want %q", runs, want)
+	}
+
+	/* Each message has its own chain values */
+	runs = nil
+	h.Send("!say alone && say again")
+	if len(runs) != 2 || !strings.Contains(runs[0], "after <nil>") {
+		t.Errorf("ran %q, want no values from the previous chain", runs)
+	}
+
+	runs = nil
+	h.Send("!say 1 && say 2 && say 3 && say 4")
+	if len(runs) != 0 {
+		t.Errorf("ran %q over the chain limit", runs)
+	}
+	h.AssertSent(t, "You can chain at most 3 commands.")
+}
+
+func TestLongMessagesStayWithinLimit(t *testing.T) {
+	content := strings.Repeat("a", 1993) + "\n```go\nfmt.Println()\n```\n" +
+		strings.Repeat("```\n"+strings.Repeat("b", 300)+"\n```\n", 20)
+	h := harness(t, &command{name: "long", handle: func(ctx *disgomux.Context) {
+		ctx.ChannelSendLong(content)
+	}})
+
+	h.Send("!long")
+	sent := h.Sent()
+	if len(sent) < 2 {
+		t.Fatalf("sent %d messages, want the content split", len(sent))
+	}
+	for i, msg := range sent {
+		if n := utf8.RuneCountInString(msg.Content); n > disgomux.MessageLimit {
+			t.Errorf("message %d has %d characters", i, n)
+		}
+	}
+}
+
+func TestCacheHit(t *testing.T) {
+	lookup := &command{
+		name: "lookup",
+		handle: func(ctx *disgomux.Context) {
+			ctx.ChannelSend("result for " + strings.Join(ctx.Arguments, " "))
+		},
+		settings: disgomux.CommandSettings{
+			Cache: &disgomux.Cache{TTL: time.Minute},
+		},
+	}
+	h := harness(t, lookup)
+
+	h.Send("!lookup thing")
+	h.Send("!lookup  THING")
+	if lookup.calls != 1 {
+		t.Errorf("handler ran %d times, want the second served from cache",
+			lookup.calls)
+	}
+
+	sent := h.Sent()
+	if len(sent) != 2 || sent[1].Content != sent[0].Content {
+		t.Fatalf("sent %s, want the cached response replayed", contents(sent))
+	}
+
+	h.Send("!lookup other")
+	if lookup.calls != 2 {
+		t.Error("different arguments served from cache")
+	}
+
+	h.Mux.ClearResponseCache()
+	h.Send("!lookup thing")
+	if lookup.calls != 3 {
+		t.Error("response served after clearing the cache")
+	}
+}
+
+// contents quotes the contents of messages for failure messages
+func contents(messages []*discordgo.Message) string {
+	quoted := make([]string, len(messages))
+	for i, msg := range messages {
+		quoted[i] = "\"" + msg.Content + "\""
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func TestMiddlewarePanicIsReported(t *testing.T) {
+	ping := &command{name: "ping"}
+	h := harness(t, ping)
+
+	var reported error
+	h.Mux.SetErrorReporter(disgomux.ReporterFunc(
+		func(err error, fields map[string]string) { reported = err },
+	))
+	h.Mux.UseMiddleware(func(ctx *disgomux.Context) { panic("boom") })
+
+	h.Send("!ping")
+	var p *disgomux.PanicError
+	if !errors.As(reported, &p) || p.Value != "boom" {
+		t.Fatalf("reported %v, want the panic", reported)
+	}
+	if ping.calls != 1 {
+		t.Error("handler did not run after the middleware panicked")
+	}
+}
+
+func TestSimpleTemplate(t *testing.T) {
+	h := harness(t)
+	err := h.Mux.RegisterSimple(disgomux.SimpleCommand{
+		Command: "where",
+		Content: "{{.User.Username}} is in {{.Channel}} ({{.Channel.Name}}) " +
+			"of {{.Guild}} ({{.Guild.Name}})",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Send("!where")
+	h.AssertSent(t, "user is in <#300> (general) of Guild (Guild)")
+
+	err = h.Mux.RegisterSimple(disgomux.SimpleCommand{
+		Command: "broken",
+		Content: "{{.User",
+	})
+	var lint *disgomux.LintError
+	if !errors.As(err, &lint) || lint.Problems[0].Command != "broken" {
+		t.Fatalf("registering a broken template returned %v", err)
+	}
+	if h.Mux.Initialize() == nil {
+		t.Error("Initialize() did not report the broken template")
+	}
+}
+
+func TestSimpleTemplateExecutionError(t *testing.T) {
+	h := harness(t)
+	var logged []string
+	h.Mux.SetLogger(disgomux.LoggerFunc(
+		func(level disgomux.LogLevel, msg string, fields map[string]string) {
+			if level == disgomux.LogWarn {
+				logged = append(logged, msg)
+			}
+		},
+	))
+	h.Mux.RegisterSimple(disgomux.SimpleCommand{
+		Command: "first",
+		Content: "{{index .Arguments 0}}",
+	})
+
+	h.Send("!first")
+	h.AssertSent(t, "{{index .Arguments 0}}")
+	if len(logged) != 1 || !strings.Contains(logged[0], "first") {
+		t.Errorf("logged %q, want the execution error", logged)
+	}
+}
+
+func TestSimpleFileErrors(t *testing.T) {
+	h := harness(t)
+	h.Mux.RegisterSimple(disgomux.SimpleCommand{
+		Command: "logo",
+		Aliases: []string{"icon"},
+		File:    &disgomux.SimpleFile{Path: "testdata/missing.png"},
+	})
+
+	var lint *disgomux.LintError
+	if err := h.Mux.Initialize(); !errors.As(err, &lint) {
+		t.Fatalf("Initialize() returned %v, want the missing file", err)
+	}
+	if len(lint.Problems) != 1 || lint.Problems[0].Command != "logo" {
+		t.Errorf("problems %v, want one for logo", lint.Problems)
+	}
+}
+
+func TestPanicReportFitsInMessage(t *testing.T) {
+	h := harness(t, &command{name: "boom", handle: func(ctx *disgomux.Context) {
+		panic(strings.Repeat("ü", 3000))
+	}})
+	h.Mux.SetPanicChannel("999")
+
+	h.Send("!boom")
+	posted := false
+	for _, msg := range h.Sent() {
+		if msg.ChannelID != "999" {
+			continue
+		}
+		posted = true
+		if n := utf8.RuneCountInString(msg.Content); n > disgomux.MessageLimit {
+			t.Errorf("panic report has %d characters", n)
+		}
+	}
+	if !posted {
+		t.Error("panic not posted to the panic channel")
+	}
+}
+
+func TestShutdownWaitsForTimedOutHandler(t *testing.T) {
+	release := make(chan struct{})
+	slow := &command{
+		name:     "slow",
+		handle:   func(ctx *disgomux.Context) { <-release },
+		settings: disgomux.CommandSettings{Timeout: 10 * time.Millisecond},
+	}
+	h := harness(t, slow)
+	h.Send("!slow")
+
+	waiting, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := h.Mux.Shutdown(waiting); err != context.DeadlineExceeded {
+		t.Fatalf("Shutdown() returned %v while the handler still runs", err)
+	}
+
+	close(release)
+	if err := h.Mux.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() returned %v once the handler returned", err)
+	}
+}
+
+func TestHasPermissions(t *testing.T) {
+	var manage, admin bool
+	h := harness(t, &command{name: "perms", handle: func(ctx *disgomux.Context) {
+		manage = ctx.HasPermissions(discordgo.PermissionManageMessages)
+		admin = ctx.HasPermissions(discordgo.PermissionAdministrator)
+	}})
+	h.Guild.Roles = append(h.Guild.Roles, &discordgo.Role{
+		ID:          muxtest.GuildID,
+		Permissions: discordgo.PermissionManageMessages,
+	})
+
+	member := &discordgo.User{ID: "402", Username: "member"}
+	h.AddMember(member)
+	h.SendAs(member, "!perms")
+	if !manage || admin {
+		t.Errorf("manage %v and admin %v, want only manage", manage, admin)
+	}
+	if calls := len(h.Mock.Calls()); calls != 0 {
+		t.Errorf("%d requests made, want permissions from the state", calls)
+	}
+}
+
+func TestAllowedMentionsDefault(t *testing.T) {
+	h := harness(t, &command{name: "say", handle: func(ctx *disgomux.Context) {
+		ctx.ChannelSend(strings.Join(ctx.Arguments, " "))
+		ctx.Reply(strings.Join(ctx.Arguments, " "))
+	}})
+
+	parsed := func() [][]discordgo.AllowedMentionType {
+		var policies [][]discordgo.AllowedMentionType
+		for _, call := range h.Mock.Calls() {
+			if call.Method == "ChannelMessageSendComplex" {
+				ms := call.Args[1].(*discordgo.MessageSend)
+				policies = append(policies, ms.AllowedMentions.Parse)
+			}
+		}
+		return policies
+	}
+
+	h.Send("!say @everyone")
+	if len(parsed()) != 2 {
+		t.Fatalf("sent %d messages, want 2", len(parsed()))
+	}
+	for _, parse := range parsed() {
+		if len(parse) != 1 || parse[0] != discordgo.AllowedMentionTypeUsers {
+			t.Errorf("parsed %v by default, want users only", parse)
+		}
+	}
+
+	h.Reset()
+	h.Mux.SetAllowedMentions(&discordgo.MessageAllowedMentions{
+		Parse: []discordgo.AllowedMentionType{
+			discordgo.AllowedMentionTypeEveryone,
+		},
+	})
+	h.Send("!say @everyone")
+	for _, parse := range parsed() {
+		if len(parse) != 1 || parse[0] != discordgo.AllowedMentionTypeEveryone {
+			t.Errorf("parsed %v, want the policy set", parse)
+		}
+	}
+}
+
+func TestVerificationIsLocalized(t *testing.T) {
+	v := &disgomux.Verification{Timeout: 10 * time.Millisecond, Retries: 1}
+	h := harness(t, &command{name: "verify", handle: func(ctx *disgomux.Context) {
+		v.Run(ctx)
+	}})
+
+	catalog := disgomux.NewCatalog("fr")
+	err := catalog.AddAll("fr", map[string]string{
+		disgomux.MessageVerifyPrompt: "Prouvez que vous êtes humain.",
+		disgomux.MessageVerifyReact:  "Réagissez avec",
+		disgomux.MessageVerifyFailed: "Échec de la vérification.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Mux.SetCatalog(catalog)
+	h.Mux.SetDefaultLocale("fr")
+
+	h.Send("!verify")
+	h.AssertSent(t, "Prouvez que vous êtes humain. Réagissez avec ✅")
+	h.AssertSent(t, "Échec de la vérification.")
+}
+
+func TestEmptyCooldownTextSendsNothing(t *testing.T) {
+	ping := &command{
+		name:   "ping",
+		handle: func(ctx *disgomux.Context) { ctx.ChannelSend("pong") },
+		settings: disgomux.CommandSettings{
+			Cooldown: &disgomux.Cooldown{Duration: time.Minute},
+		},
+	}
+	h := harness(t, ping)
+	h.Mux.SetErrors(disgomux.ErrorTexts{CommandNotFound: "Command not found."})
+
+	h.Send("!ping")
+	h.Reset()
+	h.Send("!ping")
+	h.AssertNothingSent(t)
+	if ping.calls != 1 {
+		t.Errorf("handler ran %d times, want the second on cooldown", ping.calls)
+	}
+}
+
+func TestInvalidArgumentsKeepCooldownAndQuota(t *testing.T) {
+	roll := &command{
+		name: "roll",
+		settings: disgomux.CommandSettings{
+			Arguments: []disgomux.Argument{{
+				Name: "sides", Type: disgomux.ArgumentInteger, Required: true,
+			}},
+			Cooldown: &disgomux.Cooldown{Duration: time.Minute},
+			Quota:    &disgomux.Quota{Limit: 1, Period: disgomux.QuotaDaily},
+		},
+	}
+	h := harness(t, roll)
+
+	h.Send("!roll six")
+	h.Send("!roll 6")
+	if roll.calls != 1 {
+		t.Fatalf("handler ran %d times after a mistyped invocation, want 1",
+			roll.calls)
+	}
+}
+
+func TestCacheHitKeepsQuota(t *testing.T) {
+	lookup := &command{
+		name: "lookup",
+		handle: func(ctx *disgomux.Context) {
+			ctx.ChannelSend("result for " + strings.Join(ctx.Arguments, " "))
+		},
+		settings: disgomux.CommandSettings{
+			Cache: &disgomux.Cache{TTL: time.Minute},
+			Quota: &disgomux.Quota{Limit: 2, Period: disgomux.QuotaDaily},
+		},
+	}
+	h := harness(t, lookup)
+
+	h.Send("!lookup thing")
+	h.Send("!lookup thing")
+	h.Send("!lookup other")
+	if lookup.calls != 2 {
+		t.Errorf("handler ran %d times, want the cached response to keep "+
+			"the quota", lookup.calls)
+	}
+}
+
+func TestErrorTextsFollowMentionsPolicy(t *testing.T) {
+	h := harness(t)
+	h.Mux.SetErrors(disgomux.ErrorTexts{
+		CommandNotFound: "No command {{.Command}}",
+	})
+
+	h.Send("!@everyone")
+	h.AssertSent(t, "No command @everyone")
+
+	var policies int
+	for _, call := range h.Mock.Calls() {
+		if call.Method != "ChannelMessageSendComplex" {
+			continue
+		}
+		policies++
+		parse := call.Args[1].(*discordgo.MessageSend).AllowedMentions.Parse
+		if len(parse) != 1 || parse[0] != discordgo.AllowedMentionTypeUsers {
+			t.Errorf("parsed %v, want users only", parse)
+		}
+	}
+	if policies != 1 {
+		t.Errorf("sent %d messages with a mentions policy, want 1", policies)
+	}
+}
+
+func TestDeferredEphemeralReply(t *testing.T) {
+	h := harness(t, &command{name: "secret", handle: func(ctx *disgomux.Context) {
+		if err := ctx.Defer(); err != nil {
+			t.Error(err)
+		}
+		ctx.ReplyEphemeral("only you")
+	}})
+
+	h.Slash("secret")
+
+	var followup *discordgo.WebhookParams
+	var deleted bool
+	for _, call := range h.Mock.Calls() {
+		switch call.Method {
+		case "FollowupMessageCreate":
+			followup = call.Args[1].(*discordgo.WebhookParams)
+		case "InteractionResponseEdit":
+			t.Error("edited the public deferred response")
+		case "InteractionResponseDelete":
+			deleted = true
+		}
+	}
+	if followup == nil ||
+		followup.Flags&discordgo.MessageFlagsEphemeral == 0 {
+		t.Fatalf("sent %+v, want an ephemeral followup", followup)
+	}
+	if !deleted {
+		t.Error("thinking indicator left behind")
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	h := harness(t, &command{name: "ping", handle: func(ctx *disgomux.Context) {
+		ctx.ChannelSend("pong")
+	}})
+	h.Mux.Options(disgomux.NewOptions(false, true, true, true))
+	h.Mux.SetSynchronous(true)
+
+	bot := &discordgo.User{ID: "500", Username: "other", Bot: true}
+	h.AddMember(bot)
+	h.SendAs(bot, "!ping")
+	h.AssertSent(t, "pong")
+}
+
+func TestTagCommand(t *testing.T) {
+	h := harness(t,
+		disgomux.NewTagCommand(nil),
+		&command{name: "ping"},
+	)
+
+	h.Send("!tag add rules Be nice")
+	h.AssertSent(t, "Added the tag `rules`")
+	h.Send("!rules")
+	h.AssertSent(t, "Be nice")
+
+	h.Send("!tag add rules Be mean")
+	h.AssertSent(t, "There is already a tag `rules`")
+	h.Send("!tag add ping pong")
+	h.AssertSent(t, "There is already a command `ping`")
+
+	h.Send("!tag list")
+	h.AssertSent(t, "Tags: `rules`")
+
+	h.Send("!tag remove rules")
+	h.AssertSent(t, "Removed the tag `rules`")
+	h.Send("!tag remove rules")
+	h.AssertSent(t, "There is no tag `rules`")
+	h.Send("!tag list")
+	h.AssertSent(t, "There are no tags.")
+}
+
+func TestTagCommandIsLocalized(t *testing.T) {
+	h := harness(t, disgomux.NewTagCommand(nil))
+
+	catalog := disgomux.NewCatalog("fr")
+	err := catalog.AddAll("fr", map[string]string{
+		disgomux.MessageTagAdded: "Tag ajouté :",
+		disgomux.MessageNoTags:   "Il n'y a aucun tag.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Mux.SetCatalog(catalog)
+	h.Mux.SetDefaultLocale("fr")
+
+	h.Send("!tag list")
+	h.AssertSent(t, "Il n'y a aucun tag.")
+	h.Send("!tag add rules Soyez gentils")
+	h.AssertSent(t, "Tag ajouté : `rules`")
+}
+
+func TestTagPlaceholders(t *testing.T) {
+	h := harness(t, disgomux.NewTagCommand(nil))
+
+	h.Send("!tag add hi Hi {username}, welcome to {guild} in {channel}: {args}")
+	h.Send("!hi there")
+	h.AssertSent(t, "Hi user, welcome to Guild in <#300>: there")
+
+	/* Templates are sent as written, so tags can't read the invocation */
+	h.Send("!tag add probe {{.Member.Roles}} {{.User.Email}}")
+	h.Send("!probe")
+	h.AssertSent(t, "{{.Member.Roles}} {{.User.Email}}")
+}
+
+func TestGatewayAccessors(t *testing.T) {
+	var bot *discordgo.User
+	var latency time.Duration
+	h := harness(t, &command{name: "whoami", handle: func(ctx *disgomux.Context) {
+		bot, latency = ctx.BotUser(), ctx.Latency()
+	}})
+
+	h.Send("!whoami")
+	if bot == nil || bot.ID != muxtest.BotID {
+		t.Errorf("bot user %v, want %s", bot, muxtest.BotID)
+	}
+	if latency != 0 {
+		t.Errorf("latency %s without heartbeats", latency)
+	}
+}
+
+func TestMacroExpandsOnce(t *testing.T) {
+	var got []string
+	echo := &command{name: "echo", handle: func(ctx *disgomux.Context) {
+		got = append(got, ctx.Macro+": "+strings.Join(ctx.Arguments, " "))
+	}}
+	h := harness(t, echo)
+
+	if err := h.Mux.SetMacro(muxtest.GuildID, "shout", "echo {*}!"); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Mux.SetMacro(muxtest.GuildID, "nested", "shout {1}"); err == nil {
+		t.Error("defined a macro expanding to a macro")
+	}
+
+	h.Send("!shout hello there")
+	if len(got) != 1 || got[0] != "shout: hello there!" {
+		t.Fatalf("ran %q, want the expansion", got)
+	}
+
+	/* Macros stored in a loop are expanded once, not forever */
+	_, err := h.Mux.UpdateGuildConfig(muxtest.GuildID, func(c *disgomux.GuildConfig) {
+		c.Macros["ping"] = "pong {*}"
+		c.Macros["pong"] = "ping {*}"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Reset()
+	h.Send("!ping x")
+	h.AssertSent(t, "Command not found.")
+
+	h.Reset()
+	h.Send("!shout")
+	if len(got) != 2 || got[1] != "shout: !" {
+		t.Errorf("ran %q, want {*} empty", got)
+	}
+}
+
+func TestMacroMissingArgument(t *testing.T) {
+	mute := &command{name: "mute"}
+	h := harness(t, mute)
+	if err := h.Mux.SetMacro(muxtest.GuildID, "mute7d", "mute {1} 7d"); err != nil {
+		t.Fatal(err)
+	}
+
+	h.Send("!mute7d")
+	h.AssertSentContains(t, "Invalid arguments")
+	if mute.calls != 0 {
+		t.Error("macro ran without its argument")
+	}
+}
+
+// parent is a test command with subcommands
+type parent struct {
+	command
+	subs []*disgomux.Subcommand
+}
+
+func (p *parent) Subcommands() []*disgomux.Subcommand { return p.subs }
+
+func TestSubcommandRouting(t *testing.T) {
+	var runs []string
+	handler := func(name string) func(ctx *disgomux.Context) {
+		return func(ctx *disgomux.Context) {
+			runs = append(runs, name+" "+ctx.Subcommand+": "+
+				strings.Join(ctx.Arguments, ","))
+		}
+	}
+	config := &parent{
+		command: command{name: "config", handle: handler("config")},
+		subs: []*disgomux.Subcommand{
+			{Name: "get", HelpText: "Get a setting", Handler: handler("get")},
+			{Name: "admin", HelpText: "Admin only", Handler: handler("admin"),
+				Permissions: &disgomux.CommandPermissions{
+					UserIDs: []string{"999"},
+				}},
+			{Name: "role", HelpText: "Manage roles", Subcommands: []*disgomux.Subcommand{
+				{Name: "add", HelpText: "Add a role", Handler: handler("add"),
+					Arguments: []disgomux.Argument{
+						{Name: "role", Required: true},
+						{Name: "reason"},
+					}},
+			}},
+		},
+	}
+	h := harness(t, config)
+
+	tests := []struct {
+		content, want string
+	}{
+		{"!config get prefix", "get get: prefix"},
+		{"!config GET prefix", "get get: prefix"},
+		{"!config role add mods being nice", "add role add: mods,being,nice"},
+		{"!config unknown x", "config : unknown,x"},
+		{"!config role x", "config : role,x"},
+		{"!config", "config : "},
+	}
+	for _, tt := range tests {
+		runs = nil
+		h.Send(tt.content)
+		if len(runs) != 1 || runs[0] != tt.want {
+			t.Errorf("%q ran %q, want %q", tt.content, runs, tt.want)
+		}
+	}
+
+	/* Arguments are validated against the subcommand */
+	runs = nil
+	h.Reset()
+	h.Send("!config role add")
+	if len(runs) != 0 {
+		t.Errorf("ran %q without the required argument", runs)
+	}
+	h.AssertSentContains(t, "Invalid arguments")
+
+	/* The permissions of a subcommand override those of its parent */
+	runs = nil
+	h.Send("!config admin")
+	if len(runs) != 0 {
+		t.Errorf("ran %q without the permissions of the subcommand", runs)
+	}
+
+	runs = nil
+	h.Slash("config", &discordgo.ApplicationCommandInteractionDataOption{
+		Name: "role",
+		Type: discordgo.ApplicationCommandOptionSubCommandGroup,
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+			Name: "add",
+			Type: discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+				Name:  "role",
+				Type:  discordgo.ApplicationCommandOptionString,
+				Value: "mods",
+			}},
+		}},
+	})
+	if len(runs) != 1 || runs[0] != "add role add: mods" {
+		t.Errorf("slash command ran %q, want role add", runs)
+	}
+}
@@ -0,0 +1,298 @@
+package disgomux
+
+import "fmt"
+
+// The setters below change a single option, and are safe to call while the bot
+// is live, e.g. from an admin command. Invocations already routed keep the
+// options they started with.
+
+// SetIgnoreBots sets whether messages from bots are ignored
+func (m *Mux) SetIgnoreBots(ignore bool) {
+	m.updateOptions(func(o *Options) { o.IgnoreBots = ignore })
+}
+
+// SetIgnoreDMs sets whether direct messages are ignored. See SetDMRouting()
+// to handle them differently from guild channels instead.
+func (m *Mux) SetIgnoreDMs(ignore bool) {
+	m.updateOptions(func(o *Options) { o.IgnoreDMs = ignore })
+}
+
+// SetIgnoreEmpty sets whether messages without content are ignored
+func (m *Mux) SetIgnoreEmpty(ignore bool) {
+	m.updateOptions(func(o *Options) { o.IgnoreEmpty = ignore })
+}
+
+// SetIgnoreNonDefault sets whether messages which are not of the default type
+// (e.g. pins or joins) are ignored
+func (m *Mux) SetIgnoreNonDefault(ignore bool) {
+	m.updateOptions(func(o *Options) { o.IgnoreNonDefault = ignore })
+}
+
+// SetIgnoreUnknown sets whether prefixed messages which don't match a command
+// are ignored rather than replied to
+func (m *Mux) SetIgnoreUnknown(ignore bool) {
+	m.updateOptions(func(o *Options) { o.IgnoreUnknown = ignore })
+}
+
+// SetIgnoreThreads sets whether messages in threads, including forum posts, are
+// ignored
+func (m *Mux) SetIgnoreThreads(ignore bool) {
+	m.updateOptions(func(o *Options) { o.IgnoreThreads = ignore })
+}
+
+// NewOptions creates options with the four original settings, in the order of
+// the positional literals used before Options grew, e.g.
+// m.Options(NewOptions(true, true, true, true)). Later fields are false.
+func NewOptions(
+	ignoreBots, ignoreDMs, ignoreEmpty, ignoreNonDefault bool,
+) *Options {
+	return &Options{
+		IgnoreBots:       ignoreBots,
+		IgnoreDMs:        ignoreDMs,
+		IgnoreEmpty:      ignoreEmpty,
+		IgnoreNonDefault: ignoreNonDefault,
+	}
+}
+
+// SetSynchronous sets whether middleware and handlers run in the goroutine
+// calling Handle()
+func (m *Mux) SetSynchronous(synchronous bool) {
+	m.updateOptions(func(o *Options) { o.Synchronous = synchronous })
+}
+
+// SetSerializeChannels sets whether at most one handler runs per channel at a
+// time
+func (m *Mux) SetSerializeChannels(serialize bool) {
+	m.updateOptions(func(o *Options) { o.SerializeChannels = serialize })
+}
+
+// SetPrefix replaces the prefix of the mux. Guilds with a prefix of their own
+// keep it. An invalid prefix (see ValidatePrefix()) is rejected.
+func (m *Mux) SetPrefix(prefix string) error {
+	if err := ValidatePrefix(prefix); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Prefix = prefix
+	return nil
+}
+
+// updateOptions applies update to a copy of the options, then swaps it in
+func (m *Mux) updateOptions(update func(o *Options)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o := *m.options
+	update(&o)
+	m.options = &o
+}
+
+type (
+	// Option configures a mux created with NewMux()
+	Option func(m *Mux) error
+
+	// OptionError is returned by NewMux() for an invalid option
+	OptionError struct {
+		Option string
+		Reason string
+
+		// Err is the sentinel error the option failed with, if any, e.g.
+		// ErrInvalidPrefix
+		Err error
+	}
+)
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("Invalid option %s: %s", e.Option, e.Reason)
+}
+
+// Unwrap returns the sentinel error the option failed with, for errors.Is()
+func (e *OptionError) Unwrap() error {
+	return e.Err
+}
+
+// NewMux creates a mux configured with options, e.g.
+//
+//	mux, err := disgomux.NewMux(
+//		disgomux.WithPrefix("!"),
+//		disgomux.WithFuzzy(),
+//		disgomux.IgnoreBots(false),
+//	)
+//
+// Options are applied in order over the defaults of New(). An invalid option
+// is reported as an *OptionError.
+func NewMux(options ...Option) (*Mux, error) {
+	m := newMux()
+	for _, o := range options {
+		if err := o(m); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// WithPrefix sets the prefix of the mux, a single character or emoji
+func WithPrefix(prefix string) Option {
+	return func(m *Mux) error {
+		if ValidatePrefix(prefix) != nil {
+			return &OptionError{
+				Option: "WithPrefix",
+				Reason: "prefix " + prefix + " greater than 1 character",
+				Err:    ErrInvalidPrefix,
+			}
+		}
+		m.Prefix = prefix
+		return nil
+	}
+}
+
+// WithFuzzy enables fuzzy matching of unknown commands, as InitializeFuzzy()
+func WithFuzzy() Option {
+	return func(m *Mux) error {
+		m.fuzzyMatch = true
+		return nil
+	}
+}
+
+// WithMatcher sets the matcher building fuzzy suggestions
+func WithMatcher(matcher Matcher) Option {
+	return func(m *Mux) error {
+		if matcher == nil {
+			return &OptionError{Option: "WithMatcher", Reason: "matcher is nil"}
+		}
+		m.matcher = matcher
+		return nil
+	}
+}
+
+// WithLogger sets the logger of the mux
+func WithLogger(logger Logger) Option {
+	return func(m *Mux) error {
+		m.logger = logger
+		return nil
+	}
+}
+
+// WithErrorReporter sets the reporter errors are sent to
+func WithErrorReporter(reporter ErrorReporter) Option {
+	return func(m *Mux) error {
+		m.reporter = reporter
+		return nil
+	}
+}
+
+// WithErrorTexts sets the error texts of the mux. Texts left empty keep their
+// defaults.
+func WithErrorTexts(texts ErrorTexts) Option {
+	return func(m *Mux) error {
+		defaults := m.errorTexts
+		for _, t := range []struct{ text, fallback *string }{
+			{&texts.CommandNotFound, &defaults.CommandNotFound},
+			{&texts.NoPermissions, &defaults.NoPermissions},
+			{&texts.Cooldown, &defaults.Cooldown},
+			{&texts.HandlerError, &defaults.HandlerError},
+			{&texts.Busy, &defaults.Busy},
+			{&texts.InvalidArguments, &defaults.InvalidArguments},
+			{&texts.QuotaExceeded, &defaults.QuotaExceeded},
+		} {
+			if *t.text == "" {
+				*t.text = *t.fallback
+			}
+		}
+		m.errorTexts = texts
+		return nil
+	}
+}
+
+// WithCooldownStore sets the store cooldowns are tracked in
+func WithCooldownStore(store CooldownStore) Option {
+	return func(m *Mux) error {
+		if store == nil {
+			return &OptionError{Option: "WithCooldownStore", Reason: "store is nil"}
+		}
+		m.cooldowns = store
+		return nil
+	}
+}
+
+// WithConfigStore sets the store guild configurations are kept in
+func WithConfigStore(store ConfigStore) Option {
+	return func(m *Mux) error {
+		if store == nil {
+			return &OptionError{Option: "WithConfigStore", Reason: "store is nil"}
+		}
+		m.configs = store
+		return nil
+	}
+}
+
+// WithWorkerPool runs handlers on a worker pool, as UseWorkerPool()
+func WithWorkerPool(workers, queueSize int) Option {
+	return func(m *Mux) error {
+		if workers <= 0 {
+			return &OptionError{Option: "WithWorkerPool", Reason: "workers must be positive"}
+		}
+		if queueSize < 0 {
+			return &OptionError{Option: "WithWorkerPool", Reason: "queue size is negative"}
+		}
+		m.UseWorkerPool(workers, queueSize)
+		return nil
+	}
+}
+
+// IgnoreBots sets whether messages from bots are ignored. Defaults to true.
+func IgnoreBots(ignore bool) Option {
+	return optionSetter(func(o *Options) { o.IgnoreBots = ignore })
+}
+
+// IgnoreDMs sets whether direct messages are ignored. Defaults to true.
+func IgnoreDMs(ignore bool) Option {
+	return optionSetter(func(o *Options) { o.IgnoreDMs = ignore })
+}
+
+// IgnoreEmpty sets whether messages without content are ignored. Defaults to
+// true.
+func IgnoreEmpty(ignore bool) Option {
+	return optionSetter(func(o *Options) { o.IgnoreEmpty = ignore })
+}
+
+// IgnoreNonDefault sets whether messages which are not of the default type are
+// ignored. Defaults to true.
+func IgnoreNonDefault(ignore bool) Option {
+	return optionSetter(func(o *Options) { o.IgnoreNonDefault = ignore })
+}
+
+// IgnoreUnknown sets whether prefixed messages which don't match a command are
+// ignored. Defaults to false.
+func IgnoreUnknown(ignore bool) Option {
+	return optionSetter(func(o *Options) { o.IgnoreUnknown = ignore })
+}
+
+// IgnoreThreads sets whether messages in threads, including forum posts, are
+// ignored. Defaults to false.
+func IgnoreThreads(ignore bool) Option {
+	return optionSetter(func(o *Options) { o.IgnoreThreads = ignore })
+}
+
+// Synchronous sets whether middleware and handlers run in the goroutine
+// calling Handle(). Defaults to false.
+func Synchronous(synchronous bool) Option {
+	return optionSetter(func(o *Options) { o.Synchronous = synchronous })
+}
+
+// SerializeChannels sets whether at most one handler runs per channel at a
+// time. Defaults to false.
+func SerializeChannels(serialize bool) Option {
+	return optionSetter(func(o *Options) { o.SerializeChannels = serialize })
+}
+
+// optionSetter makes an Option changing the Options of the mux
+func optionSetter(update func(o *Options)) Option {
+	return func(m *Mux) error {
+		m.updateOptions(update)
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package disgomux
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// outputRedirect is the channel an invocation's responses are redirected to
+type outputRedirect struct {
+	mu        sync.Mutex
+	channelID string
+	acked     bool
+}
+
+// RedirectOutput sends all further helper sends of this Context to another
+// channel, e.g. a log channel for a noisy report. The first redirected send
+// is acknowledged with a short note in the invoking channel. Takes precedence
+// over ReplyInThread(); an empty channelID ends the redirection.
+func (ctx *Context) RedirectOutput(channelID string) {
+	ctx.output.mu.Lock()
+	defer ctx.output.mu.Unlock()
+
+	ctx.output.channelID = channelID
+}
+
+// outputChannel returns the channel helper sends are redirected to, empty if
+// they are not, acknowledging the redirection the first time around
+func (ctx *Context) outputChannel() string {
+	ctx.output.mu.Lock()
+	channelID := ctx.output.channelID
+	if channelID == "" || channelID == ctx.Message.ChannelID {
+		ctx.output.mu.Unlock()
+		return ""
+	}
+	ack := !ctx.output.acked
+	ctx.output.acked = true
+	ctx.output.mu.Unlock()
+
+	if ack {
+		text := ctx.mux.localize(ctx, MessageRedirected, "Posted in")
+		_, err := ctx.send(ctx.Message.ChannelID, &discordgo.MessageSend{
+			Content: text + " <#" + channelID + ">.",
+		})
+		if err != nil {
+			ctx.mux.reportCtx(ctx, err)
+		}
+	}
+	return channelID
+}
+
+// outputChannelFor returns the channel the responses of an invocation of the
+// command are redirected to: the one set for it in the guild configuration,
+// else the OutputChannel of its settings
+func (m *Mux) outputChannelFor(ctx *Context, settings *CommandSettings) string {
+	if channelID := ctx.config.OutputChannels[ctx.Command]; channelID != "" {
+		return channelID
+	}
+	return settings.OutputChannel
+}
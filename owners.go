@@ -0,0 +1,31 @@
+package disgomux
+
+// SetOwners sets the IDs of the users owning the bot. Owners may run every
+// command, whatever its permissions.
+func (m *Mux) SetOwners(userIDs ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.owners = append([]string(nil), userIDs...)
+}
+
+// WithOwners sets the owners of the bot, as SetOwners()
+func WithOwners(userIDs ...string) Option {
+	return func(m *Mux) error {
+		m.SetOwners(userIDs...)
+		return nil
+	}
+}
+
+// IsOwner reports whether the author of the invocation owns the bot
+func (ctx *Context) IsOwner() bool {
+	return ctx.mux != nil && ctx.mux.isOwner(ctx.Message.Author.ID)
+}
+
+// isOwner reports whether the user owns the bot
+func (m *Mux) isOwner(userID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return arrayContains(m.owners, userID)
+}
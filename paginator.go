@@ -0,0 +1,139 @@
+package disgomux
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Paginator posts a series of embeds as a single message which the invoking
+// user can page through using reactions. Initialized with NewPaginator().
+type Paginator struct {
+	Pages []*discordgo.MessageEmbed
+
+	// Lifetime is how long the pages can be navigated for. Defaults to five
+	// minutes.
+	Lifetime time.Duration
+
+	// Previous and Next are the navigation emojis. Default to ◀ and ▶.
+	Previous, Next string
+
+	// DeleteOnTimeout deletes the message once the lifetime passes, instead of
+	// only removing the navigation reactions
+	DeleteOnTimeout bool
+
+	mu   sync.Mutex
+	page int
+}
+
+// NewPaginator creates a paginator for the supplied pages
+func NewPaginator(pages ...*discordgo.MessageEmbed) *Paginator {
+	return &Paginator{
+		Pages:    pages,
+		Lifetime: 5 * time.Minute,
+		Previous: "◀",
+		Next:     "▶",
+	}
+}
+
+// Send posts the first page to the current channel and handles navigation in
+// the background until the lifetime passes. Only the invoking user can
+// navigate.
+func (p *Paginator) Send(ctx *Context) (*discordgo.Message, error) {
+	if len(p.Pages) == 0 {
+		return nil, fmt.Errorf("Paginator has no pages")
+	}
+
+	msg, err := ctx.ChannelSendEmbed(p.render(0))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.Pages) == 1 {
+		return msg, nil
+	}
+
+	ctx.API().MessageReactionAdd(msg.ChannelID, msg.ID, p.Previous)
+	ctx.API().MessageReactionAdd(msg.ChannelID, msg.ID, p.Next)
+
+	userID := ctx.Message.Author.ID
+	cancel := ctx.mux.HandleReaction(ctx.Session, ReactionRoute{
+		MessageID: msg.ID,
+		Filter: func(r *discordgo.MessageReaction) bool {
+			return r.UserID == userID
+		},
+	}, func(session *discordgo.Session, r *discordgo.MessageReaction, _ bool) {
+		p.navigate(ctx.mux.api(session), msg, r)
+	})
+
+	/* Keep the mux from shutting down before the paginator is cleaned up */
+	if !ctx.mux.lifecycle.acquire() {
+		cancel()
+		return msg, nil
+	}
+
+	go func() {
+		defer ctx.mux.lifecycle.release()
+
+		timer := ctx.mux.clock().NewTimer(p.Lifetime)
+		defer timer.Stop()
+
+		/* Wrap up early if the mux shuts down */
+		select {
+		case <-timer.C():
+		case <-ctx.mux.lifecycle.closing():
+		}
+
+		cancel()
+		if p.DeleteOnTimeout {
+			ctx.API().ChannelMessageDelete(msg.ChannelID, msg.ID)
+			return
+		}
+		ctx.API().MessageReactionsRemoveAll(msg.ChannelID, msg.ID)
+	}()
+
+	return msg, nil
+}
+
+// navigate moves to the page selected by a reaction
+func (p *Paginator) navigate(
+	session Session,
+	msg *discordgo.Message,
+	r *discordgo.MessageReaction,
+) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch r.Emoji.Name {
+	case p.Previous:
+		if p.page == 0 {
+			return
+		}
+		p.page--
+	case p.Next:
+		if p.page == len(p.Pages)-1 {
+			return
+		}
+		p.page++
+	default:
+		return
+	}
+
+	/* Remove the user's reaction so the button can be pressed again */
+	session.MessageReactionRemove(msg.ChannelID, msg.ID, r.Emoji.APIName(), r.UserID)
+	session.ChannelMessageEditEmbed(msg.ChannelID, msg.ID, p.render(p.page))
+}
+
+// render returns the embed for page i, numbering it in the footer if the page
+// has no footer of its own
+func (p *Paginator) render(i int) *discordgo.MessageEmbed {
+	embed := *p.Pages[i]
+	if embed.Footer == nil {
+		embed.Footer = &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Page %d/%d", i+1, len(p.Pages)),
+		}
+	}
+	return &embed
+}
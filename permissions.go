@@ -0,0 +1,140 @@
+package disgomux
+
+import "github.com/bwmarrin/discordgo"
+
+// permissionCheck evaluates CommandPermissions for the author of a single
+// message. The author's guild member is fetched at most once, so one check can
+// be reused for several commands (e.g. when filtering suggestions).
+type permissionCheck struct {
+	session *discordgo.Session
+	api     Session
+	message *discordgo.MessageCreate
+	member  *discordgo.Member
+	channel *discordgo.Channel
+}
+
+func newPermissionCheck(
+	session *discordgo.Session,
+	api Session,
+	message *discordgo.MessageCreate,
+) *permissionCheck {
+	return &permissionCheck{session: session, api: api, message: message}
+}
+
+// allowed reports whether the message author satisfies p. A nil or empty set of
+// permissions allows everyone.
+func (pc *permissionCheck) allowed(p *CommandPermissions) (bool, error) {
+	if p == nil ||
+		(len(p.UserIDs) == 0 && len(p.RoleIDs) == 0 && len(p.ChanIDs) == 0 &&
+			p.Permissions == 0) {
+		return true, nil
+	}
+
+	/* Check if user explicitly has permission */
+	if arrayContains(p.UserIDs, pc.message.Author.ID) {
+		return true, nil
+	}
+
+	/* Check if one of the user's roles has permission */
+	if len(p.RoleIDs) != 0 {
+		member, err := pc.getMember()
+		if err != nil {
+			return false, err
+		}
+
+		for _, r := range member.Roles {
+			if arrayContains(p.RoleIDs, r) {
+				return true, nil
+			}
+		}
+	}
+
+	/* Check if the channel (or the channel of the thread) has permission */
+	if arrayContains(p.ChanIDs, pc.message.ChannelID) ||
+		(len(p.ChanIDs) != 0 && arrayContains(p.ChanIDs, pc.parentID())) {
+		return true, nil
+	}
+
+	/* Check if the user has the permission bits in the channel */
+	if p.Permissions != 0 {
+		perms, err := pc.channelPermissions()
+		if err != nil {
+			return false, err
+		}
+		if perms&p.Permissions == p.Permissions {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// HasPermissions reports whether the invoking user has all the permission bits
+// (discordgo.PermissionManageMessages...) in the channel of the invocation, the
+// parent channel in threads. They come with interactions, and are otherwise
+// computed from the session state, without requests to Discord.
+func (ctx *Context) HasPermissions(permissions int64) bool {
+	check := ctx.perms
+	if check == nil {
+		check = newPermissionCheck(ctx.Session, ctx.API(), ctx.Message)
+	}
+
+	perms, err := check.channelPermissions()
+	return err == nil && perms&permissions == permissions
+}
+
+// channelPermissions returns the permissions of the author in the channel.
+// Interactions come with them; for messages they are computed from the state,
+// in the parent channel for threads, which have no permissions of their own.
+func (pc *permissionCheck) channelPermissions() (int64, error) {
+	if pc.member != nil && pc.member.Permissions != 0 {
+		return pc.member.Permissions, nil
+	}
+
+	channelID := pc.message.ChannelID
+	if parentID := pc.parentID(); parentID != "" {
+		channelID = parentID
+	}
+	return pc.session.State.UserChannelPermissions(
+		pc.message.Author.ID, channelID,
+	)
+}
+
+// parentID returns the channel of the thread the message was sent in, or ""
+// if it wasn't sent in a thread
+func (pc *permissionCheck) parentID() string {
+	if pc.message.GuildID == "" {
+		return ""
+	}
+
+	if pc.channel == nil {
+		channel, err := lookupChannel(
+			pc.session.State, pc.api, pc.message.ChannelID,
+		)
+		if err != nil {
+			return ""
+		}
+		pc.channel = channel
+	}
+
+	if !pc.channel.IsThread() {
+		return ""
+	}
+	return pc.channel.ParentID
+}
+
+func (pc *permissionCheck) getMember() (*discordgo.Member, error) {
+	if pc.member != nil {
+		return pc.member, nil
+	}
+
+	member, err := pc.api.GuildMember(
+		pc.message.GuildID, pc.message.Author.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	pc.member = member
+	return member, nil
+}
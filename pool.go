@@ -0,0 +1,270 @@
+package disgomux
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what happens to an invocation arriving while the
+// worker pool queue is full
+type OverflowPolicy int
+
+const (
+	// OverflowDrop silently drops the new invocation. This is the default.
+	OverflowDrop OverflowPolicy = iota
+
+	// OverflowDropOldest drops the oldest queued invocation to make room
+	OverflowDropOldest
+
+	// OverflowReject drops the new invocation and replies with the Busy error
+	// text
+	OverflowReject
+
+	// OverflowBlock makes Handle() wait until there is room in the queue
+	OverflowBlock
+)
+
+// QueueStats is a snapshot of the worker pool queue
+type QueueStats struct {
+	Workers, Depth, Capacity int
+
+	// Submitted counts the invocations queued, Dropped the ones discarded
+	// under the overflow policy and Blocked the ones that had to wait for room
+	Submitted, Dropped, Blocked uint64
+
+	// Prioritized counts the invocations of priority commands, which bypass
+	// the queue (see CommandSettings.Priority)
+	Prioritized uint64
+}
+
+// task is a unit of work for the pool. drop is called instead of run if the
+// task is discarded under the overflow policy.
+type task struct {
+	run, drop func()
+}
+
+// workerPool runs handlers on a fixed number of goroutines, fed by a bounded
+// queue. mu is held for reading while submitting, so the queue is only closed
+// once no submitter can send to it.
+type workerPool struct {
+	jobs    chan task
+	workers int
+
+	mu      sync.RWMutex
+	stopped bool
+
+	submitted, dropped, blocked, prioritized uint64
+}
+
+// UseWorkerPool makes the mux run handlers on a fixed number of worker
+// goroutines instead of one goroutine per invocation. Up to queueSize
+// invocations wait for a free worker; what happens to invocations arriving
+// while the queue is full is set by SetOverflowPolicy(). It may be called while
+// the mux is handling events: the new pool takes the invocations dispatched
+// from then on, and the workers of the previous pool exit once they have run
+// what was queued.
+func (m *Mux) UseWorkerPool(workers, queueSize int) {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	p := &workerPool{
+		jobs:    make(chan task, queueSize),
+		workers: workers,
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for t := range p.jobs {
+				m.observeQueue()
+				t.run()
+			}
+		}()
+	}
+
+	m.mu.Lock()
+	old := m.pool
+	m.pool = p
+	m.mu.Unlock()
+
+	/* The workers of a previous pool finish its queue, then exit */
+	if old != nil {
+		old.stop()
+	}
+}
+
+// workers returns the worker pool in use, if any
+func (m *Mux) workers() *workerPool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.pool
+}
+
+// SetOverflowPolicy sets what happens to invocations arriving while the worker
+// pool queue is full. Must be called before Mux.Handle().
+func (m *Mux) SetOverflowPolicy(policy OverflowPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.overflow = policy
+}
+
+// overflowPolicy returns the worker pool overflow policy
+func (m *Mux) overflowPolicy() OverflowPolicy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.overflow
+}
+
+// QueueStats returns a snapshot of the worker pool queue, or the zero value if
+// no worker pool is in use
+func (m *Mux) QueueStats() QueueStats {
+	p := m.workers()
+	if p == nil {
+		return QueueStats{}
+	}
+
+	return QueueStats{
+		Workers:   p.workers,
+		Depth:     len(p.jobs),
+		Capacity:  cap(p.jobs),
+		Submitted: atomic.LoadUint64(&p.submitted),
+		Dropped:   atomic.LoadUint64(&p.dropped),
+		Blocked:   atomic.LoadUint64(&p.blocked),
+
+		Prioritized: atomic.LoadUint64(&p.prioritized),
+	}
+}
+
+// stop closes the queue of the pool once the submissions in progress are done,
+// making the workers exit when it is empty
+func (p *workerPool) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.stopped {
+		p.stopped = true
+		close(p.jobs)
+	}
+}
+
+// submit queues t, applying the overflow policy if the queue is full. It
+// reports false, leaving t alone, if the pool was stopped.
+func (p *workerPool) submit(t task, policy OverflowPolicy) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.stopped {
+		return false
+	}
+
+	select {
+	case p.jobs <- t:
+		atomic.AddUint64(&p.submitted, 1)
+		return true
+	default:
+	}
+
+	switch policy {
+	case OverflowBlock:
+		/* The workers keep draining the queue while stop() waits */
+		atomic.AddUint64(&p.blocked, 1)
+		p.jobs <- t
+		atomic.AddUint64(&p.submitted, 1)
+
+	case OverflowDropOldest:
+		for {
+			select {
+			case p.jobs <- t:
+				atomic.AddUint64(&p.submitted, 1)
+				return true
+			case oldest := <-p.jobs:
+				atomic.AddUint64(&p.dropped, 1)
+				oldest.drop()
+			}
+		}
+
+	default:
+		atomic.AddUint64(&p.dropped, 1)
+		t.drop()
+	}
+	return true
+}
+
+// channelQueues serializes jobs per channel. A channel with queued jobs has
+// one runner working through them in order.
+type channelQueues struct {
+	mu     sync.Mutex
+	queues map[string][]task
+}
+
+// enqueue adds t to the queue of the channel, using start to launch a runner
+// if the channel has none. If the runner is dropped, so are the jobs queued
+// behind it.
+func (q *channelQueues) enqueue(
+	channelID string,
+	t task,
+	start func(runner task),
+) {
+	q.mu.Lock()
+	if q.queues == nil {
+		q.queues = make(map[string][]task)
+	}
+
+	if queue, running := q.queues[channelID]; running {
+		q.queues[channelID] = append(queue, t)
+		q.mu.Unlock()
+		return
+	}
+
+	q.queues[channelID] = []task{}
+	q.mu.Unlock()
+
+	runner := task{
+		run: func() {
+			for next := t.run; next != nil; next = q.next(channelID) {
+				next()
+			}
+		},
+		drop: func() {
+			t.drop()
+			for _, queued := range q.take(channelID) {
+				queued.drop()
+			}
+		},
+	}
+
+	/* Started outside the lock, as starting may block until a worker frees up */
+	start(runner)
+}
+
+// next pops the next job of the channel, or retires its runner if there are
+// none left
+func (q *channelQueues) next(channelID string) func() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queue := q.queues[channelID]
+	if len(queue) == 0 {
+		delete(q.queues, channelID)
+		return nil
+	}
+
+	q.queues[channelID] = queue[1:]
+	return queue[0].run
+}
+
+// take removes and returns every job queued for the channel, retiring its
+// runner
+func (q *channelQueues) take(channelID string) []task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queue := q.queues[channelID]
+	delete(q.queues, channelID)
+	return queue
+}
@@ -0,0 +1,72 @@
+package disgomux
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Runes which extend the character before them, as in emoji sequences
+const (
+	zeroWidthJoiner = '\u200d'
+	keycap          = '\u20e3'
+)
+
+// ValidatePrefix checks that the prefix is a single character, which may be an
+// emoji made of several code points such as 👋🏽 or 🇳🇱. An invalid prefix is
+// reported with an error matching ErrInvalidPrefix.
+func ValidatePrefix(prefix string) error {
+	if !utf8.ValidString(prefix) {
+		return fmt.Errorf("%w: %q is not valid UTF-8", ErrInvalidPrefix, prefix)
+	}
+	if prefix != "" && !isCharacter(prefix) {
+		return fmt.Errorf(
+			"%w: %s greater than 1 character", ErrInvalidPrefix, prefix,
+		)
+	}
+	return nil
+}
+
+// isCharacter reports whether s is a single user-perceived character: one
+// rune, followed only by marks, variation selectors, skin tone modifiers and
+// tags, joined to further runes with zero width joiners, or a pair of regional
+// indicators forming a flag
+func isCharacter(s string) bool {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 || unicode.IsSpace(r) || unicode.IsControl(r) {
+		return false
+	}
+
+	flag := isRegionalIndicator(r)
+	for s = s[size:]; s != ""; s = s[size:] {
+		r, size = utf8.DecodeRuneInString(s)
+		switch {
+		case flag && isRegionalIndicator(r):
+			flag = false
+		case r == zeroWidthJoiner:
+			/* The joiner must join two runes */
+			next, n := utf8.DecodeRuneInString(s[size:])
+			if n == 0 || unicode.IsSpace(next) {
+				return false
+			}
+			size += n
+		case isExtending(r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isExtending reports whether r modifies the rune before it
+func isExtending(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Variation_Selector) ||
+		r == keycap ||
+		(r >= 0x1f3fb && r <= 0x1f3ff) || /* Skin tones */
+		(r >= 0xe0020 && r <= 0xe007f) /* Tags, as in subdivision flags */
+}
+
+// isRegionalIndicator reports whether r is half of a flag emoji
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
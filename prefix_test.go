@@ -0,0 +1,65 @@
+//go:build go1.18
+// +build go1.18
+
+package disgomux
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+func FuzzSplitCommand(f *testing.F) {
+	for _, seed := range []string{
+		"", "ping", "echo hello world", "echo\nline", "echo　wide",
+		"  leading", "trailing ", "ütf 8", "\xff\xfe",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, content string) {
+		command, rest := splitCommand(content)
+		if strings.IndexFunc(command, unicode.IsSpace) >= 0 {
+			t.Fatalf("command %q contains whitespace", command)
+		}
+		if !strings.HasPrefix(content, command) ||
+			!strings.HasSuffix(content, rest) {
+			t.Fatalf("%q split into %q and %q", content, command, rest)
+		}
+
+		/* Exactly one whitespace rune separates the command from the rest */
+		if sep := content[len(command) : len(content)-len(rest)]; sep != "" {
+			r, size := utf8.DecodeRuneInString(sep)
+			if size != len(sep) || !unicode.IsSpace(r) {
+				t.Fatalf("%q split on %q", content, sep)
+			}
+		}
+
+		if args := splitArguments(rest); strings.Join(args, " ") != rest {
+			t.Fatalf("arguments %q don't join back to %q", args, rest)
+		}
+	})
+}
+
+func FuzzValidatePrefix(f *testing.F) {
+	for _, seed := range []string{
+		"!", "é", "👋🏽", "🇳🇱", "1️⃣", "👨‍👩‍👧", "🏴󠁧󠁢󠁳󠁣󠁴󠁿", "!!", " ", "‍", "\xff",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, prefix string) {
+		if ValidatePrefix(prefix) != nil || prefix == "" {
+			return
+		}
+
+		/* A valid prefix is one character: no whitespace, and valid UTF-8 */
+		if !utf8.ValidString(prefix) {
+			t.Fatalf("invalid UTF-8 prefix %q accepted", prefix)
+		}
+		if strings.IndexFunc(prefix, unicode.IsSpace) >= 0 {
+			t.Fatalf("prefix %q with whitespace accepted", prefix)
+		}
+	})
+}
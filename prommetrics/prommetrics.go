@@ -0,0 +1,126 @@
+// Package prommetrics exports the metrics of a disgomux.Mux to Prometheus.
+//
+//	collector := prommetrics.New("mybot")
+//	prometheus.MustRegister(collector)
+//	mux.SetMetrics(collector)
+package prommetrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/CS-5/disgomux"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is both a disgomux.Metrics and a prometheus.Collector
+type Collector struct {
+	dispatched *prometheus.CounterVec
+	latency    *prometheus.HistogramVec
+	errors     *prometheus.CounterVec
+	denied     *prometheus.CounterVec
+	queue      prometheus.Gauge
+}
+
+var _ disgomux.ShardMetrics = (*Collector)(nil)
+
+// New creates a Collector whose metrics are prefixed with namespace. Register
+// it with Prometheus, then pass it to Mux.SetMetrics(). Command metrics are
+// labelled with the shard of the invocation, "0" without sharding.
+func New(namespace string) *Collector {
+	return &Collector{
+		dispatched: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "commands_dispatched_total",
+			Help:      "Number of command invocations dispatched.",
+		}, []string{"shard", "command"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "command_duration_seconds",
+			Help:      "Time taken by command handlers.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"shard", "command"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "command_errors_total",
+			Help:      "Number of command invocations which failed.",
+		}, []string{"shard", "command"}),
+		denied: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "permission_denials_total",
+			Help:      "Number of command invocations refused for lack of permission.",
+		}, []string{"shard", "command"}),
+		queue: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "queue_depth",
+			Help:      "Number of invocations waiting for a worker.",
+		}),
+	}
+}
+
+// CommandDispatched counts a dispatched invocation
+func (c *Collector) CommandDispatched(command string) {
+	c.ShardCommandDispatched(0, command)
+}
+
+// ShardCommandDispatched counts a dispatched invocation in the shard
+func (c *Collector) ShardCommandDispatched(shard int, command string) {
+	c.dispatched.WithLabelValues(strconv.Itoa(shard), command).Inc()
+}
+
+// CommandCompleted observes the latency of a handler, counting it as failed if
+// err is not nil
+func (c *Collector) CommandCompleted(
+	command string,
+	latency time.Duration,
+	err error,
+) {
+	c.ShardCommandCompleted(0, command, latency, err)
+}
+
+// ShardCommandCompleted is CommandCompleted for a handler in the shard
+func (c *Collector) ShardCommandCompleted(
+	shard int,
+	command string,
+	latency time.Duration,
+	err error,
+) {
+	labels := []string{strconv.Itoa(shard), command}
+	c.latency.WithLabelValues(labels...).Observe(latency.Seconds())
+	if err != nil {
+		c.errors.WithLabelValues(labels...).Inc()
+	}
+}
+
+// PermissionDenied counts a refused invocation
+func (c *Collector) PermissionDenied(command string) {
+	c.ShardPermissionDenied(0, command)
+}
+
+// ShardPermissionDenied counts a refused invocation in the shard
+func (c *Collector) ShardPermissionDenied(shard int, command string) {
+	c.denied.WithLabelValues(strconv.Itoa(shard), command).Inc()
+}
+
+// QueueDepth sets the queue depth gauge
+func (c *Collector) QueueDepth(depth int) {
+	c.queue.Set(float64(depth))
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.dispatched.Describe(ch)
+	c.latency.Describe(ch)
+	c.errors.Describe(ch)
+	c.denied.Describe(ch)
+	c.queue.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.dispatched.Collect(ch)
+	c.latency.Collect(ch)
+	c.errors.Collect(ch)
+	c.denied.Collect(ch)
+	c.queue.Collect(ch)
+}
@@ -0,0 +1,77 @@
+package disgomux
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// MessageFilter reports whether a message is an acceptable response
+type MessageFilter func(*discordgo.Message) bool
+
+// Prompt sends question to the current channel (unless it is empty) and waits
+// for the next message from the invoking user in the same channel which passes
+// filter (a nil filter accepts any message). The response is not handled as a
+// command. ErrTimeout is returned if no response arrives within the timeout.
+func (ctx *Context) Prompt(
+	question string,
+	timeout time.Duration,
+	filter MessageFilter,
+) (*discordgo.Message, error) {
+	if question != "" {
+		if _, err := ctx.ChannelSend(question); err != nil {
+			return nil, err
+		}
+	}
+
+	return ctx.awaitMessage(ctx.Message.ChannelID, timeout, filter)
+}
+
+// awaitMessage waits for the next message from the invoking user in the
+// channel which passes filter
+func (ctx *Context) awaitMessage(
+	channelID string,
+	timeout time.Duration,
+	filter MessageFilter,
+) (*discordgo.Message, error) {
+	userID := ctx.Message.Author.ID
+	event, err := ctx.mux.events.wait(ctx.mux.clock(), timeout, func(event interface{}) bool {
+		msg, ok := event.(*discordgo.MessageCreate)
+		return ok &&
+			msg.ChannelID == channelID &&
+			msg.Author.ID == userID &&
+			(filter == nil || filter(msg.Message))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return event.(*discordgo.MessageCreate).Message, nil
+}
+
+// ReactionFilter reports whether a reaction is an acceptable response
+type ReactionFilter func(*discordgo.MessageReaction) bool
+
+// WaitForReaction waits for a reaction to be added to the message with the
+// specified ID which passes filter (a nil filter accepts any reaction,
+// including the bot's own). ErrTimeout is returned if no reaction arrives
+// within the timeout.
+func (ctx *Context) WaitForReaction(
+	messageID string,
+	timeout time.Duration,
+	filter ReactionFilter,
+) (*discordgo.MessageReaction, error) {
+	ctx.mux.events.attach(ctx.Session)
+
+	event, err := ctx.mux.events.wait(ctx.mux.clock(), timeout, func(event interface{}) bool {
+		r, ok := event.(*discordgo.MessageReactionAdd)
+		return ok &&
+			r.MessageID == messageID &&
+			(filter == nil || filter(r.MessageReaction))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return event.(*discordgo.MessageReactionAdd).MessageReaction, nil
+}
@@ -0,0 +1,160 @@
+package disgomux
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord limits for deleting messages
+const (
+	// bulkDeleteLimit is the number of messages one bulk delete may remove
+	bulkDeleteLimit = 100
+
+	// bulkDeleteMaxAge is the age of the oldest message a bulk delete may
+	// remove, with an hour's margin for clock skew
+	bulkDeleteMaxAge = 14*24*time.Hour - time.Hour
+
+	// purgeScanLimit is the number of messages PurgeMessages() looks through
+	purgeScanLimit = 1000
+)
+
+// PurgeFilter selects the messages PurgeMessages() deletes
+type PurgeFilter func(msg *discordgo.Message) bool
+
+// PurgeFrom selects the messages of the user
+func PurgeFrom(userID string) PurgeFilter {
+	return func(msg *discordgo.Message) bool {
+		return msg.Author != nil && msg.Author.ID == userID
+	}
+}
+
+// PurgeBots selects the messages of bots
+func PurgeBots() PurgeFilter {
+	return func(msg *discordgo.Message) bool {
+		return msg.Author != nil && msg.Author.Bot
+	}
+}
+
+// PurgeContaining selects the messages containing the text, ignoring case
+func PurgeContaining(text string) PurgeFilter {
+	text = strings.ToLower(text)
+	return func(msg *discordgo.Message) bool {
+		return strings.Contains(strings.ToLower(msg.Content), text)
+	}
+}
+
+// PurgeAll selects the messages selected by every filter
+func PurgeAll(filters ...PurgeFilter) PurgeFilter {
+	return func(msg *discordgo.Message) bool {
+		for _, f := range filters {
+			if !f(msg) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// PurgeMessages deletes the n most recent messages of the current channel
+// which are selected by filter (nil selects every message), not counting the
+// invoking message, and returns how many were deleted. Pinned messages are
+// kept, and only the last 1000 messages are looked through. Messages are
+// deleted in bulk, except those older than two weeks, which Discord only lets
+// bots delete one by one. Nothing is attempted if the session state shows the
+// bot lacks the Manage Messages permission.
+func (ctx *Context) PurgeMessages(n int, filter PurgeFilter) (int, error) {
+	channelID := ctx.Message.ChannelID
+	perms, err := ctx.Session.State.UserChannelPermissions(
+		ctx.Session.State.User.ID, channelID,
+	)
+	if err == nil && perms&discordgo.PermissionManageMessages == 0 {
+		return 0, fmt.Errorf("Missing permission to delete messages")
+	}
+
+	messages, err := ctx.purgeable(channelID, n, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	/* Bulk deletes refuse messages older than two weeks */
+	now := ctx.mux.clock().Now()
+	var bulk, single []string
+	for _, msg := range messages {
+		if now.Sub(messageTime(msg)) < bulkDeleteMaxAge {
+			bulk = append(bulk, msg.ID)
+		} else {
+			single = append(single, msg.ID)
+		}
+	}
+
+	api := ctx.API()
+	deleted := 0
+	for len(bulk) > 0 {
+		batch := bulk
+		if len(batch) > bulkDeleteLimit {
+			batch = batch[:bulkDeleteLimit]
+		}
+		bulk = bulk[len(batch):]
+
+		if err := api.ChannelMessagesBulkDelete(channelID, batch); err != nil {
+			return deleted, err
+		}
+		deleted += len(batch)
+	}
+	for _, id := range single {
+		if err := api.ChannelMessageDelete(channelID, id); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// purgeable fetches the n most recent messages of the channel before the
+// invoking message which filter selects, skipping pinned ones
+func (ctx *Context) purgeable(
+	channelID string,
+	n int,
+	filter PurgeFilter,
+) ([]*discordgo.Message, error) {
+	/* Interactions are not messages of the channel, so there is none to skip */
+	before := ctx.Message.ID
+	if ctx.Interaction != nil {
+		before = ""
+	}
+
+	var selected []*discordgo.Message
+	for scanned := 0; len(selected) < n && scanned < purgeScanLimit; {
+		page, err := ctx.API().ChannelMessages(
+			channelID, bulkDeleteLimit, before, "", "",
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, msg := range page {
+			if len(selected) < n && !msg.Pinned && (filter == nil || filter(msg)) {
+				selected = append(selected, msg)
+			}
+		}
+		if len(page) < bulkDeleteLimit {
+			break
+		}
+		scanned += len(page)
+		before = page[len(page)-1].ID
+	}
+	return selected, nil
+}
+
+// messageTime returns when the message was sent, from its timestamp or else
+// its snowflake ID
+func messageTime(msg *discordgo.Message) time.Time {
+	if !msg.Timestamp.IsZero() {
+		return msg.Timestamp
+	}
+	t, _ := discordgo.SnowflakeTimestamp(msg.ID)
+	return t
+}
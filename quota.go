@@ -0,0 +1,67 @@
+package disgomux
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// QuotaPeriod is the span of time a quota allows its uses over
+type QuotaPeriod int
+
+// Quota periods. Days start at midnight UTC, weeks on Monday at midnight UTC.
+const (
+	QuotaDaily QuotaPeriod = iota
+	QuotaWeekly
+)
+
+// Quota limits a command to Limit uses per Period within its Scope, e.g.
+// twenty image generations per user per day. Quotas are tracked in the
+// cooldown store of the mux, and checked after the cooldown of the command.
+type Quota struct {
+	Limit  int
+	Period QuotaPeriod
+	Scope  CooldownScope
+}
+
+// bounds returns the start and end of the period now falls in
+func (p QuotaPeriod) bounds(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	if p == QuotaWeekly {
+		/* Weekday() counts from Sunday */
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7)
+	}
+	return start, start.AddDate(0, 0, 1)
+}
+
+// overQuota consumes a use of the quota q for the message author, and returns
+// when the quota resets if it is used up. Store errors let the invocation
+// through.
+func (m *Mux) overQuota(
+	name string,
+	q *Quota,
+	message *discordgo.MessageCreate,
+) (time.Time, bool) {
+	if q == nil || q.Limit <= 0 {
+		return time.Time{}, false
+	}
+
+	/*
+		The period is part of the key, so each period gets a fresh bucket
+		expiring at its end whenever the first use of it happens
+	*/
+	now := m.clock().Now()
+	start, end := q.Period.bounds(now)
+	key := "quota:" + cooldownKey(name, q.Scope, message) + ":" +
+		strconv.FormatInt(start.Unix(), 10)
+
+	ok, reset, err := m.cooldownStore().Take(key, q.Limit, end.Sub(now), now)
+	if err != nil || ok {
+		return time.Time{}, false
+	}
+	return reset, true
+}
@@ -0,0 +1,108 @@
+package disgomux
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestQuotaPeriodBounds(t *testing.T) {
+	at := func(s string) time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	tests := []struct {
+		period     QuotaPeriod
+		now        string
+		start, end string
+	}{
+		{QuotaDaily, "2024-03-13T15:04:05Z",
+			"2024-03-13T00:00:00Z", "2024-03-14T00:00:00Z"},
+		{QuotaDaily, "2024-03-13T00:00:00Z",
+			"2024-03-13T00:00:00Z", "2024-03-14T00:00:00Z"},
+		{QuotaDaily, "2024-03-13T23:59:59Z",
+			"2024-03-13T00:00:00Z", "2024-03-14T00:00:00Z"},
+		{QuotaDaily, "2024-12-31T12:00:00Z",
+			"2024-12-31T00:00:00Z", "2025-01-01T00:00:00Z"},
+
+		/* Days are UTC days, whatever the zone of the time */
+		{QuotaDaily, "2024-03-13T23:30:00-05:00",
+			"2024-03-14T00:00:00Z", "2024-03-15T00:00:00Z"},
+
+		/* Weeks start on Monday */
+		{QuotaWeekly, "2024-03-13T15:04:05Z",
+			"2024-03-11T00:00:00Z", "2024-03-18T00:00:00Z"},
+		{QuotaWeekly, "2024-03-11T00:00:00Z",
+			"2024-03-11T00:00:00Z", "2024-03-18T00:00:00Z"},
+		{QuotaWeekly, "2024-03-17T23:59:59Z",
+			"2024-03-11T00:00:00Z", "2024-03-18T00:00:00Z"},
+		{QuotaWeekly, "2024-03-18T00:00:00Z",
+			"2024-03-18T00:00:00Z", "2024-03-25T00:00:00Z"},
+		{QuotaWeekly, "2025-01-01T08:00:00Z",
+			"2024-12-30T00:00:00Z", "2025-01-06T00:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		start, end := tt.period.bounds(at(tt.now))
+		if !start.Equal(at(tt.start)) || !end.Equal(at(tt.end)) {
+			t.Errorf("period %d at %s: %s to %s, want %s to %s",
+				tt.period, tt.now, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestOverQuota(t *testing.T) {
+	m := newMux()
+
+	/* Sunday evening, the last hours of the week */
+	clock := NewFakeClock(time.Date(2024, 3, 17, 22, 0, 0, 0, time.UTC))
+	m.SetClock(clock)
+
+	message := &discordgo.MessageCreate{Message: &discordgo.Message{
+		ChannelID: "300",
+		GuildID:   "200",
+		Author:    &discordgo.User{ID: "400"},
+	}}
+	other := &discordgo.MessageCreate{Message: &discordgo.Message{
+		ChannelID: "300",
+		GuildID:   "200",
+		Author:    &discordgo.User{ID: "401"},
+	}}
+	q := &Quota{Limit: 2, Period: QuotaWeekly}
+
+	for i := 0; i < 2; i++ {
+		if _, over := m.overQuota("roll", q, message); over {
+			t.Fatalf("use %d over the quota of 2", i+1)
+		}
+	}
+
+	reset, over := m.overQuota("roll", q, message)
+	if !over {
+		t.Fatal("third use within the quota of 2")
+	}
+	if want := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC); !reset.Equal(want) {
+		t.Errorf("resets at %s, want Monday %s", reset, want)
+	}
+
+	if _, over := m.overQuota("roll", q, other); over {
+		t.Error("the quota of another user was used")
+	}
+	if _, over := m.overQuota("other", q, message); over {
+		t.Error("the quota of another command was used")
+	}
+
+	/* Monday starts a fresh week */
+	clock.Advance(2 * time.Hour)
+	if _, over := m.overQuota("roll", q, message); over {
+		t.Error("quota not reset on Monday")
+	}
+
+	if _, over := m.overQuota("roll", nil, message); over {
+		t.Error("no quota was over")
+	}
+}
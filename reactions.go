@@ -0,0 +1,88 @@
+package disgomux
+
+import "github.com/bwmarrin/discordgo"
+
+type (
+	// ReactionHandler handles a reaction routed by the mux. added is false
+	// for removed reactions.
+	ReactionHandler func(
+		session *discordgo.Session,
+		r *discordgo.MessageReaction,
+		added bool,
+	)
+
+	// ReactionRoute selects the reactions routed to a handler, e.g. for role
+	// menus or starboards. Empty fields match any reaction.
+	ReactionRoute struct {
+		// MessageID limits the route to the reactions on one message
+		MessageID string
+
+		// Emoji limits the route to one emoji: a unicode emoji, or the name,
+		// ID or name:ID of a custom emoji
+		Emoji string
+
+		// Removals routes removed reactions as well as added ones
+		Removals bool
+
+		Filter ReactionFilter
+	}
+)
+
+// HandleReaction routes the reactions matching route to handler until cancel
+// is called. Reactions by the bot itself are skipped. Routes share a single
+// DiscordGo handler, added to the session the first time. Panics in handlers
+// are recovered and reported.
+func (m *Mux) HandleReaction(
+	session *discordgo.Session,
+	route ReactionRoute,
+	handler ReactionHandler,
+) (cancel func()) {
+	m.events.attach(session)
+
+	return m.events.listen(
+		func(event interface{}) bool {
+			r, added := reactionEvent(event)
+			return r != nil && (added || route.Removals) && route.match(session, r)
+		},
+		func(event interface{}) {
+			r, added := reactionEvent(event)
+			defer m.recoverHandler(map[string]string{
+				"event":   "reaction",
+				"guild":   r.GuildID,
+				"channel": r.ChannelID,
+				"user":    r.UserID,
+			})
+			handler(session, r, added)
+		},
+	)
+}
+
+// match reports whether the route accepts a reaction
+func (route *ReactionRoute) match(
+	session *discordgo.Session,
+	r *discordgo.MessageReaction,
+) bool {
+	if session.State.User != nil && r.UserID == session.State.User.ID {
+		return false
+	}
+	if route.MessageID != "" && r.MessageID != route.MessageID {
+		return false
+	}
+	if route.Emoji != "" && route.Emoji != r.Emoji.Name &&
+		route.Emoji != r.Emoji.ID && route.Emoji != r.Emoji.APIName() {
+		return false
+	}
+	return route.Filter == nil || route.Filter(r)
+}
+
+// reactionEvent returns the reaction of a reaction added or removed event,
+// and whether it was added. The reaction is nil for other events.
+func reactionEvent(event interface{}) (*discordgo.MessageReaction, bool) {
+	switch e := event.(type) {
+	case *discordgo.MessageReactionAdd:
+		return e.MessageReaction, true
+	case *discordgo.MessageReactionRemove:
+		return e.MessageReaction, false
+	}
+	return nil, false
+}
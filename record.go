@@ -0,0 +1,144 @@
+package disgomux
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// RecordedInvocation is an invocation recorded by a Recorder, along with
+	// the messages the bot responded with
+	RecordedInvocation struct {
+		Time        time.Time              `json:"time"`
+		Message     *discordgo.Message     `json:"message,omitempty"`
+		Interaction *discordgo.Interaction `json:"interaction,omitempty"`
+		Responses   []*discordgo.Message   `json:"responses,omitempty"`
+		Error       string                 `json:"error,omitempty"`
+	}
+
+	// Recorder is a middleware writing every invocation it sees, once over,
+	// as a line of JSON. Replay the recording with Mux.Replay(), or with the
+	// muxtest package in tests.
+	//
+	//	recorder, err := disgomux.CreateRecording("traffic.jsonl")
+	//	mux.UseMiddleware(recorder.Middleware)
+	//	defer recorder.Close()
+	Recorder struct {
+		mu     sync.Mutex
+		w      io.Writer
+		closer io.Closer
+		err    error
+	}
+)
+
+// NewRecorder creates a recorder writing to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// CreateRecording creates a recorder writing to the file at path, which is
+// truncated
+func CreateRecording(path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{w: f, closer: f}, nil
+}
+
+// Middleware records the invocation once it is over
+func (r *Recorder) Middleware(ctx *Context) {
+	recorded := RecordedInvocation{Time: time.Now()}
+	if ctx.Interaction != nil {
+		recorded.Interaction = ctx.Interaction.Interaction
+	} else {
+		recorded.Message = ctx.Message.Message
+	}
+
+	ctx.OnDone(func(err error) {
+		recorded.Responses = ctx.Responses()
+		if err != nil {
+			recorded.Error = err.Error()
+		}
+		r.write(recorded)
+	})
+}
+
+// write appends an invocation to the recording
+func (r *Recorder) write(recorded RecordedInvocation) {
+	data, err := json.Marshal(recorded)
+	if err == nil {
+		data = append(data, '\n')
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+	if err == nil {
+		_, err = r.w.Write(data)
+	}
+	r.err = err
+}
+
+// Err returns the first error the recorder failed to write with
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.err
+}
+
+// Close closes the file of a recorder made with CreateRecording()
+func (r *Recorder) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// ReadRecording reads the invocations written by a Recorder
+func ReadRecording(rd io.Reader) ([]RecordedInvocation, error) {
+	var recorded []RecordedInvocation
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(nil, 1<<24)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var inv RecordedInvocation
+		if err := json.Unmarshal(scanner.Bytes(), &inv); err != nil {
+			return nil, err
+		}
+		recorded = append(recorded, inv)
+	}
+	return recorded, scanner.Err()
+}
+
+// Replay feeds the recorded invocations back through the mux, in order. Use a
+// mux the messages weren't handled by already, or they are dropped as
+// duplicates.
+func (m *Mux) Replay(
+	session *discordgo.Session,
+	recorded []RecordedInvocation,
+) {
+	for _, inv := range recorded {
+		switch {
+		case inv.Interaction != nil:
+			m.HandleInteraction(session, &discordgo.InteractionCreate{
+				Interaction: inv.Interaction,
+			})
+		case inv.Message != nil:
+			m.Handle(session, &discordgo.MessageCreate{Message: inv.Message})
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package disgomux
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+type (
+	// ConfigSource provides the state ReloadConfig swaps in, e.g. a file
+	// written by ExportConfig or a database
+	ConfigSource interface {
+		Load() (ConfigSnapshot, error)
+	}
+
+	// ConfigSourceFunc adapts a function to a ConfigSource
+	ConfigSourceFunc func() (ConfigSnapshot, error)
+
+	// configFile is a ConfigSource reading a snapshot file
+	configFile string
+)
+
+// errNoConfigSource is returned when reloading without a source
+var errNoConfigSource = errors.New("No config source set")
+
+// Load calls f()
+func (f ConfigSourceFunc) Load() (ConfigSnapshot, error) {
+	return f()
+}
+
+// ConfigFile returns a ConfigSource reading a snapshot written by
+// ExportConfig from the file at path
+func ConfigFile(path string) ConfigSource {
+	return configFile(path)
+}
+
+func (path configFile) Load() (ConfigSnapshot, error) {
+	f, err := os.Open(string(path))
+	if err != nil {
+		return ConfigSnapshot{}, err
+	}
+	defer f.Close()
+
+	var s ConfigSnapshot
+	return s, decodeSnapshot(f, &s)
+}
+
+// SetConfigSource sets the source ReloadConfig loads the state of the mux from
+func (m *Mux) SetConfigSource(source ConfigSource) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.configSource = source
+}
+
+// ReloadConfig replaces the prefix, error texts, disabled commands, simple
+// commands and guild configurations of the mux with those of the config
+// source, while it keeps handling messages. Unlike ImportConfig, state
+// missing from the source is dropped. Nothing changes if the source fails to
+// load, or has a simple command which is not a valid template.
+func (m *Mux) ReloadConfig() error {
+	m.mu.RLock()
+	source := m.configSource
+	m.mu.RUnlock()
+	if source == nil {
+		return errNoConfigSource
+	}
+
+	s, err := source.Load()
+	if err != nil {
+		return err
+	}
+
+	/* Build the simple command tables aside, then swap them in at once, so
+	invalid templates leave the running configuration as it is */
+	global := make(map[string]SimpleCommand)
+	guilds := make(map[string]map[string]SimpleCommand)
+	for _, c := range s.SimpleCommands {
+		if len(c.Command) == 0 {
+			continue
+		}
+
+		table := global
+		if c.GuildID != "" {
+			if guilds[c.GuildID] == nil {
+				guilds[c.GuildID] = make(map[string]SimpleCommand)
+			}
+			table = guilds[c.GuildID]
+		}
+		if err := addSimple(table, c); err != nil {
+			return &LintError{Problems: []LintProblem{
+				{Command: c.Command, Problem: err.Error()},
+			}}
+		}
+	}
+
+	if err := m.replaceGuildConfigs(s.Guilds); err != nil {
+		return err
+	}
+
+	m.simpleMu.Lock()
+	m.SimpleCommands = global
+	m.GuildSimpleCommands = guilds
+	m.simpleMu.Unlock()
+
+	disabled := make(map[string]map[string]bool, len(s.DisabledCommands))
+	for guildID, commands := range s.DisabledCommands {
+		disabled[guildID] = make(map[string]bool, len(commands))
+		for _, c := range commands {
+			disabled[guildID][c] = true
+		}
+	}
+
+	m.mu.Lock()
+	if s.Prefix != "" {
+		m.Prefix = s.Prefix
+	}
+	if s.ErrorTexts != (ErrorTexts{}) {
+		m.errorTexts = s.ErrorTexts
+	}
+	m.disabled = disabled
+	m.mu.Unlock()
+
+	m.log(LogInfo, "Configuration reloaded", nil)
+	return nil
+}
+
+// WatchConfigFile sets the file at path as the config source, and reloads it
+// whenever its modification time changes, checking every interval until
+// cancel is called or the mux shuts down. Failed reloads are logged and
+// reported, keeping the current state.
+func (m *Mux) WatchConfigFile(path string, interval time.Duration) (cancel func()) {
+	m.SetConfigSource(ConfigFile(path))
+
+	stop := make(chan struct{})
+	cancel = closeOnce(stop)
+
+	if !m.lifecycle.acquire() {
+		return cancel
+	}
+
+	var modified time.Time
+	if info, err := os.Stat(path); err == nil {
+		modified = info.ModTime()
+	}
+
+	go func() {
+		defer m.lifecycle.release()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			case <-m.lifecycle.closing():
+				return
+			}
+
+			info, err := os.Stat(path)
+			if err != nil || !info.ModTime().After(modified) {
+				continue
+			}
+			modified = info.ModTime()
+
+			if err := m.ReloadConfig(); err != nil {
+				fields := map[string]string{"path": path}
+				m.log(LogError, "Failed to reload configuration", fields)
+				m.report(err, fields)
+			}
+		}
+	}()
+
+	return cancel
+}
+
+// replaceGuildConfigs saves the guild configurations to the config store,
+// deleting those of other guilds
+func (m *Mux) replaceGuildConfigs(configs []GuildConfig) error {
+	current, err := m.configs.List()
+	if err != nil {
+		return err
+	}
+
+	keep := make(map[string]bool, len(configs))
+	for _, c := range configs {
+		if err := m.configs.Save(c); err != nil {
+			return err
+		}
+		keep[c.GuildID] = true
+	}
+
+	for _, c := range current {
+		if !keep[c.GuildID] {
+			if err := m.configs.Delete(c.GuildID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
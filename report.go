@@ -0,0 +1,161 @@
+package disgomux
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// ErrorReporter receives errors the mux encounters: panics recovered from
+	// handlers, errors returned by handlers and internal failures such as
+	// failed sends. fields describe where the error happened (guild, channel,
+	// user, command). Implementations must be safe for concurrent use.
+	ErrorReporter interface {
+		Report(err error, fields map[string]string)
+	}
+
+	// ReporterFunc adapts a function to an ErrorReporter, e.g. to forward
+	// errors to a Sentry-style client:
+	//
+	//	mux.SetErrorReporter(disgomux.ReporterFunc(
+	//		func(err error, fields map[string]string) {
+	//			sentry.CaptureException(err)
+	//		},
+	//	))
+	ReporterFunc func(err error, fields map[string]string)
+
+	// PanicError is reported when a handler panics
+	PanicError struct {
+		Value interface{}
+		Stack []byte
+	}
+)
+
+// Report calls f(err, fields)
+func (f ReporterFunc) Report(err error, fields map[string]string) {
+	f(err, fields)
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("Handler panicked: %v", e.Value)
+}
+
+// SetErrorReporter sets the reporter errors are sent to
+func (m *Mux) SetErrorReporter(reporter ErrorReporter) {
+	m.reporter = reporter
+}
+
+// SetPanicChannel sets a channel which the stack traces of panics recovered
+// from handlers are posted to. An empty channel ID (the default) disables it.
+func (m *Mux) SetPanicChannel(channelID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.panicChannel = channelID
+}
+
+// panicChannelID returns the channel panics are posted to, if any
+func (m *Mux) panicChannelID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.panicChannel
+}
+
+// report passes err to the error reporter, if one is set
+func (m *Mux) report(err error, fields map[string]string) {
+	if m.reporter != nil {
+		m.reporter.Report(err, fields)
+	}
+}
+
+// reportCtx reports err with the fields of the invocation
+func (m *Mux) reportCtx(ctx *Context, err error) {
+	m.report(err, ctx.fields())
+}
+
+// recoverPanic recovers a panicking handler, reporting the panic and telling
+// the user something went wrong. The panic is stored in err. Must be deferred.
+func (m *Mux) recoverPanic(ctx *Context, err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	p := &PanicError{Value: v, Stack: debug.Stack()}
+	*err = p
+	m.reportCtx(ctx, p)
+	m.replyError(ctx, m.handlerErrorText(ctx), 0)
+
+	if channelID := m.panicChannelID(); channelID != "" {
+		m.postPanic(ctx, p, channelID)
+	}
+}
+
+// recoverHandler recovers a panicking event handler, reporting the panic with
+// the fields describing the event. Must be deferred.
+func (m *Mux) recoverHandler(fields map[string]string) {
+	if v := recover(); v != nil {
+		m.report(&PanicError{Value: v, Stack: debug.Stack()}, fields)
+	}
+}
+
+// panicHeaderLimit is the number of characters of a panic message header
+// kept in the message, the rest being left to the attached trace
+const panicHeaderLimit = 500
+
+// postPanic posts the stack trace of a panic to the panic channel, as a code
+// block truncated to fit in one message with the full trace attached if needed
+func (m *Mux) postPanic(ctx *Context, p *PanicError, channelID string) {
+	header := fmt.Sprintf(
+		"Panic in `%s` (invocation %s, guild %s, channel %s, user %s): %v\n",
+		ctx.Command, ctx.id, ctx.Message.GuildID, ctx.Message.ChannelID,
+		ctx.Message.Author.ID, p.Value,
+	)
+	stack := string(p.Stack)
+
+	const fences = len("```\n\n```")
+	ms := &discordgo.MessageSend{}
+	if utf8.RuneCountInString(header)+utf8.RuneCountInString(stack)+fences >
+		MessageLimit {
+		/* The whole panic goes in the file, the message keeps what fits */
+		ms.Files = []*discordgo.File{{
+			Name:   "stack.txt",
+			Reader: strings.NewReader(header + "\n" + stack),
+		}}
+
+		if utf8.RuneCountInString(header) > panicHeaderLimit {
+			header = runePrefix(header, panicHeaderLimit-2) + "…\n"
+		}
+		stack = runePrefix(
+			stack, MessageLimit-utf8.RuneCountInString(header)-fences,
+		)
+	}
+	ms.Content = header + "```\n" + stack + "\n```"
+
+	m.retry(channelID, func() error {
+		_, err := ctx.API().ChannelMessageSendComplex(channelID, ms)
+		return err
+	})
+}
+
+// fields describes the invocation for error reports and logs
+func (ctx *Context) fields() map[string]string {
+	fields := map[string]string{
+		"invocation": ctx.id,
+		"guild":      ctx.Message.GuildID,
+		"channel":    ctx.Message.ChannelID,
+		"user":       ctx.Message.Author.ID,
+		"message":    ctx.Message.ID,
+		"command":    ctx.Command,
+	}
+	if ctx.ShardCount() > 1 {
+		fields["shard"] = strconv.Itoa(ctx.ShardID())
+	}
+	return fields
+}
@@ -0,0 +1,103 @@
+package disgomux
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	mentionPattern   = regexp.MustCompile(`^<@!?(\d+)>$`)
+	snowflakePattern = regexp.MustCompile(`^\d{15,21}$`)
+)
+
+// ResolveUser resolves arg to a member of the current guild. arg may be a
+// mention, a user ID, a username, a username#discriminator, or (part of) a
+// nickname. Exact matches are preferred over partial ones. ErrUserNotFound is
+// returned if nothing matches and *AmbiguousUserError if several members do.
+func (ctx *Context) ResolveUser(arg string) (*discordgo.Member, error) {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return nil, ErrUserNotFound
+	}
+
+	/* Mentions and IDs identify the user directly */
+	id := arg
+	if match := mentionPattern.FindStringSubmatch(arg); match != nil {
+		id = match[1]
+	}
+	if snowflakePattern.MatchString(id) {
+		return ctx.memberByID(id)
+	}
+
+	guild, err := ctx.Guild()
+	if err != nil {
+		return nil, err
+	}
+
+	query := strings.ToLower(arg)
+	var exact, partial []*discordgo.Member
+	for _, m := range guild.Members {
+		if m.User == nil {
+			continue
+		}
+
+		names := []string{
+			strings.ToLower(m.User.Username),
+			strings.ToLower(m.User.GlobalName),
+			strings.ToLower(m.Nick),
+		}
+
+		if strings.ToLower(m.User.String()) == query {
+			return m, nil
+		}
+
+		matched := false
+		for _, n := range names {
+			if n != "" && n == query {
+				exact = append(exact, m)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		for _, n := range names {
+			if n != "" && strings.Contains(n, query) {
+				partial = append(partial, m)
+				break
+			}
+		}
+	}
+
+	for _, candidates := range [][]*discordgo.Member{exact, partial} {
+		switch len(candidates) {
+		case 0:
+			continue
+		case 1:
+			return candidates[0], nil
+		default:
+			return nil, &AmbiguousUserError{Query: arg, Candidates: candidates}
+		}
+	}
+
+	return nil, ErrUserNotFound
+}
+
+// memberByID returns the member of the current guild with the specified user
+// ID, from the session state or else from Discord
+func (ctx *Context) memberByID(userID string) (*discordgo.Member, error) {
+	guildID := ctx.Message.GuildID
+	if m, err := ctx.Session.State.Member(guildID, userID); err == nil {
+		return m, nil
+	}
+
+	m, err := ctx.API().GuildMember(guildID, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return m, nil
+}
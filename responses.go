@@ -0,0 +1,191 @@
+package disgomux
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// defaultTrackedResponses is how many invocations are tracked when cleaning up
+// responses turns tracking on
+const defaultTrackedResponses = 100
+
+// responseTracker remembers the responses sent to the most recent invocations,
+// keyed by the ID of the invoking message
+type responseTracker struct {
+	mu        sync.Mutex
+	limit     int
+	order     []string
+	responses map[string][]*discordgo.Message
+}
+
+// TrackResponses makes the mux remember the messages sent through Context
+// helpers for the last limit invocations, so they can be looked up with
+// Mux.Responses(). A limit of zero disables tracking.
+func (m *Mux) TrackResponses(limit int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if limit <= 0 {
+		m.tracked = nil
+		return
+	}
+
+	m.tracked = &responseTracker{
+		limit:     limit,
+		responses: make(map[string][]*discordgo.Message),
+	}
+}
+
+// tracker returns the response tracker, nil if tracking is off
+func (m *Mux) tracker() *responseTracker {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.tracked
+}
+
+// Responses returns the tracked responses to the invoking message with the
+// specified ID
+func (m *Mux) Responses(invocationID string) []*discordgo.Message {
+	tracked := m.tracker()
+	if tracked == nil {
+		return nil
+	}
+
+	tracked.mu.Lock()
+	defer tracked.mu.Unlock()
+
+	return append([]*discordgo.Message(nil), tracked.responses[invocationID]...)
+}
+
+// CleanUpResponses deletes the tracked responses to an invocation when the
+// invoking message is deleted, until cancel is called. Response tracking is
+// turned on with a limit of 100 invocations if it is off.
+func (m *Mux) CleanUpResponses(session *discordgo.Session) (cancel func()) {
+	tracked := m.tracker()
+	if tracked == nil {
+		m.TrackResponses(defaultTrackedResponses)
+		tracked = m.tracker()
+	}
+
+	m.events.attach(session)
+	return m.events.listen(
+		func(event interface{}) bool {
+			switch event.(type) {
+			case *discordgo.MessageDelete, *discordgo.MessageDeleteBulk:
+				return true
+			}
+			return false
+		},
+		func(event interface{}) {
+			var ids []string
+			switch e := event.(type) {
+			case *discordgo.MessageDelete:
+				ids = []string{e.ID}
+			case *discordgo.MessageDeleteBulk:
+				ids = e.Messages
+			}
+
+			for _, id := range ids {
+				for _, msg := range tracked.take(id) {
+					m.api(session).ChannelMessageDelete(msg.ChannelID, msg.ID)
+				}
+			}
+		},
+	)
+}
+
+// add records msg as a response to the invocation, evicting the oldest
+// invocation once the limit is reached
+func (t *responseTracker) add(invocationID string, msg *discordgo.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.responses[invocationID]; !ok {
+		if len(t.order) == t.limit {
+			delete(t.responses, t.order[0])
+			t.order = t.order[1:]
+		}
+		t.order = append(t.order, invocationID)
+	}
+
+	t.responses[invocationID] = append(t.responses[invocationID], msg)
+}
+
+// take returns and forgets the responses of the invocation
+func (t *responseTracker) take(invocationID string) []*discordgo.Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	responses := t.responses[invocationID]
+	t.drop(invocationID)
+	return responses
+}
+
+// forget drops the responses of the invocation
+func (t *responseTracker) forget(invocationID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.drop(invocationID)
+}
+
+// drop drops the responses of the invocation. Must be called with mu held.
+func (t *responseTracker) drop(invocationID string) {
+	if _, ok := t.responses[invocationID]; !ok {
+		return
+	}
+
+	delete(t.responses, invocationID)
+	for i, id := range t.order {
+		if id == invocationID {
+			t.order = append(t.order[:i], t.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Responses returns the messages sent through the helpers of this Context
+func (ctx *Context) Responses() []*discordgo.Message {
+	ctx.responsesMu.Lock()
+	defer ctx.responsesMu.Unlock()
+
+	return append([]*discordgo.Message(nil), ctx.responses...)
+}
+
+// EditResponse replaces the content of the last message sent through the
+// helpers of this Context, e.g. to report progress. If nothing has been sent
+// yet the content is sent as a new message.
+func (ctx *Context) EditResponse(content string) (*discordgo.Message, error) {
+	ctx.responsesMu.Lock()
+	var last *discordgo.Message
+	if n := len(ctx.responses); n != 0 {
+		last = ctx.responses[n-1]
+	}
+	ctx.responsesMu.Unlock()
+
+	if last == nil {
+		return ctx.ChannelSend(content)
+	}
+
+	ctx.captureEdit(content)
+	if ctx.Invocation != nil && last.ChannelID == ctx.Invocation.ChannelID() {
+		return ctx.Invocation.Edit(last.ID, content)
+	}
+	return ctx.API().ChannelMessageEdit(last.ChannelID, last.ID, content)
+}
+
+// recordResponse remembers msg as a response to this invocation
+func (ctx *Context) recordResponse(msg *discordgo.Message) {
+	ctx.responsesMu.Lock()
+	ctx.responses = append(ctx.responses, msg)
+	ctx.responsesMu.Unlock()
+
+	if ctx.mux == nil {
+		return
+	}
+	if tracked := ctx.mux.tracker(); tracked != nil {
+		tracked.add(ctx.Message.ID, msg)
+	}
+}
@@ -0,0 +1,78 @@
+package disgomux
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Send retry defaults. Rate limits are already retried by discordgo itself.
+const (
+	defaultSendRetries = 3
+	sendBackoff        = 250 * time.Millisecond
+)
+
+// SetSendRetries sets how many times a failed send is retried, with
+// exponential backoff, when the failure looks transient (network errors and
+// 5xx responses). Defaults to three; zero disables retries.
+func (m *Mux) SetSendRetries(retries int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sendRetries = retries
+}
+
+// retries returns how many times failed sends are retried
+func (m *Mux) retries() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.sendRetries
+}
+
+// OnSendFailure sets a hook called when a message in the channel could not be
+// sent, even after retrying
+func (m *Mux) OnSendFailure(hook func(channelID string, err error)) {
+	m.sendFailure = hook
+}
+
+// retry calls send until it succeeds, fails permanently, or runs out of
+// retries. Persistent failures are passed to the OnSendFailure hook and the
+// error reporter.
+func (m *Mux) retry(channelID string, send func() error) error {
+	err := send()
+	backoff := sendBackoff
+	retries := m.retries()
+
+	for attempt := 0; err != nil && attempt < retries && transient(err); attempt++ {
+		time.Sleep(backoff)
+		backoff *= 2
+		err = send()
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	fields := map[string]string{"channel": channelID, "error": err.Error()}
+	m.log(LogWarn, "Failed to send message", fields)
+
+	m.report(err, map[string]string{"channel": channelID})
+	if m.sendFailure != nil {
+		m.sendFailure(channelID, err)
+	}
+	return err
+}
+
+// transient reports whether a failed request is worth retrying
+func transient(err error) bool {
+	if restErr, ok := err.(*discordgo.RESTError); ok {
+		return restErr.Response != nil &&
+			restErr.Response.StatusCode >= http.StatusInternalServerError
+	}
+
+	_, ok := err.(net.Error)
+	return ok
+}
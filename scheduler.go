@@ -0,0 +1,194 @@
+package disgomux
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TaskContext is passed to scheduled tasks
+type TaskContext struct {
+	Session *discordgo.Session
+
+	// Schedule is the schedule the task was registered with
+	Schedule string
+
+	// Time is when the run was due
+	Time time.Time
+
+	mux     *Mux
+	context context.Context
+}
+
+// Schedule runs task on a schedule until cancel is called or the mux shuts
+// down: a cron expression with five fields (e.g. "0 9 * * 1-5" for 9:00 on
+// weekdays, in local time), a shorthand such as @hourly or @daily, or an
+// interval such as "@every 10m". Runs never overlap; one still going when the
+// next is due delays it. Panics are recovered and reported, and Shutdown()
+// waits for a run in progress.
+func (m *Mux) Schedule(
+	session *discordgo.Session,
+	spec string,
+	task func(ctx *TaskContext),
+) (cancel func(), err error) {
+	s, err := parseSchedule(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := make(chan struct{})
+	cancel = closeOnce(stop)
+
+	if !m.lifecycle.acquire() {
+		return cancel, nil
+	}
+
+	clock := m.clock()
+	go func() {
+		defer m.lifecycle.release()
+
+		for {
+			due := s.next(clock.Now())
+			if due.IsZero() {
+				m.log(LogWarn, "Scheduled task will never run again", map[string]string{
+					"schedule": spec,
+				})
+				return
+			}
+
+			timer := clock.NewTimer(due.Sub(clock.Now()))
+			select {
+			case <-timer.C():
+			case <-stop:
+				timer.Stop()
+				return
+			case <-m.lifecycle.closing():
+				timer.Stop()
+				return
+			}
+
+			m.runTask(&TaskContext{
+				Session:  session,
+				Schedule: spec,
+				Time:     due,
+				mux:      m,
+				context:  m.lifecycle.context(),
+			}, task)
+		}
+	}()
+
+	return cancel, nil
+}
+
+// ScheduleFunc runs fn once after the delay, even if the handler has returned,
+// unless cancel is called first, e.g. to lift a mute after ten minutes. Panics
+// are recovered and reported. Pending functions are dropped when the mux shuts
+// down, so use a store of your own for follow-ups which must survive restarts.
+func (ctx *Context) ScheduleFunc(
+	delay time.Duration,
+	fn func(task *TaskContext),
+) (cancel func()) {
+	return ctx.mux.scheduleOnce(ctx.Session, "in "+delay.String(), delay, fn)
+}
+
+// ScheduleReply sends content to the current channel after the delay, as
+// ScheduleFunc() runs functions
+func (ctx *Context) ScheduleReply(
+	delay time.Duration,
+	content string,
+) (cancel func()) {
+	return ctx.ScheduleFunc(delay, func(task *TaskContext) {
+		if _, err := ctx.ChannelSend(content); err != nil {
+			task.Report(err)
+		}
+	})
+}
+
+// scheduleOnce runs task once after the delay, unless cancelled or the mux
+// shuts down first
+func (m *Mux) scheduleOnce(
+	session *discordgo.Session,
+	spec string,
+	delay time.Duration,
+	task func(ctx *TaskContext),
+) (cancel func()) {
+	stop := make(chan struct{})
+	cancel = closeOnce(stop)
+
+	if !m.lifecycle.acquire() {
+		return cancel
+	}
+
+	clock := m.clock()
+	due := clock.Now().Add(delay)
+	go func() {
+		defer m.lifecycle.release()
+
+		timer := clock.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C():
+		case <-stop:
+			return
+		case <-m.lifecycle.closing():
+			return
+		}
+
+		m.runTask(&TaskContext{
+			Session:  session,
+			Schedule: spec,
+			Time:     due,
+			mux:      m,
+			context:  m.lifecycle.context(),
+		}, task)
+	}()
+
+	return cancel
+}
+
+// runTask runs a scheduled task, recovering and reporting panics
+func (m *Mux) runTask(ctx *TaskContext, task func(ctx *TaskContext)) {
+	defer func() {
+		if v := recover(); v != nil {
+			ctx.Report(&PanicError{Value: v, Stack: debug.Stack()})
+		}
+	}()
+
+	m.log(LogDebug, "Running scheduled task", ctx.fields())
+	task(ctx)
+}
+
+// Context returns a context.Context which is cancelled when the mux shuts
+// down, for the requests the task makes
+func (ctx *TaskContext) Context() context.Context {
+	return ctx.context
+}
+
+// Report passes an error of the task to the error reporter of the mux, and
+// logs it
+func (ctx *TaskContext) Report(err error) {
+	fields := ctx.fields()
+	ctx.mux.log(LogError, fmt.Sprintf("Scheduled task failed: %v", err), fields)
+	ctx.mux.report(err, fields)
+}
+
+// fields describes the task for error reports and logs
+func (ctx *TaskContext) fields() map[string]string {
+	return map[string]string{
+		"schedule": ctx.Schedule,
+		"due":      ctx.Time.Format(time.RFC3339),
+	}
+}
+
+// closeOnce returns a function closing ch, which may be called repeatedly
+func closeOnce(ch chan struct{}) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(ch) })
+	}
+}
@@ -0,0 +1,227 @@
+package disgomux
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Session is the subset of *discordgo.Session used by the mux and the Context
+// helpers to talk to Discord. *discordgo.Session satisfies it; MockSession is
+// an in-memory implementation for tests.
+type Session interface {
+	ChannelMessageSend(
+		channelID, content string,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Message, error)
+	ChannelMessageSendComplex(
+		channelID string,
+		data *discordgo.MessageSend,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Message, error)
+	ChannelMessageSendEmbed(
+		channelID string,
+		embed *discordgo.MessageEmbed,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Message, error)
+	ChannelMessageEdit(
+		channelID, messageID, content string,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Message, error)
+	ChannelMessageEditEmbed(
+		channelID, messageID string,
+		embed *discordgo.MessageEmbed,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Message, error)
+	ChannelMessageDelete(
+		channelID, messageID string,
+		options ...discordgo.RequestOption,
+	) error
+	ChannelMessages(
+		channelID string,
+		limit int,
+		beforeID, afterID, aroundID string,
+		options ...discordgo.RequestOption,
+	) ([]*discordgo.Message, error)
+	ChannelMessagesBulkDelete(
+		channelID string,
+		messages []string,
+		options ...discordgo.RequestOption,
+	) error
+	ChannelMessageCrosspost(
+		channelID, messageID string,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Message, error)
+
+	MessageReactionAdd(
+		channelID, messageID, emojiID string,
+		options ...discordgo.RequestOption,
+	) error
+	MessageReactionRemove(
+		channelID, messageID, emojiID, userID string,
+		options ...discordgo.RequestOption,
+	) error
+	MessageReactionsRemoveAll(
+		channelID, messageID string,
+		options ...discordgo.RequestOption,
+	) error
+
+	ChannelTyping(channelID string, options ...discordgo.RequestOption) error
+	MessageThreadStart(
+		channelID, messageID, name string,
+		archiveDuration int,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Channel, error)
+
+	Channel(
+		channelID string,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Channel, error)
+	Guild(
+		guildID string,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Guild, error)
+	GuildMember(
+		guildID, userID string,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Member, error)
+	GuildRoles(
+		guildID string,
+		options ...discordgo.RequestOption,
+	) ([]*discordgo.Role, error)
+	GuildMemberRoleAdd(
+		guildID, userID, roleID string,
+		options ...discordgo.RequestOption,
+	) error
+	GuildMemberDeleteWithReason(
+		guildID, userID, reason string,
+		options ...discordgo.RequestOption,
+	) error
+	UserChannelCreate(
+		recipientID string,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Channel, error)
+
+	InteractionRespond(
+		interaction *discordgo.Interaction,
+		resp *discordgo.InteractionResponse,
+		options ...discordgo.RequestOption,
+	) error
+	InteractionResponse(
+		interaction *discordgo.Interaction,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Message, error)
+	InteractionResponseEdit(
+		interaction *discordgo.Interaction,
+		edit *discordgo.WebhookEdit,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Message, error)
+	InteractionResponseDelete(
+		interaction *discordgo.Interaction,
+		options ...discordgo.RequestOption,
+	) error
+	FollowupMessageCreate(
+		interaction *discordgo.Interaction,
+		wait bool,
+		data *discordgo.WebhookParams,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Message, error)
+	FollowupMessageEdit(
+		interaction *discordgo.Interaction,
+		messageID string,
+		data *discordgo.WebhookEdit,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Message, error)
+	FollowupMessageDelete(
+		interaction *discordgo.Interaction,
+		messageID string,
+		options ...discordgo.RequestOption,
+	) error
+
+	ChannelWebhooks(
+		channelID string,
+		options ...discordgo.RequestOption,
+	) ([]*discordgo.Webhook, error)
+	WebhookCreate(
+		channelID, name, avatar string,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Webhook, error)
+	WebhookExecute(
+		webhookID, token string,
+		wait bool,
+		data *discordgo.WebhookParams,
+		options ...discordgo.RequestOption,
+	) (*discordgo.Message, error)
+}
+
+var _ Session = (*discordgo.Session)(nil)
+
+// SetSession replaces the session the mux and the Context helpers send
+// requests through, e.g. with a MockSession in tests. The gateway session
+// passed to Handle() is still used for its state. nil (the default) sends
+// through the gateway session.
+func (m *Mux) SetSession(session Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.session = session
+}
+
+// WithSession sets the session requests are sent through, as SetSession()
+func WithSession(session Session) Option {
+	return func(m *Mux) error {
+		m.SetSession(session)
+		return nil
+	}
+}
+
+// API returns the session requests of the invocation are sent through. Prefer
+// it over Session in handlers which should be testable with a MockSession.
+func (ctx *Context) API() Session {
+	if ctx.mux == nil {
+		return ctx.Session
+	}
+	return ctx.mux.api(ctx.Session)
+}
+
+// State returns the state cache of the gateway session, nil if there is none
+func (ctx *Context) State() *discordgo.State {
+	if ctx.Session == nil {
+		return nil
+	}
+	return ctx.Session.State
+}
+
+// BotUser returns the user of the bot from the state, nil if it is unknown
+func (ctx *Context) BotUser() *discordgo.User {
+	state := ctx.State()
+	if state == nil {
+		return nil
+	}
+	return state.User
+}
+
+// Latency returns the heartbeat latency of the gateway session, zero if there
+// is none
+func (ctx *Context) Latency() time.Duration {
+	if ctx.Session == nil {
+		return 0
+	}
+	return ctx.Session.HeartbeatLatency()
+}
+
+// api returns the session requests are sent through, in place of the gateway
+// session
+func (m *Mux) api(session *discordgo.Session) Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var api Session = session
+	if m.session != nil {
+		api = m.session
+	}
+	if m.dryRun != nil {
+		return &dryRunSession{Session: api, mux: m, recorder: m.dryRun}
+	}
+	return api
+}
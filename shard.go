@@ -0,0 +1,90 @@
+package disgomux
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// shardCounters hold the debug counters of each shard, keyed by shard ID
+type shardCounters struct {
+	counters sync.Map
+}
+
+// ShardID returns the ID of the shard whose session received the invocation,
+// for bots running several sessions (manual sharding) with one mux. Zero
+// without sharding.
+func (ctx *Context) ShardID() int {
+	return shardOf(ctx.Session)
+}
+
+// ShardCount returns the number of shards the bot runs, as configured on the
+// session which received the invocation. One without sharding.
+func (ctx *Context) ShardCount() int {
+	if ctx.Session == nil || ctx.Session.ShardCount < 1 {
+		return 1
+	}
+	return ctx.Session.ShardCount
+}
+
+// DebugStatsByShard returns DebugStats() for each shard which received an
+// event, keyed by shard ID. The worker pool is shared by all shards, so the
+// queue depth is only reported by DebugStats().
+func (m *Mux) DebugStatsByShard() map[int]DebugStats {
+	stats := make(map[int]DebugStats)
+	m.shards.counters.Range(func(key, value interface{}) bool {
+		c := value.(*debugCounters)
+		stats[key.(int)] = DebugStats{
+			MessagesSeen:       atomic.LoadUint64(&c.messages),
+			CommandsDispatched: atomic.LoadUint64(&c.dispatched),
+			NotFound:           atomic.LoadUint64(&c.notFound),
+			ActiveHandlers:     atomic.LoadInt64(&c.active),
+		}
+		return true
+	})
+	return stats
+}
+
+// shardOf returns the shard ID of the session, zero if there is none
+func shardOf(session *discordgo.Session) int {
+	if session == nil {
+		return 0
+	}
+	return session.ShardID
+}
+
+// shard returns the debug counters of the shard, creating them on first use
+func (s *shardCounters) shard(id int) *debugCounters {
+	if c, ok := s.counters.Load(id); ok {
+		return c.(*debugCounters)
+	}
+	c, _ := s.counters.LoadOrStore(id, &debugCounters{})
+	return c.(*debugCounters)
+}
+
+// countMessage counts a message or interaction received by the session
+func (m *Mux) countMessage(session *discordgo.Session) {
+	atomic.AddUint64(&m.counters.messages, 1)
+	atomic.AddUint64(&m.shards.shard(shardOf(session)).messages, 1)
+}
+
+// countNotFound counts a prefixed message received by the session which did
+// not match a command
+func (m *Mux) countNotFound(session *discordgo.Session) {
+	atomic.AddUint64(&m.counters.notFound, 1)
+	atomic.AddUint64(&m.shards.shard(shardOf(session)).notFound, 1)
+}
+
+// countDispatched counts an invocation received by the session being
+// dispatched
+func (m *Mux) countDispatched(session *discordgo.Session) {
+	atomic.AddUint64(&m.counters.dispatched, 1)
+	atomic.AddUint64(&m.shards.shard(shardOf(session)).dispatched, 1)
+}
+
+// countActive adds delta to the handlers running for the session
+func (m *Mux) countActive(session *discordgo.Session, delta int64) {
+	atomic.AddInt64(&m.counters.active, delta)
+	atomic.AddInt64(&m.shards.shard(shardOf(session)).active, delta)
+}
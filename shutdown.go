@@ -0,0 +1,119 @@
+package disgomux
+
+import (
+	"context"
+	"sync"
+)
+
+// lifecycle tracks in-flight work so the mux can shut down gracefully
+type lifecycle struct {
+	mu       sync.RWMutex
+	closed   bool
+	inflight sync.WaitGroup
+	done     chan struct{}
+	base     context.Context
+	cancel   context.CancelFunc
+	once     sync.Once
+	stopped  sync.Once
+}
+
+// acquire registers a unit of in-flight work, reporting false once the mux is
+// shutting down
+func (l *lifecycle) acquire() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.closed {
+		return false
+	}
+	l.inflight.Add(1)
+	return true
+}
+
+// isClosed reports whether the mux is shutting down
+func (l *lifecycle) isClosed() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.closed
+}
+
+// extend registers a unit of in-flight work spawned by work already in flight,
+// which holds off shutdown, so it is registered even once the mux is closed
+func (l *lifecycle) extend() {
+	l.inflight.Add(1)
+}
+
+// release marks a unit of in-flight work as finished
+func (l *lifecycle) release() {
+	l.inflight.Done()
+}
+
+// closing returns a channel which is closed when the mux starts shutting down
+func (l *lifecycle) closing() <-chan struct{} {
+	return l.channel()
+}
+
+// context returns the context invocations derive from, cancelled once
+// shutdown gives up waiting on them
+func (l *lifecycle) context() context.Context {
+	l.init()
+	return l.base
+}
+
+// channel returns the shutdown channel
+func (l *lifecycle) channel() chan struct{} {
+	l.init()
+	return l.done
+}
+
+// init lazily creates the shutdown channel and base context
+func (l *lifecycle) init() {
+	l.once.Do(func() {
+		l.done = make(chan struct{})
+		l.base, l.cancel = context.WithCancel(context.Background())
+	})
+}
+
+// Shutdown stops the mux from dispatching new invocations and waits for
+// in-flight handlers to finish, until ctx is done. If ctx is done first, the
+// contexts of the remaining invocations are cancelled and ctx.Err() is
+// returned. Either way the workers of the worker pool exit once its queue is
+// empty. Paginators are wrapped up and messages pending deletion are deleted
+// right away. Prompts waiting inside handlers keep receiving messages until
+// their handler returns.
+func (m *Mux) Shutdown(ctx context.Context) error {
+	closing := m.lifecycle.channel()
+
+	m.lifecycle.mu.Lock()
+	if !m.lifecycle.closed {
+		m.lifecycle.closed = true
+		close(closing)
+	}
+	m.lifecycle.mu.Unlock()
+
+	m.temps.flush()
+
+	done := make(chan struct{})
+	go func() {
+		m.lifecycle.inflight.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	m.lifecycle.cancel()
+
+	/* Stop the workers, only once even if Shutdown is called again */
+	m.lifecycle.stopped.Do(func() {
+		if p := m.workers(); p != nil {
+			p.stop()
+		}
+	})
+	return err
+}
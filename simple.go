@@ -0,0 +1,285 @@
+package disgomux
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// SimpleTemplateData is the data supplied when rendering the content of
+	// a simple command, e.g. "Welcome to {{.Guild}}, {{.User.Mention}}!"
+	SimpleTemplateData struct {
+		User    *discordgo.User
+		Member  *discordgo.Member
+		Guild   TemplateGuild
+		Channel TemplateChannel
+
+		Args      string
+		Arguments []string
+	}
+
+	// TemplateGuild is the guild of an invocation in templates. Name is
+	// empty in DMs or if the guild could not be resolved.
+	TemplateGuild struct {
+		ID, Name string
+	}
+
+	// TemplateChannel is the channel of an invocation in templates. Name is
+	// empty if the channel could not be resolved.
+	TemplateChannel struct {
+		ID, Name string
+	}
+)
+
+// String returns the name of the guild, so {{.Guild}} renders as the name
+func (g TemplateGuild) String() string {
+	return g.Name
+}
+
+// String mentions the channel, so {{.Channel}} renders as a link to it
+func (c TemplateChannel) String() string {
+	if c.ID == "" {
+		return ""
+	}
+	return "<#" + c.ID + ">"
+}
+
+// simpleState holds the per-command state shared between copies of a
+// registered simple command
+type simpleState struct {
+	contents  []string
+	templates []*template.Template
+	next      uint32
+
+	fileMu sync.Mutex
+	file   []byte
+}
+
+// newSimpleState creates the state of a simple command, parsing its contents.
+// The first parse error is returned along with the state, in which contents
+// failing to parse are sent verbatim. The contents of tags are not templates.
+func newSimpleState(simple SimpleCommand) (*simpleState, error) {
+	st := &simpleState{contents: simple.Contents}
+	if len(st.contents) == 0 {
+		st.contents = []string{simple.Content}
+	}
+
+	var first error
+	st.templates = make([]*template.Template, len(st.contents))
+	if simple.Tag {
+		return st, nil
+	}
+	for i, c := range st.contents {
+		t, err := parseSimpleTemplate(c)
+		if err != nil && first == nil {
+			first = err
+		}
+		st.templates[i] = t
+	}
+
+	return st, first
+}
+
+// pick returns the index of the content to send for the next invocation
+func (st *simpleState) pick(roundRobin bool) int {
+	if len(st.contents) == 1 {
+		return 0
+	}
+
+	if roundRobin {
+		return int((atomic.AddUint32(&st.next, 1) - 1) % uint32(len(st.contents)))
+	}
+	return rand.Intn(len(st.contents))
+}
+
+// loadFile returns the content of f, reading and caching it on first use. If
+// loading fails it is retried on the next call.
+func (st *simpleState) loadFile(f *SimpleFile) ([]byte, error) {
+	if f.Data != nil {
+		return f.Data, nil
+	}
+
+	st.fileMu.Lock()
+	defer st.fileMu.Unlock()
+
+	if st.file != nil {
+		return st.file, nil
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+
+	switch {
+	case f.Path != "":
+		data, err = ioutil.ReadFile(f.Path)
+	case f.URL != "":
+		data, err = fetchFile(f.URL)
+	default:
+		err = fmt.Errorf("File %s has no content source", f.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	st.file = data
+	return data, nil
+}
+
+// fileClient fetches the files of simple commands, giving up on servers which
+// don't answer
+var fileClient = &http.Client{Timeout: 30 * time.Second}
+
+func fetchFile(url string) ([]byte, error) {
+	resp, err := fileClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Fetching %s returned %s", url, resp.Status)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// fileName returns the name f should be uploaded with
+func (f *SimpleFile) fileName() string {
+	switch {
+	case f.Name != "":
+		return f.Name
+	case f.Path != "":
+		return path.Base(f.Path)
+	default:
+		return path.Base(f.URL)
+	}
+}
+
+// parseSimpleTemplate parses content as a template if it contains any
+// actions, returning nil for content sent verbatim
+func parseSimpleTemplate(content string) (*template.Template, error) {
+	if !strings.Contains(content, "{{") {
+		return nil, nil
+	}
+	return template.New("simple").Parse(content)
+}
+
+// handleSimple responds to an invocation of a simple command, returning the
+// error it was rejected with, if any
+func (m *Mux) handleSimple(ctx *Context, simple SimpleCommand) error {
+	err := m.admit(ctx, simple.Command, admission{
+		permissions: simple.Permissions,
+		cooldown:    simple.Cooldown,
+	})
+	if err != nil {
+		return err
+	}
+
+	if simple.state == nil {
+		simple.state, _ = newSimpleState(simple)
+	}
+
+	for _, r := range simple.Reactions {
+		ctx.React(r)
+	}
+
+	content := renderSimple(ctx, simple)
+
+	var file *discordgo.File
+	if simple.File != nil {
+		if data, err := simple.state.loadFile(simple.File); err == nil {
+			file = &discordgo.File{
+				Name:   simple.File.fileName(),
+				Reader: bytes.NewReader(data),
+			}
+		}
+	}
+
+	if content == "" && simple.Embed == nil && file == nil {
+		return nil
+	}
+
+	ms := &discordgo.MessageSend{Content: content}
+	if simple.Embed != nil {
+		ms.Embeds = []*discordgo.MessageEmbed{simple.Embed}
+	}
+	if file != nil {
+		ms.Files = []*discordgo.File{file}
+	}
+	ctx.ChannelSendComplex(ms)
+	return nil
+}
+
+// renderSimple picks the content of simple to send, executing its template if
+// it has one
+func renderSimple(ctx *Context, simple SimpleCommand) string {
+	st := simple.state
+	i := st.pick(simple.RoundRobin)
+	if simple.Tag {
+		return renderTag(st.contents[i], simpleTemplateData(ctx))
+	}
+	if st.templates[i] == nil {
+		return st.contents[i]
+	}
+
+	var sb strings.Builder
+	if err := st.templates[i].Execute(&sb, simpleTemplateData(ctx)); err != nil {
+		ctx.mux.logCtx(
+			ctx, LogWarn, "Failed to render "+simple.Command+": "+err.Error(),
+		)
+		return st.contents[i]
+	}
+	return sb.String()
+}
+
+// renderTag replaces the placeholders of the content of a tag. Tags are
+// written by members, so unlike templates they can't reach into the data.
+func renderTag(content string, data SimpleTemplateData) string {
+	var user, username string
+	if data.User != nil {
+		user, username = data.User.Mention(), data.User.Username
+	}
+
+	return strings.NewReplacer(
+		"{user}", user,
+		"{username}", username,
+		"{guild}", data.Guild.Name,
+		"{channel}", data.Channel.String(),
+		"{args}", data.Args,
+	).Replace(content)
+}
+
+// simpleTemplateData returns the data simple commands are rendered with
+func simpleTemplateData(ctx *Context) SimpleTemplateData {
+	data := SimpleTemplateData{
+		User:      ctx.Message.Author,
+		Member:    ctx.Message.Member,
+		Args:      strings.Join(ctx.Arguments, " "),
+		Arguments: ctx.Arguments,
+	}
+
+	data.Guild.ID = ctx.Message.GuildID
+	if data.Guild.ID != "" {
+		if guild, err := ctx.Guild(); err == nil {
+			data.Guild.Name = guild.Name
+		}
+	}
+	data.Channel.ID = ctx.Message.ChannelID
+	if channel, err := ctx.Channel(); err == nil {
+		data.Channel.Name = channel.Name
+	}
+	return data
+}
@@ -0,0 +1,135 @@
+package disgomux
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// ConfigSnapshot is the state of a mux saved by ExportConfig: its prefix,
+// error texts, guild configurations (with their ignore lists), disabled
+// commands and simple commands
+type ConfigSnapshot struct {
+	Prefix           string              `json:"prefix"`
+	ErrorTexts       ErrorTexts          `json:"error_texts"`
+	Guilds           []GuildConfig       `json:"guilds"`
+	DisabledCommands map[string][]string `json:"disabled_commands"`
+	SimpleCommands   []SimpleCommand     `json:"simple_commands"`
+}
+
+// ExportConfig writes the state of the mux to w as JSON, e.g. to back it up or
+// move the bot to another host. See ConfigSnapshot.
+func (m *Mux) ExportConfig(w io.Writer) error {
+	snapshot, err := m.snapshot()
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(snapshot)
+}
+
+// ImportConfig reads a snapshot written by ExportConfig from r and merges it
+// into the mux: guild configurations and simple commands replace those of the
+// same guild and name, and are saved to the configured stores. An empty
+// prefix or error texts in the snapshot keep the current ones.
+func (m *Mux) ImportConfig(r io.Reader) error {
+	var snapshot ConfigSnapshot
+	if err := decodeSnapshot(r, &snapshot); err != nil {
+		return err
+	}
+	return m.restore(snapshot)
+}
+
+// decodeSnapshot reads a snapshot written by ExportConfig
+func decodeSnapshot(r io.Reader, s *ConfigSnapshot) error {
+	return json.NewDecoder(r).Decode(s)
+}
+
+// snapshot captures the state of the mux
+func (m *Mux) snapshot() (ConfigSnapshot, error) {
+	guilds, err := m.configs.List()
+	if err != nil {
+		return ConfigSnapshot{}, err
+	}
+
+	s := ConfigSnapshot{
+		Prefix:           m.prefix(),
+		ErrorTexts:       m.texts(),
+		Guilds:           guilds,
+		DisabledCommands: make(map[string][]string),
+	}
+
+	m.mu.RLock()
+	for guildID, commands := range m.disabled {
+		for c, disabled := range commands {
+			if disabled {
+				s.DisabledCommands[guildID] = append(s.DisabledCommands[guildID], c)
+			}
+		}
+		sort.Strings(s.DisabledCommands[guildID])
+	}
+	m.mu.RUnlock()
+
+	m.simpleMu.RLock()
+	s.SimpleCommands = appendSimple(s.SimpleCommands, m.SimpleCommands)
+	for _, table := range m.GuildSimpleCommands {
+		s.SimpleCommands = appendSimple(s.SimpleCommands, table)
+	}
+	m.simpleMu.RUnlock()
+
+	sort.Slice(s.SimpleCommands, func(i, j int) bool {
+		a, b := s.SimpleCommands[i], s.SimpleCommands[j]
+		if a.GuildID != b.GuildID {
+			return a.GuildID < b.GuildID
+		}
+		return a.Command < b.Command
+	})
+	return s, nil
+}
+
+// restore merges a snapshot into the mux
+func (m *Mux) restore(s ConfigSnapshot) error {
+	for _, c := range s.Guilds {
+		if err := m.configs.Save(c); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range s.SimpleCommands {
+		if err := m.persistSimple(c); err != nil {
+			return err
+		}
+	}
+	if err := m.RegisterSimple(s.SimpleCommands...); err != nil {
+		return err
+	}
+
+	for guildID, commands := range s.DisabledCommands {
+		m.DisableCommand(guildID, commands...)
+	}
+
+	m.mu.Lock()
+	if s.Prefix != "" {
+		m.Prefix = s.Prefix
+	}
+	if s.ErrorTexts != (ErrorTexts{}) {
+		m.errorTexts = s.ErrorTexts
+	}
+	m.mu.Unlock()
+	return nil
+}
+
+// appendSimple appends the simple commands of a table, skipping aliases
+func appendSimple(
+	list []SimpleCommand,
+	table map[string]SimpleCommand,
+) []SimpleCommand {
+	for name, c := range table {
+		if name == c.Command {
+			list = append(list, c)
+		}
+	}
+	return list
+}
@@ -0,0 +1,188 @@
+package disgomux
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// MessageLimit is the maximum number of characters in a Discord message
+const MessageLimit = 2000
+
+// ChannelSendLong sends content to the current channel, split into as many
+// messages as needed to stay within MessageLimit. Content is split on line
+// boundaries where possible, and code blocks cut by a split are closed and
+// reopened in the next message. All sent messages are returned, up to the first
+// error.
+func (ctx *Context) ChannelSendLong(
+	content string,
+) ([]*discordgo.Message, error) {
+	return ctx.sendChunks(splitMessage(content, MessageLimit))
+}
+
+// sendChunks sends each chunk as a separate message, stopping at the first
+// error
+func (ctx *Context) sendChunks(chunks []string) ([]*discordgo.Message, error) {
+	var msgs []*discordgo.Message
+	for _, chunk := range chunks {
+		msg, err := ctx.ChannelSend(chunk)
+		if err != nil {
+			return msgs, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// splitMessage splits content into chunks of at most limit characters
+func splitMessage(content string, limit int) []string {
+	if utf8.RuneCountInString(content) <= limit {
+		return []string{content}
+	}
+
+	var (
+		chunks []string
+		chunk  strings.Builder
+		size   int
+		inCode bool
+		fence  string
+	)
+
+	/* Count of characters needed to close an open code block */
+	closing := func() int {
+		if inCode {
+			return len("\n```")
+		}
+		return 0
+	}
+
+	flush := func() {
+		if size == 0 {
+			return
+		}
+
+		out := chunk.String()
+		if inCode {
+			out = strings.TrimSuffix(out, "\n") + "\n```"
+		}
+		chunks = append(chunks, out)
+
+		chunk.Reset()
+		size = 0
+		if inCode {
+			chunk.WriteString(fence + "\n")
+			size = utf8.RuneCountInString(fence) + 1
+		}
+	}
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+
+		/* A line opening a block needs room for the fence closing it */
+		trimmed := strings.TrimSpace(line)
+		isFence := strings.HasPrefix(trimmed, "```")
+		opens := isFence && !inCode && strings.Count(trimmed, "```") == 1
+		reserve := closing()
+		if opens {
+			reserve = len("\n```")
+		}
+
+		n := utf8.RuneCountInString(line)
+		if size+n+reserve > limit {
+			flush()
+		}
+
+		/* Lines which don't fit on their own are cut into pieces */
+		for size+n+reserve > limit {
+			room := limit - size - reserve
+			if room <= 0 {
+				break
+			}
+
+			piece := runePrefix(line, room)
+			chunk.WriteString(piece)
+			size += room
+			flush()
+
+			line = line[len(piece):]
+			n -= room
+		}
+
+		chunk.WriteString(line)
+		size += n
+
+		/* A fence either opens a block (optionally with a language) or closes
+		the open one */
+		if isFence {
+			if inCode {
+				inCode = false
+			} else if opens {
+				inCode = true
+				fence = trimmed
+			}
+		}
+	}
+
+	if size != 0 {
+		out := chunk.String()
+		if inCode {
+			out = strings.TrimSuffix(out, "\n") + "\n```"
+		}
+		chunks = append(chunks, out)
+	}
+
+	return chunks
+}
+
+// runePrefix returns the first n runes of s
+func runePrefix(s string, n int) string {
+	i := 0
+	for pos := range s {
+		if i == n {
+			return s[:pos]
+		}
+		i++
+	}
+	return s
+}
+
+// codeBlock wraps content in a code block highlighted as lang, escaping the
+// backtick fences within content so they can't break out of the block
+func codeBlock(lang, content string) string {
+	escaped := strings.Replace(content, "```", "``\u200b`", -1)
+	return "```" + lang + "\n" + strings.TrimSuffix(escaped, "\n") + "\n```"
+}
+
+// codeSplitLimit is the largest number of messages SendCode splits a code block
+// into before attaching it as a file instead
+const codeSplitLimit = 3
+
+// SendCode sends content to the current channel as a code block highlighted as
+// lang (which may be empty). Backtick fences within content are escaped so they
+// can't break out of the block. Long content is split over several messages,
+// or attached as a file if it would take more than a few.
+func (ctx *Context) SendCode(
+	lang, content string,
+) ([]*discordgo.Message, error) {
+	block := codeBlock(lang, content)
+
+	if chunks := splitMessage(block, MessageLimit); len(chunks) <= codeSplitLimit {
+		return ctx.sendChunks(chunks)
+	}
+
+	ext := lang
+	if ext == "" {
+		ext = "txt"
+	}
+
+	msg, err := ctx.ChannelSendFile(
+		"output."+ext, strings.NewReader(content), "",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return []*discordgo.Message{msg}, nil
+}
@@ -0,0 +1,52 @@
+package disgomux
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitMessageLimit(t *testing.T) {
+	fenced := strings.Repeat("```go\nfmt.Println()\n```\n", 200)
+
+	var mixed strings.Builder
+	for i := 0; i < 300; i++ {
+		mixed.WriteString(strings.Repeat("ä", i%97) + "\n")
+		if i%7 == 0 {
+			mixed.WriteString("```\n")
+		}
+	}
+
+	inputs := map[string]string{
+		"fence after long line": strings.Repeat("a", 1993) +
+			"\n```go\nfmt.Println()\n```\n",
+		"fence at limit":  strings.Repeat("a", 1995) + "\n```\nb\n```",
+		"many fences":     fenced,
+		"unclosed fence":  "```\n" + strings.Repeat("line\n", 1000),
+		"long code line":  "```\n" + strings.Repeat("x", 5000) + "\n```",
+		"mixed":           mixed.String(),
+		"no newlines":     strings.Repeat("ü", 4500),
+		"long after code": "```\nx\n```\n" + strings.Repeat("b", 3000),
+	}
+
+	for name, content := range inputs {
+		chunks := splitMessage(content, MessageLimit)
+		if len(chunks) == 0 {
+			t.Errorf("%s: no chunks", name)
+		}
+		for i, chunk := range chunks {
+			if n := utf8.RuneCountInString(chunk); n > MessageLimit {
+				t.Errorf("%s: chunk %d has %d characters", name, i, n)
+			}
+		}
+	}
+}
+
+func TestSplitMessageClosesFences(t *testing.T) {
+	content := "```go\n" + strings.Repeat("fmt.Println()\n", 400) + "```"
+	for i, chunk := range splitMessage(content, MessageLimit) {
+		if strings.Count(chunk, "```")%2 != 0 {
+			t.Errorf("chunk %d leaves a code block open", i)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package disgomux
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type (
+	// CommandStats describes the usage of a command
+	CommandStats struct {
+		Invocations, Errors uint64
+		UniqueUsers         int
+		AverageLatency      time.Duration
+	}
+
+	// StatsStore accumulates command usage. Implementations must be safe for
+	// concurrent use.
+	StatsStore interface {
+		// Record adds an invocation of the command by the user
+		Record(command, userID string, latency time.Duration, failed bool) error
+
+		// Stats returns the usage of every command invoked, keyed by name
+		Stats() (map[string]CommandStats, error)
+	}
+
+	// ShardStatsStore is a StatsStore which partitions usage by shard, for
+	// bots running several sessions with one mux. If the store set with
+	// SetStatsStore() implements it, the mux records invocations with
+	// RecordShard instead of Record.
+	ShardStatsStore interface {
+		StatsStore
+
+		// RecordShard is Record for an invocation received by the shard
+		RecordShard(
+			shard int,
+			command, userID string,
+			latency time.Duration,
+			failed bool,
+		) error
+
+		// ShardStats returns the usage of every command invoked in the shard,
+		// keyed by name
+		ShardStats(shard int) (map[string]CommandStats, error)
+	}
+
+	// MemoryStatsStore is a ShardStatsStore which keeps usage in memory. It
+	// remembers every user who invoked each command, to count unique users.
+	MemoryStatsStore struct {
+		mu     sync.Mutex
+		shards map[int]map[string]*commandUsage
+	}
+
+	commandUsage struct {
+		invocations, errors uint64
+		latency             time.Duration
+		users               map[string]struct{}
+	}
+)
+
+// NewMemoryStatsStore creates an empty in-memory stats store
+func NewMemoryStatsStore() *MemoryStatsStore {
+	return &MemoryStatsStore{shards: make(map[int]map[string]*commandUsage)}
+}
+
+// Record adds an invocation of the command by the user, to shard zero
+func (s *MemoryStatsStore) Record(
+	command, userID string,
+	latency time.Duration,
+	failed bool,
+) error {
+	return s.RecordShard(0, command, userID, latency, failed)
+}
+
+// RecordShard adds an invocation of the command by the user in the shard
+func (s *MemoryStatsStore) RecordShard(
+	shard int,
+	command, userID string,
+	latency time.Duration,
+	failed bool,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	commands, ok := s.shards[shard]
+	if !ok {
+		commands = make(map[string]*commandUsage)
+		s.shards[shard] = commands
+	}
+
+	u, ok := commands[command]
+	if !ok {
+		u = &commandUsage{users: make(map[string]struct{})}
+		commands[command] = u
+	}
+
+	u.invocations++
+	if failed {
+		u.errors++
+	}
+	u.latency += latency
+	u.users[userID] = struct{}{}
+	return nil
+}
+
+// Stats returns the usage of every command invoked in any shard, keyed by name
+func (s *MemoryStatsStore) Stats() (map[string]CommandStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	/* Merge the shards, counting users who invoked a command in several once */
+	total := make(map[string]*commandUsage)
+	for _, commands := range s.shards {
+		for name, u := range commands {
+			t, ok := total[name]
+			if !ok {
+				t = &commandUsage{users: make(map[string]struct{})}
+				total[name] = t
+			}
+			t.invocations += u.invocations
+			t.errors += u.errors
+			t.latency += u.latency
+			for id := range u.users {
+				t.users[id] = struct{}{}
+			}
+		}
+	}
+	return usageStats(total), nil
+}
+
+// ShardStats returns the usage of every command invoked in the shard, keyed by
+// name
+func (s *MemoryStatsStore) ShardStats(
+	shard int,
+) (map[string]CommandStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return usageStats(s.shards[shard]), nil
+}
+
+// usageStats summarizes the usage of each command
+func usageStats(commands map[string]*commandUsage) map[string]CommandStats {
+	stats := make(map[string]CommandStats, len(commands))
+	for name, u := range commands {
+		stats[name] = CommandStats{
+			Invocations:    u.invocations,
+			Errors:         u.errors,
+			UniqueUsers:    len(u.users),
+			AverageLatency: u.latency / time.Duration(u.invocations),
+		}
+	}
+	return stats
+}
+
+// ErrorRate returns the fraction of invocations which failed
+func (s CommandStats) ErrorRate() float64 {
+	if s.Invocations == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Invocations)
+}
+
+// SetStatsStore sets the store used to accumulate command usage. Defaults to
+// a MemoryStatsStore; nil disables usage statistics.
+func (m *Mux) SetStatsStore(store StatsStore) {
+	m.stats = store
+}
+
+// Stats returns the usage of every command invoked since the mux started (or
+// as long as the stats store remembers), keyed by name
+func (m *Mux) Stats() (map[string]CommandStats, error) {
+	if m.stats == nil {
+		return map[string]CommandStats{}, nil
+	}
+	return m.stats.Stats()
+}
+
+// ShardStats returns the usage of every command invoked in the shard, keyed by
+// name. Fails if the stats store does not implement ShardStatsStore.
+func (m *Mux) ShardStats(shard int) (map[string]CommandStats, error) {
+	switch store := m.stats.(type) {
+	case nil:
+		return map[string]CommandStats{}, nil
+	case ShardStatsStore:
+		return store.ShardStats(shard)
+	default:
+		return nil, fmt.Errorf("Stats store does not partition usage by shard")
+	}
+}
+
+// recordStats adds a finished invocation to the usage statistics
+func (m *Mux) recordStats(ctx *Context, latency time.Duration, err error) {
+	if m.stats == nil {
+		return
+	}
+
+	var recordErr error
+	if store, ok := m.stats.(ShardStatsStore); ok {
+		recordErr = store.RecordShard(
+			ctx.ShardID(), ctx.Command, ctx.Message.Author.ID, latency, err != nil,
+		)
+	} else {
+		recordErr = m.stats.Record(
+			ctx.Command, ctx.Message.Author.ID, latency, err != nil,
+		)
+	}
+	if recordErr != nil {
+		m.reportCtx(ctx, recordErr)
+	}
+}
@@ -0,0 +1,243 @@
+package disgomux
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+type (
+	// SimpleCommandStore persists simple commands created at runtime, so they
+	// survive restarts. Commands are identified by their guild ID (empty for
+	// global commands) and name. Implementations must be safe for concurrent
+	// use.
+	SimpleCommandStore interface {
+		Load(guildID, name string) (SimpleCommand, bool, error)
+		Save(c SimpleCommand) error
+		Delete(guildID, name string) error
+		List() ([]SimpleCommand, error)
+	}
+
+	// MemorySimpleStore is a SimpleCommandStore which keeps simple commands in
+	// memory only
+	MemorySimpleStore struct {
+		mu       sync.RWMutex
+		commands map[simpleKey]SimpleCommand
+	}
+
+	simpleKey struct {
+		guildID, name string
+	}
+
+	// FileSimpleStore is a SimpleCommandStore which keeps simple commands in a
+	// JSON file. The whole file is rewritten on every change.
+	FileSimpleStore struct {
+		path string
+		mem  *MemorySimpleStore
+		once sync.Once
+		err  error
+	}
+)
+
+// NewMemorySimpleStore creates an empty in-memory store
+func NewMemorySimpleStore() *MemorySimpleStore {
+	return &MemorySimpleStore{commands: make(map[simpleKey]SimpleCommand)}
+}
+
+// Load returns the simple command with the specified guild ID and name
+func (s *MemorySimpleStore) Load(
+	guildID, name string,
+) (SimpleCommand, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.commands[simpleKey{guildID, name}]
+	return c, ok, nil
+}
+
+// Save stores c, replacing any simple command with the same guild ID and name
+func (s *MemorySimpleStore) Save(c SimpleCommand) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c.state = nil
+	s.commands[simpleKey{c.GuildID, c.Command}] = c
+	return nil
+}
+
+// Delete removes the simple command with the specified guild ID and name
+func (s *MemorySimpleStore) Delete(guildID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.commands, simpleKey{guildID, name})
+	return nil
+}
+
+// List returns all stored simple commands sorted by guild ID and name
+func (s *MemorySimpleStore) List() ([]SimpleCommand, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sorted(), nil
+}
+
+// sorted returns the stored commands ordered by guild ID and name. Must be
+// called with mu held.
+func (s *MemorySimpleStore) sorted() []SimpleCommand {
+	list := make([]SimpleCommand, 0, len(s.commands))
+	for _, c := range s.commands {
+		list = append(list, c)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].GuildID != list[j].GuildID {
+			return list[i].GuildID < list[j].GuildID
+		}
+		return list[i].Command < list[j].Command
+	})
+	return list
+}
+
+// NewFileSimpleStore creates a store backed by the JSON file at path. The file
+// is created on the first save if it does not exist.
+func NewFileSimpleStore(path string) *FileSimpleStore {
+	return &FileSimpleStore{path: path, mem: NewMemorySimpleStore()}
+}
+
+// Load returns the simple command with the specified guild ID and name
+func (s *FileSimpleStore) Load(
+	guildID, name string,
+) (SimpleCommand, bool, error) {
+	if err := s.read(); err != nil {
+		return SimpleCommand{}, false, err
+	}
+	return s.mem.Load(guildID, name)
+}
+
+// Save stores c, replacing any simple command with the same guild ID and name
+func (s *FileSimpleStore) Save(c SimpleCommand) error {
+	if err := s.read(); err != nil {
+		return err
+	}
+
+	s.mem.Save(c)
+	return s.write()
+}
+
+// Delete removes the simple command with the specified guild ID and name
+func (s *FileSimpleStore) Delete(guildID, name string) error {
+	if err := s.read(); err != nil {
+		return err
+	}
+
+	s.mem.Delete(guildID, name)
+	return s.write()
+}
+
+// List returns all stored simple commands sorted by guild ID and name
+func (s *FileSimpleStore) List() ([]SimpleCommand, error) {
+	if err := s.read(); err != nil {
+		return nil, err
+	}
+	return s.mem.List()
+}
+
+// read loads the file into memory the first time the store is used
+func (s *FileSimpleStore) read() error {
+	s.once.Do(func() {
+		data, err := ioutil.ReadFile(s.path)
+		if os.IsNotExist(err) {
+			return
+		}
+		if err != nil {
+			s.err = err
+			return
+		}
+
+		var list []SimpleCommand
+		if err := json.Unmarshal(data, &list); err != nil {
+			s.err = err
+			return
+		}
+
+		for _, c := range list {
+			s.mem.Save(c)
+		}
+	})
+	return s.err
+}
+
+// write replaces the file with the current contents of the store
+func (s *FileSimpleStore) write() error {
+	/* Hold the write lock so concurrent saves can't interleave their writes */
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+
+	data, err := json.MarshalIndent(s.mem.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), ".simple-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// UseSimpleStore sets the store used to persist simple commands created at
+// runtime (e.g. by the TagCommand) and registers all simple commands already in
+// the store.
+func (m *Mux) UseSimpleStore(store SimpleCommandStore) error {
+	list, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.simpleStore = store
+	m.mu.Unlock()
+
+	return m.RegisterSimple(list...)
+}
+
+// persistSimple saves c to the simple command store, if one is in use
+func (m *Mux) persistSimple(c SimpleCommand) error {
+	store := m.simpleCommandStore()
+	if store == nil {
+		return nil
+	}
+	return store.Save(c)
+}
+
+// forgetSimple deletes the named command of the guild from the simple command
+// store, if one is in use
+func (m *Mux) forgetSimple(guildID, name string) error {
+	store := m.simpleCommandStore()
+	if store == nil {
+		return nil
+	}
+	return store.Delete(guildID, name)
+}
+
+// simpleCommandStore returns the simple command store, if one is in use
+func (m *Mux) simpleCommandStore() SimpleCommandStore {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.simpleStore
+}
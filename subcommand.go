@@ -0,0 +1,168 @@
+package disgomux
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// ParentCommand is a Command with subcommands, e.g. config with set and
+	// get. "!config set prefix ?" and "/config set prefix:?" are both routed
+	// to the handler of the set subcommand, with the arguments following it.
+	// Handle is called when no subcommand matches a message.
+	ParentCommand interface {
+		Command
+		Subcommands() []*Subcommand
+	}
+
+	// Subcommand is a subcommand of a ParentCommand. A subcommand with
+	// subcommands of its own is a group, and its Handler and Arguments are
+	// not used. Groups may not be nested, as in slash commands.
+	Subcommand struct {
+		Name, HelpText string
+		Arguments      []Argument
+		Handler        func(ctx *Context)
+		Subcommands    []*Subcommand
+
+		// Permissions override the permissions of the parent command if set
+		Permissions *CommandPermissions
+	}
+
+	// subcommandHandler dispatches a subcommand like a Command, with the
+	// settings of its parent
+	subcommandHandler struct {
+		parent   Command
+		sub      *Subcommand
+		settings CommandSettings
+	}
+)
+
+// newSubcommandHandler wraps a subcommand for dispatch under its parent
+func newSubcommandHandler(parent Command, sub *Subcommand) *subcommandHandler {
+	settings := *parent.Settings()
+	settings.HelpText = sub.HelpText
+	settings.Arguments = sub.Arguments
+	return &subcommandHandler{parent: parent, sub: sub, settings: settings}
+}
+
+func (h *subcommandHandler) Init(*Mux)                    {}
+func (h *subcommandHandler) Handle(ctx *Context)          { h.sub.Handler(ctx) }
+func (h *subcommandHandler) HandleHelp(ctx *Context) bool { return h.parent.HandleHelp(ctx) }
+func (h *subcommandHandler) Settings() *CommandSettings   { return &h.settings }
+
+func (h *subcommandHandler) Permissions() *CommandPermissions {
+	if h.sub.Permissions != nil {
+		return h.sub.Permissions
+	}
+	return h.parent.Permissions()
+}
+
+// findSubcommand returns the subcommand with the name
+func findSubcommand(subs []*Subcommand, name string) *Subcommand {
+	for _, s := range subs {
+		if strings.EqualFold(s.Name, name) {
+			return s
+		}
+	}
+	return nil
+}
+
+// resolveSubcommand matches the leading arguments of a message to the
+// subcommands of the handler. The matched subcommand is returned as the
+// handler, and its names are taken off the arguments.
+func resolveSubcommand(ctx *Context, handler Command) Command {
+	parent, ok := handler.(ParentCommand)
+	if !ok {
+		return handler
+	}
+
+	subs := parent.Subcommands()
+	var path []string
+	for len(ctx.Arguments) > len(path) {
+		sub := findSubcommand(subs, ctx.Arguments[len(path)])
+		if sub == nil {
+			break
+		}
+		path = append(path, strings.ToLower(sub.Name))
+
+		if len(sub.Subcommands) == 0 {
+			if sub.Handler == nil {
+				break
+			}
+			ctx.Subcommand = strings.Join(path, " ")
+			ctx.Arguments = ctx.Arguments[len(path):]
+			return newSubcommandHandler(handler, sub)
+		}
+		subs = sub.Subcommands
+	}
+	return handler
+}
+
+// slashSubcommand resolves the subcommand of a slash command from its
+// options, returning the handler and the options of the subcommand
+func slashSubcommand(
+	ctx *Context,
+	handler Command,
+	options []*discordgo.ApplicationCommandInteractionDataOption,
+) (Command, []*discordgo.ApplicationCommandInteractionDataOption) {
+	parent, ok := handler.(ParentCommand)
+	if !ok {
+		return handler, options
+	}
+
+	subs := parent.Subcommands()
+	var path []string
+	for len(options) == 1 {
+		o := options[0]
+		if o.Type != discordgo.ApplicationCommandOptionSubCommand &&
+			o.Type != discordgo.ApplicationCommandOptionSubCommandGroup {
+			break
+		}
+
+		sub := findSubcommand(subs, o.Name)
+		if sub == nil {
+			break
+		}
+		path = append(path, o.Name)
+		options = o.Options
+
+		if o.Type == discordgo.ApplicationCommandOptionSubCommand {
+			if sub.Handler == nil {
+				break
+			}
+			ctx.Subcommand = strings.Join(path, " ")
+			return newSubcommandHandler(handler, sub), options
+		}
+		subs = sub.Subcommands
+	}
+	return handler, options
+}
+
+// subcommandOptions converts subcommands into application command options.
+// Groups hold their subcommands; deeper nesting is dropped.
+func subcommandOptions(
+	subs []*Subcommand,
+	depth int,
+) []*discordgo.ApplicationCommandOption {
+	var options []*discordgo.ApplicationCommandOption
+	for _, s := range subs {
+		option := &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        strings.ToLower(s.Name),
+			Description: appDescription(s.HelpText),
+		}
+
+		if len(s.Subcommands) > 0 {
+			if depth > 0 {
+				continue
+			}
+			option.Type = discordgo.ApplicationCommandOptionSubCommandGroup
+			option.Options = subcommandOptions(s.Subcommands, depth+1)
+		} else {
+			option.Options = appOptions(s.Arguments)
+		}
+		options = append(options, option)
+	}
+	return options
+}
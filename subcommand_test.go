@@ -0,0 +1,43 @@
+package disgomux
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSubcommandOptions(t *testing.T) {
+	handler := func(ctx *Context) {}
+	options := subcommandOptions([]*Subcommand{
+		{Name: "Get", HelpText: "Get a setting", Handler: handler,
+			Arguments: []Argument{{Name: "key", Required: true}}},
+		{Name: "role", HelpText: "Manage roles", Subcommands: []*Subcommand{
+			{Name: "add", HelpText: "Add a role", Handler: handler},
+			{Name: "deep", HelpText: "Too deep", Subcommands: []*Subcommand{
+				{Name: "deeper", HelpText: "Deeper", Handler: handler},
+			}},
+		}},
+	}, 0)
+
+	if len(options) != 2 {
+		t.Fatalf("%d options, want 2", len(options))
+	}
+
+	get := options[0]
+	if get.Type != discordgo.ApplicationCommandOptionSubCommand ||
+		get.Name != "get" || len(get.Options) != 1 ||
+		get.Options[0].Name != "key" || !get.Options[0].Required {
+		t.Errorf("get option %+v", get)
+	}
+
+	role := options[1]
+	if role.Type != discordgo.ApplicationCommandOptionSubCommandGroup {
+		t.Errorf("role is not a group: %+v", role)
+	}
+
+	/* Groups can't be nested in slash commands */
+	if len(role.Options) != 1 || role.Options[0].Name != "add" ||
+		role.Options[0].Type != discordgo.ApplicationCommandOptionSubCommand {
+		t.Errorf("role options %+v, want only add", role.Options)
+	}
+}
@@ -0,0 +1,206 @@
+package disgomux
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TagCommand is a built-in command for managing simple commands ("tags") at
+// runtime:
+//
+//	!tag add <name> <content>
+//	!tag edit <name> <content>
+//	!tag remove <name>
+//	!tag list
+//
+// The content of a tag is sent as is, except for the placeholders {user} (a
+// mention of the invoker), {username}, {guild}, {channel} (a mention of the
+// channel) and {args}. Unlike other simple commands, tags are not templates:
+// anyone allowed to manage them could otherwise read the invocation through
+// the template.
+//
+// Tags are scoped to the guild they are created in (or global when managed from
+// a DM). Access is restricted by the permissions supplied to NewTagCommand.
+// Only tags can be edited or removed, not the simple commands registered by
+// other means. Tags are persisted to the store set with Mux.UseSimpleStore(), if any.
+type TagCommand struct {
+	mux         *Mux
+	permissions *CommandPermissions
+}
+
+// NewTagCommand creates a tag command restricted to the given permissions, or
+// to members with Manage Messages if nil
+func NewTagCommand(permissions *CommandPermissions) *TagCommand {
+	if permissions == nil {
+		permissions = &CommandPermissions{
+			Permissions: discordgo.PermissionManageMessages,
+		}
+	}
+	return &TagCommand{permissions: permissions}
+}
+
+// Init stores the multiplexer the tags are managed on
+func (t *TagCommand) Init(m *Mux) {
+	t.mux = m
+}
+
+// Handle dispatches the tag subcommands
+func (t *TagCommand) Handle(ctx *Context) {
+	if t.mux == nil || len(ctx.Arguments) == 0 {
+		t.HandleHelp(ctx)
+		return
+	}
+
+	args := ctx.Arguments[1:]
+	switch strings.ToLower(ctx.Arguments[0]) {
+	case "add":
+		t.add(ctx, args)
+	case "edit":
+		t.edit(ctx, args)
+	case "remove", "delete":
+		t.remove(ctx, args)
+	case "list":
+		t.list(ctx)
+	default:
+		t.HandleHelp(ctx)
+	}
+}
+
+// HandleHelp sends the usage of the tag command
+func (t *TagCommand) HandleHelp(ctx *Context) bool {
+	ctx.ChannelSendf(
+		"%[1]s `%[2]stag add <name> <content>`, `%[2]stag edit <name> "+
+			"<content>`, `%[2]stag remove <name>`, `%[2]stag list`",
+		ctx.mux.localize(ctx, MessageTagUsage, "Usage:"), ctx.Prefix,
+	)
+	return true
+}
+
+// reply sends a localized text about the tag with the name
+func (t *TagCommand) reply(ctx *Context, key, fallback, name string) {
+	ctx.ChannelSend(t.mux.localize(ctx, key, fallback) + " `" + name + "`")
+}
+
+// replySaved confirms a change to the tag with the name, warning if it could
+// not be persisted
+func (t *TagCommand) replySaved(
+	ctx *Context,
+	key, fallback, name string,
+	err error,
+) {
+	text := t.mux.localize(ctx, key, fallback) + " `" + name + "`"
+	if err != nil {
+		text += "\n" + t.mux.localize(
+			ctx, MessageTagNotSaved, "The change could not be saved.",
+		)
+	}
+	ctx.ChannelSend(text)
+}
+
+// Settings returns the tag command settings
+func (t *TagCommand) Settings() *CommandSettings {
+	return &CommandSettings{
+		Command:  "tag",
+		HelpText: "Manage simple commands",
+	}
+}
+
+// Permissions returns the tag command permissions
+func (t *TagCommand) Permissions() *CommandPermissions {
+	return t.permissions
+}
+
+func (t *TagCommand) add(ctx *Context, args []string) {
+	if len(args) < 2 {
+		t.HandleHelp(ctx)
+		return
+	}
+
+	name := strings.ToLower(args[0])
+	guildID := ctx.Message.GuildID
+	if _, ok := t.mux.command(name); ok {
+		t.reply(ctx, MessageTagIsCommand, "There is already a command", name)
+		return
+	}
+	if _, ok := t.mux.resolveSimple(guildID, name); ok {
+		t.reply(ctx, MessageTagExists, "There is already a tag", name)
+		return
+	}
+
+	c := SimpleCommand{
+		Command: name,
+		GuildID: guildID,
+		Content: strings.Join(args[1:], " "),
+		Tag:     true,
+	}
+
+	t.mux.RegisterSimple(c)
+	err := t.mux.persistSimple(c)
+	t.replySaved(ctx, MessageTagAdded, "Added the tag", name, err)
+}
+
+func (t *TagCommand) edit(ctx *Context, args []string) {
+	if len(args) < 2 {
+		t.HandleHelp(ctx)
+		return
+	}
+
+	name := strings.ToLower(args[0])
+	c, ok := t.tag(ctx, name)
+	if !ok {
+		return
+	}
+
+	c.Content = strings.Join(args[1:], " ")
+	c.Contents = nil
+	t.mux.RegisterSimple(c)
+	err := t.mux.persistSimple(c)
+	t.replySaved(ctx, MessageTagUpdated, "Updated the tag", c.Command, err)
+}
+
+func (t *TagCommand) remove(ctx *Context, args []string) {
+	if len(args) < 1 {
+		t.HandleHelp(ctx)
+		return
+	}
+
+	name := strings.ToLower(args[0])
+	c, ok := t.tag(ctx, name)
+	if !ok {
+		return
+	}
+
+	t.mux.UnregisterGuildSimple(c.GuildID, c.Command)
+	err := t.mux.forgetSimple(c.GuildID, c.Command)
+	t.replySaved(ctx, MessageTagRemoved, "Removed the tag", c.Command, err)
+}
+
+func (t *TagCommand) list(ctx *Context) {
+	names := t.mux.GuildSimpleNames(ctx.Message.GuildID)
+	if len(names) == 0 {
+		ctx.ChannelSend(t.mux.localize(ctx, MessageNoTags, "There are no tags."))
+		return
+	}
+
+	ctx.ChannelSendf(
+		"%s `%s`",
+		t.mux.localize(ctx, MessageTagList, "Tags:"),
+		strings.Join(names, "`, `"),
+	)
+}
+
+// tag looks up the tag to edit or remove, telling the user if there is none.
+// Simple commands which are not tags are left alone.
+func (t *TagCommand) tag(ctx *Context, name string) (SimpleCommand, bool) {
+	c, ok := t.mux.GuildSimple(ctx.Message.GuildID, name)
+	if !ok {
+		t.reply(ctx, MessageTagMissing, "There is no tag", name)
+		return c, false
+	}
+	if !c.Tag {
+		t.reply(ctx, MessageTagNotTag, "Only tags can be changed here, not", name)
+		return c, false
+	}
+	return c, true
+}
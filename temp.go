@@ -0,0 +1,85 @@
+package disgomux
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// temporaries tracks messages scheduled for deletion, so they can still
+	// be cleaned up when the mux shuts down before their time is up
+	temporaries struct {
+		mu      sync.Mutex
+		pending map[string]*temporary
+	}
+
+	temporary struct {
+		timer  Timer
+		delete func()
+	}
+)
+
+// ChannelSendTemp sends a message to the current channel which is deleted
+// after ttl. The deletion is managed by the mux, so it happens even once the
+// handler has returned.
+func (ctx *Context) ChannelSendTemp(
+	content string,
+	ttl time.Duration,
+) (*discordgo.Message, error) {
+	msg, err := ctx.ChannelSend(content)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.mux.temps.deleteAfter(ctx.mux.clock(), ctx.API(), msg, ttl)
+	return msg, nil
+}
+
+// DeleteAfter schedules deletion of any message after ttl
+func (ctx *Context) DeleteAfter(msg *discordgo.Message, ttl time.Duration) {
+	ctx.mux.temps.deleteAfter(ctx.mux.clock(), ctx.API(), msg, ttl)
+}
+
+// deleteAfter schedules msg to be deleted after ttl
+func (t *temporaries) deleteAfter(
+	clock Clock,
+	session Session,
+	msg *discordgo.Message,
+	ttl time.Duration,
+) {
+	tmp := &temporary{}
+	tmp.delete = func() {
+		t.mu.Lock()
+		delete(t.pending, msg.ID)
+		t.mu.Unlock()
+
+		session.ChannelMessageDelete(msg.ChannelID, msg.ID)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending == nil {
+		t.pending = make(map[string]*temporary)
+	}
+	t.pending[msg.ID] = tmp
+	tmp.timer = clock.AfterFunc(ttl, tmp.delete)
+}
+
+// flush deletes all pending messages immediately
+func (t *temporaries) flush() {
+	t.mu.Lock()
+	var pending []*temporary
+	for _, tmp := range t.pending {
+		if tmp.timer.Stop() {
+			pending = append(pending, tmp)
+		}
+	}
+	t.mu.Unlock()
+
+	for _, tmp := range pending {
+		tmp.delete()
+	}
+}
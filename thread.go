@@ -0,0 +1,94 @@
+package disgomux
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// threadArchiveMinutes is how long threads created by the mux stay active
+// without new messages
+const threadArchiveMinutes = 60
+
+// threadRedirect lazily creates the thread an invocation's responses are
+// redirected to
+type threadRedirect struct {
+	mu       sync.Mutex
+	name     string
+	threadID string
+}
+
+// IsThread reports whether the invocation is in a thread, including a post of a
+// forum channel
+func (ctx *Context) IsThread() bool {
+	channel, err := ctx.Channel()
+	return err == nil && channel.IsThread()
+}
+
+// IsForumPost reports whether the invocation is in a post of a forum channel
+func (ctx *Context) IsForumPost() bool {
+	if !ctx.IsThread() {
+		return false
+	}
+	parent, err := ctx.ParentChannel()
+	return err == nil && parent.Type == discordgo.ChannelTypeGuildForum
+}
+
+// ParentChannel returns the channel the thread of the invocation belongs to,
+// e.g. the forum channel of a post, resolved from the session state or else
+// fetched from Discord. Fails if the invocation is not in a thread.
+func (ctx *Context) ParentChannel() (*discordgo.Channel, error) {
+	channel, err := ctx.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if !channel.IsThread() || channel.ParentID == "" {
+		return nil, fmt.Errorf("Channel %s is not a thread", channel.ID)
+	}
+	return lookupChannel(ctx.Session.State, ctx.API(), channel.ParentID)
+}
+
+// CreateThread starts a thread from the invoking message and returns it
+func (ctx *Context) CreateThread(name string) (*discordgo.Channel, error) {
+	return ctx.API().MessageThreadStart(
+		ctx.Message.ChannelID, ctx.Message.ID, name, threadArchiveMinutes,
+	)
+}
+
+// ReplyInThread redirects all further helper sends of this Context into a
+// thread with the specified name, started from the invoking message once
+// something is sent
+func (ctx *Context) ReplyInThread(name string) {
+	ctx.thread.mu.Lock()
+	defer ctx.thread.mu.Unlock()
+
+	ctx.thread.name = name
+}
+
+// channelID returns the channel the helpers of this Context send to: the
+// output channel if any, else the redirect thread, created if needed. Falls back to the invoking channel if the
+// thread can't be created.
+func (ctx *Context) channelID() string {
+	if channelID := ctx.outputChannel(); channelID != "" {
+		return channelID
+	}
+
+	ctx.thread.mu.Lock()
+	defer ctx.thread.mu.Unlock()
+
+	if ctx.thread.threadID != "" {
+		return ctx.thread.threadID
+	}
+	if ctx.thread.name == "" {
+		return ctx.Message.ChannelID
+	}
+
+	channel, err := ctx.CreateThread(ctx.thread.name)
+	if err != nil {
+		return ctx.Message.ChannelID
+	}
+
+	ctx.thread.threadID = channel.ID
+	return channel.ID
+}
@@ -0,0 +1,54 @@
+package disgomux
+
+import "context"
+
+type (
+	// Tracer starts the spans of an invocation: one for the whole
+	// invocation, with children for each middleware, the permission check
+	// and the handler. attributes describe the invocation (guild, channel,
+	// user, command). An OpenTelemetry trace.Tracer is adapted by starting a
+	// span with the attributes, and recording the error before ending it.
+	// Implementations must be safe for concurrent use.
+	Tracer interface {
+		Start(
+			parent context.Context,
+			name string,
+			attributes map[string]string,
+		) (context.Context, Span)
+	}
+
+	// Span is a traced operation started by a Tracer
+	Span interface {
+		// End finishes the span, with the error the operation failed with
+		End(err error)
+	}
+)
+
+// Span names
+const (
+	spanInvocation  = "disgomux.invocation"
+	spanMiddleware  = "disgomux.middleware"
+	spanPermissions = "disgomux.permissions"
+	spanHandler     = "disgomux.handler"
+)
+
+// noopSpan is used when there is no tracer
+type noopSpan struct{}
+
+func (noopSpan) End(error) {}
+
+// SetTracer sets the tracer invocations are traced with. Must be called before
+// Mux.Handle().
+func (m *Mux) SetTracer(tracer Tracer) {
+	m.tracer = tracer
+}
+
+// startSpan starts a span as a child of the context of the invocation,
+// returning the context of the new span
+func (m *Mux) startSpan(ctx *Context, name string) (context.Context, Span) {
+	parent := ctx.Context()
+	if m.tracer == nil {
+		return parent, noopSpan{}
+	}
+	return m.tracer.Start(parent, name, ctx.fields())
+}
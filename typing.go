@@ -0,0 +1,49 @@
+package disgomux
+
+import (
+	"sync"
+	"time"
+)
+
+// typingInterval is how often the typing indicator is refreshed. Discord shows
+// it for 10 seconds after each trigger.
+const typingInterval = 8 * time.Second
+
+// typing keeps the typing indicator of a channel alive until stopped
+type typing struct {
+	stop chan struct{}
+	once sync.Once
+}
+
+// Typing shows the typing indicator in the current channel until the handler
+// returns. Calling it more than once has no extra effect.
+func (ctx *Context) Typing() {
+	if ctx.typing != nil {
+		return
+	}
+
+	t := &typing{stop: make(chan struct{})}
+	ctx.typing = t
+
+	go func() {
+		ticker := time.NewTicker(typingInterval)
+		defer ticker.Stop()
+
+		for {
+			ctx.API().ChannelTyping(ctx.Message.ChannelID)
+
+			select {
+			case <-ticker.C:
+			case <-t.stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopTyping stops the typing indicator, if it was started
+func (ctx *Context) stopTyping() {
+	if ctx.typing != nil {
+		ctx.typing.once.Do(func() { close(ctx.typing.stop) })
+	}
+}
@@ -0,0 +1,275 @@
+package disgomux
+
+import (
+	"crypto/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ChallengeKind is the kind of challenge a Verification issues
+type ChallengeKind int
+
+// Challenge kinds
+const (
+	// ChallengeReact asks the user to react to the challenge with an emoji
+	ChallengeReact ChallengeKind = iota
+
+	// ChallengeCode asks the user to type back a random code
+	ChallengeCode
+
+	// ChallengeQuestion asks the user a question with known answers
+	ChallengeQuestion
+)
+
+// codeAlphabet holds the characters of challenge codes, without the ones
+// easily mistaken for each other (0 and O, 1 and I)
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Verification challenges a user to prove they are human, e.g. members
+// joining a guild (see Mux.VerifyMembers()) or users invoking a verify
+// command, and runs a callback on the outcome
+type Verification struct {
+	Challenge ChallengeKind
+
+	// Prompt introduces the challenge. Defaults to "Please verify that you
+	// are human.", or its translation
+	Prompt string
+
+	// Emoji is the emoji to react with for ChallengeReact. Defaults to "✅".
+	Emoji string
+
+	// CodeLength is the length of the code for ChallengeCode. Defaults to
+	// six.
+	CodeLength int
+
+	// Question is asked for ChallengeQuestion. Any of the Answers passes,
+	// whatever the case.
+	Question string
+	Answers  []string
+
+	// ChannelID is where the challenge is issued. Defaults to the invoking
+	// channel, or to a DM with the user if DM is set or there is no invoking
+	// channel, as for members joining.
+	ChannelID string
+	DM        bool
+
+	// Timeout is how long each attempt waits for an answer. Defaults to five
+	// minutes. An attempt timing out fails the verification.
+	Timeout time.Duration
+
+	// Retries is how many attempts the user gets. Defaults to three.
+	Retries int
+
+	// OnSuccess is called once the user passes, e.g. AssignRole(). Its error
+	// is returned by Run().
+	OnSuccess func(ctx *Context) error
+
+	// OnFailure is called with ErrVerificationFailed or ErrTimeout once the
+	// user fails, e.g. Kick()
+	OnFailure func(ctx *Context, err error)
+}
+
+// Run challenges the author of the invocation until they pass or run out of
+// attempts. ErrVerificationFailed is returned if they give wrong answers, and
+// ErrTimeout if an attempt goes unanswered.
+func (v *Verification) Run(ctx *Context) error {
+	channelID, err := v.channel(ctx)
+	if err != nil {
+		return err
+	}
+
+	timeout := v.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+
+	retries := v.Retries
+	if retries <= 0 {
+		retries = 3
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = v.challenge(ctx, channelID, timeout)
+		if err == nil {
+			ctx.send(channelID, &discordgo.MessageSend{
+				Content: ctx.mux.localize(
+					ctx, MessageVerified, "You're verified, welcome!",
+				),
+			})
+			if v.OnSuccess != nil {
+				return v.OnSuccess(ctx)
+			}
+			return nil
+		}
+		if err != ErrVerificationFailed || attempt >= retries {
+			break
+		}
+
+		text := ctx.mux.localize(
+			ctx, MessageVerifyRetry, "That's not right. Attempts left:",
+		)
+		ctx.send(channelID, &discordgo.MessageSend{
+			Content: text + " " + strconv.Itoa(retries-attempt),
+		})
+	}
+
+	if err == ErrVerificationFailed || err == ErrTimeout {
+		ctx.send(channelID, &discordgo.MessageSend{
+			Content: ctx.mux.localize(
+				ctx, MessageVerifyFailed, "Verification failed.",
+			),
+		})
+		if v.OnFailure != nil {
+			v.OnFailure(ctx, err)
+		}
+	}
+	return err
+}
+
+// channel returns the channel the challenge is issued in
+func (v *Verification) channel(ctx *Context) (string, error) {
+	if v.ChannelID != "" {
+		return v.ChannelID, nil
+	}
+	if v.DM || ctx.Message.ChannelID == "" {
+		return ctx.dmChannel()
+	}
+	return ctx.Message.ChannelID, nil
+}
+
+// challenge issues one attempt of the challenge and waits for the answer.
+// Wrong answers fail with ErrVerificationFailed.
+func (v *Verification) challenge(
+	ctx *Context,
+	channelID string,
+	timeout time.Duration,
+) error {
+	prompt := v.Prompt
+	if prompt == "" {
+		prompt = ctx.mux.localize(
+			ctx, MessageVerifyPrompt, "Please verify that you are human.",
+		)
+	}
+
+	switch v.Challenge {
+	case ChallengeReact:
+		emoji := v.Emoji
+		if emoji == "" {
+			emoji = "✅"
+		}
+
+		msg, err := ctx.send(channelID, &discordgo.MessageSend{
+			Content: prompt + " " + ctx.mux.localize(
+				ctx, MessageVerifyReact, "React below with",
+			) + " " + emoji,
+		})
+		if err != nil {
+			return err
+		}
+		ctx.API().MessageReactionAdd(channelID, msg.ID, emoji)
+
+		userID := ctx.Message.Author.ID
+		_, err = ctx.WaitForReaction(msg.ID, timeout, func(r *discordgo.MessageReaction) bool {
+			return r.UserID == userID &&
+				(r.Emoji.Name == emoji || r.Emoji.APIName() == emoji)
+		})
+		return err
+
+	case ChallengeCode:
+		code, err := challengeCode(v.CodeLength)
+		if err != nil {
+			return err
+		}
+		return v.ask(ctx, channelID, timeout,
+			prompt+" "+ctx.mux.localize(
+				ctx, MessageVerifyCode, "Type this code:",
+			)+" `"+code+"`", []string{code},
+		)
+
+	default:
+		return v.ask(ctx, channelID, timeout,
+			prompt+"\n"+v.Question, v.Answers,
+		)
+	}
+}
+
+// ask sends the question and checks the next message of the user against the
+// answers
+func (v *Verification) ask(
+	ctx *Context,
+	channelID string,
+	timeout time.Duration,
+	question string,
+	answers []string,
+) error {
+	_, err := ctx.send(channelID, &discordgo.MessageSend{Content: question})
+	if err != nil {
+		return err
+	}
+
+	reply, err := ctx.awaitMessage(channelID, timeout, nil)
+	if err != nil {
+		return err
+	}
+
+	answer := strings.TrimSpace(reply.Content)
+	for _, a := range answers {
+		if strings.EqualFold(answer, strings.TrimSpace(a)) {
+			return nil
+		}
+	}
+	return ErrVerificationFailed
+}
+
+// challengeCode generates a random code of the length (six if zero)
+func challengeCode(length int) (string, error) {
+	if length <= 0 {
+		length = 6
+	}
+
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+	return string(b), nil
+}
+
+// VerifyMembers challenges members joining a guild with the verification,
+// through OnMemberJoin(). Bots are not challenged.
+func (m *Mux) VerifyMembers(v *Verification) {
+	m.OnMemberJoin(func(ctx *Context) {
+		if ctx.Message.Author.Bot {
+			return
+		}
+		v.Run(ctx)
+	})
+}
+
+// AssignRole returns a Verification.OnSuccess callback giving the verified
+// member the role
+func AssignRole(roleID string) func(ctx *Context) error {
+	return func(ctx *Context) error {
+		return ctx.API().GuildMemberRoleAdd(
+			ctx.Message.GuildID, ctx.Message.Author.ID, roleID,
+		)
+	}
+}
+
+// Kick returns a Verification.OnFailure callback kicking the member who failed
+// from the guild, with the reason in the audit log
+func Kick(reason string) func(ctx *Context, err error) {
+	return func(ctx *Context, err error) {
+		kickErr := ctx.API().GuildMemberDeleteWithReason(
+			ctx.Message.GuildID, ctx.Message.Author.ID, reason,
+		)
+		if kickErr != nil {
+			ctx.mux.reportCtx(ctx, kickErr)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package disgomux
+
+import "github.com/bwmarrin/discordgo"
+
+// VoiceState returns the voice state of the author of the invoking message,
+// from the session state. Fails with ErrNotInVoice if they are not in a voice
+// channel of the guild, or the invocation is in a DM.
+func (ctx *Context) VoiceState() (*discordgo.VoiceState, error) {
+	if ctx.Message.GuildID == "" || ctx.Session == nil || ctx.Session.State == nil {
+		return nil, ErrNotInVoice
+	}
+
+	state, err := ctx.Session.State.VoiceState(
+		ctx.Message.GuildID, ctx.Message.Author.ID,
+	)
+	if err != nil || state.ChannelID == "" {
+		return nil, ErrNotInVoice
+	}
+	return state, nil
+}
+
+// UsersInVoiceWith returns the other users in the voice channel of the author
+// of the invoking message, bots included, from the session state. Fails with
+// ErrNotInVoice as VoiceState().
+func (ctx *Context) UsersInVoiceWith() ([]*discordgo.User, error) {
+	own, err := ctx.VoiceState()
+	if err != nil {
+		return nil, err
+	}
+
+	state := ctx.Session.State
+	guild, err := state.Guild(own.GuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	state.RLock()
+	var others []*discordgo.VoiceState
+	for _, vs := range guild.VoiceStates {
+		if vs.ChannelID == own.ChannelID && vs.UserID != own.UserID {
+			others = append(others, vs)
+		}
+	}
+	state.RUnlock()
+
+	users := make([]*discordgo.User, 0, len(others))
+	for _, vs := range others {
+		member := vs.Member
+		if member == nil || member.User == nil {
+			member, _ = state.Member(vs.GuildID, vs.UserID)
+		}
+		if member != nil && member.User != nil {
+			users = append(users, member.User)
+			continue
+		}
+		users = append(users, &discordgo.User{ID: vs.UserID})
+	}
+	return users, nil
+}
+
+// RequireVoice is an Inhibitor refusing invocations by users who are not in a
+// voice channel of the guild, e.g. for music commands
+func RequireVoice() Inhibitor {
+	return func(ctx *Context) error {
+		if _, err := ctx.VoiceState(); err != nil {
+			return &InhibitError{
+				Reason: ctx.mux.localize(
+					ctx, MessageNotInVoice,
+					"You must be in a voice channel to use that command.",
+				),
+				Err: err,
+			}
+		}
+		return nil
+	}
+}
+
+// RequireSameVoice is an Inhibitor refusing invocations by users who are not in
+// the voice channel the bot is in, if it is in one, e.g. so only listeners
+// may skip a song
+func RequireSameVoice() Inhibitor {
+	return func(ctx *Context) error {
+		own, err := ctx.VoiceState()
+		if err != nil {
+			return RequireVoice()(ctx)
+		}
+
+		bot, err := ctx.Session.State.VoiceState(
+			ctx.Message.GuildID, ctx.Session.State.User.ID,
+		)
+		if err != nil || bot.ChannelID == "" || bot.ChannelID == own.ChannelID {
+			return nil
+		}
+		return &InhibitError{
+			Reason: ctx.mux.localize(
+				ctx, MessageNotInSameVoice,
+				"You must be in my voice channel to use that command.",
+			),
+			Err: ErrNotInVoice,
+		}
+	}
+}
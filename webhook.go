@@ -0,0 +1,84 @@
+package disgomux
+
+import "github.com/bwmarrin/discordgo"
+
+// webhookName is the name of the webhooks the mux creates for sending
+const webhookName = "disgomux"
+
+// WebhookSend sends content to the current channel through a webhook, shown
+// with the specified username and avatar. The webhook is created the first time
+// it is needed in a channel (which requires the Manage Webhooks permission) and
+// reused afterwards.
+func (ctx *Context) WebhookSend(
+	username, avatarURL, content string,
+) (*discordgo.Message, error) {
+	return ctx.WebhookSendComplex(&discordgo.WebhookParams{
+		Content:   content,
+		Username:  username,
+		AvatarURL: avatarURL,
+	})
+}
+
+// WebhookSendAs sends content through a webhook, shown with the name and avatar
+// of the specified user, e.g. for quoting them
+func (ctx *Context) WebhookSendAs(
+	user *discordgo.User,
+	content string,
+) (*discordgo.Message, error) {
+	return ctx.WebhookSend(user.Username, user.AvatarURL(""), content)
+}
+
+// WebhookSendComplex sends arbitrary webhook parameters to the current channel.
+// The mux's allowed mentions policy is applied unless params sets its own.
+func (ctx *Context) WebhookSendComplex(
+	params *discordgo.WebhookParams,
+) (*discordgo.Message, error) {
+	hook, err := ctx.webhook(ctx.Message.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.AllowedMentions == nil {
+		params.AllowedMentions = ctx.allowedMentions()
+	}
+
+	msg, err := ctx.API().WebhookExecute(hook.ID, hook.Token, true, params)
+	if err != nil {
+		/* The webhook may have been deleted, so don't reuse it */
+		ctx.mux.webhooks.Delete(ctx.Message.ChannelID)
+		return nil, err
+	}
+
+	ctx.recordResponse(msg)
+	return msg, nil
+}
+
+// webhook returns the mux's webhook for the channel, finding or creating it
+// if none is cached
+func (ctx *Context) webhook(channelID string) (*discordgo.Webhook, error) {
+	if hook, ok := ctx.mux.webhooks.Load(channelID); ok {
+		return hook.(*discordgo.Webhook), nil
+	}
+
+	hooks, err := ctx.API().ChannelWebhooks(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	botID := ctx.Session.State.User.ID
+	for _, hook := range hooks {
+		if hook.Name == webhookName && hook.Token != "" &&
+			hook.User != nil && hook.User.ID == botID {
+			ctx.mux.webhooks.Store(channelID, hook)
+			return hook, nil
+		}
+	}
+
+	hook, err := ctx.API().WebhookCreate(channelID, webhookName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.mux.webhooks.Store(channelID, hook)
+	return hook, nil
+}
@@ -0,0 +1,199 @@
+package disgomux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type (
+	// WizardStep is a single question asked by a Wizard
+	WizardStep struct {
+		// Name is the key the answer is stored under
+		Name     string
+		Question string
+
+		// Timeout overrides the wizard's timeout for this step
+		Timeout time.Duration
+
+		// Validate, if set, checks an answer. The error text is sent to the
+		// user and the question asked again.
+		Validate func(answer string) error
+	}
+
+	// Wizard asks the invoking user a series of questions, then optionally
+	// confirms the answers with a summary before returning them
+	Wizard struct {
+		Steps []WizardStep
+
+		// Timeout is how long each step waits for an answer. Defaults to one
+		// minute.
+		Timeout time.Duration
+
+		// Retries is how many invalid answers a step accepts before the wizard
+		// gives up. Defaults to three.
+		Retries int
+
+		// CancelWord aborts the wizard when sent as an answer. Defaults to
+		// "cancel".
+		CancelWord string
+
+		// Confirm asks the user to confirm a summary of the answers
+		Confirm bool
+	}
+
+	// Menu asks the invoking user to pick one of several options by number
+	Menu struct {
+		Title   string
+		Options []string
+
+		// Timeout is how long to wait for a choice. Defaults to one minute.
+		Timeout time.Duration
+
+		// CancelWord aborts the menu when sent instead of a choice. Defaults
+		// to "cancel".
+		CancelWord string
+	}
+)
+
+// Run asks each step in order and returns the answers keyed by step name.
+// ErrCancelled is returned if the user cancels or declines the summary, and
+// ErrTimeout if a step goes unanswered.
+func (w *Wizard) Run(ctx *Context) (map[string]string, error) {
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+
+	retries := w.Retries
+	if retries <= 0 {
+		retries = 3
+	}
+
+	cancel := w.CancelWord
+	if cancel == "" {
+		cancel = "cancel"
+	}
+
+	answers := make(map[string]string, len(w.Steps))
+	for _, step := range w.Steps {
+		stepTimeout := timeout
+		if step.Timeout > 0 {
+			stepTimeout = step.Timeout
+		}
+
+		answer, err := askStep(ctx, step, stepTimeout, retries, cancel)
+		if err != nil {
+			return nil, err
+		}
+		answers[step.Name] = answer
+	}
+
+	if !w.Confirm {
+		return answers, nil
+	}
+
+	var sb strings.Builder
+	for _, step := range w.Steps {
+		fmt.Fprintf(&sb, "**%s**: %s\n", step.Name, answers[step.Name])
+	}
+	sb.WriteString("\n" + ctx.mux.localize(
+		ctx, MessageWizardConfirm, "Is this correct? (yes/no)",
+	))
+
+	reply, err := ctx.Prompt(sb.String(), timeout, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(reply.Content)) {
+	case "y", "yes":
+		return answers, nil
+	default:
+		return nil, ErrCancelled
+	}
+}
+
+// askStep asks a single question until it receives a valid answer
+func askStep(
+	ctx *Context,
+	step WizardStep,
+	timeout time.Duration,
+	retries int,
+	cancel string,
+) (string, error) {
+	question := step.Question
+	for attempt := 0; attempt < retries; attempt++ {
+		reply, err := ctx.Prompt(question, timeout, nil)
+		if err != nil {
+			return "", err
+		}
+
+		answer := strings.TrimSpace(reply.Content)
+		if strings.EqualFold(answer, cancel) {
+			return "", ErrCancelled
+		}
+
+		if step.Validate == nil {
+			return answer, nil
+		}
+
+		verr := step.Validate(answer)
+		if verr == nil {
+			return answer, nil
+		}
+		question = verr.Error() + "\n" + step.Question
+	}
+
+	ctx.ChannelSend(ctx.mux.localize(
+		ctx, MessageWizardRetries, "Too many invalid answers.",
+	))
+	return "", ErrCancelled
+}
+
+// Run posts the options and returns the index of the one the user picks.
+// ErrCancelled is returned if the user cancels, and ErrTimeout if no valid
+// choice arrives in time.
+func (mn *Menu) Run(ctx *Context) (int, error) {
+	timeout := mn.Timeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+
+	cancel := mn.CancelWord
+	if cancel == "" {
+		cancel = "cancel"
+	}
+
+	var sb strings.Builder
+	if mn.Title != "" {
+		sb.WriteString(mn.Title + "\n")
+	}
+	for i, o := range mn.Options {
+		fmt.Fprintf(&sb, "`%d` %s\n", i+1, o)
+	}
+
+	reply, err := ctx.Prompt(sb.String(), timeout, func(msg *discordgo.Message) bool {
+		answer := strings.TrimSpace(msg.Content)
+		if strings.EqualFold(answer, cancel) {
+			return true
+		}
+
+		n, err := strconv.Atoi(answer)
+		return err == nil && n >= 1 && n <= len(mn.Options)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	answer := strings.TrimSpace(reply.Content)
+	if strings.EqualFold(answer, cancel) {
+		return 0, ErrCancelled
+	}
+
+	n, _ := strconv.Atoi(answer)
+	return n - 1, nil
+}